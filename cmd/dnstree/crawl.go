@@ -0,0 +1,174 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of go-irchain.
+//
+// go-irchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-irchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-irchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/p2p/enr"
+	"github.com/irchain/go-irchain/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	bootnodesFlag = cli.StringFlag{
+		Name:  "bootnodes",
+		Usage: "comma separated hnode URLs to start the crawl from (defaults to the mainnet bootnodes)",
+	}
+	crawlTimeFlag = cli.DurationFlag{
+		Name:  "timeout",
+		Usage: "time to spend crawling",
+		Value: 30 * time.Minute,
+	}
+	listenAddrFlag = cli.StringFlag{
+		Name:  "addr",
+		Usage: "UDP listening address of the crawler",
+		Value: ":0",
+	}
+)
+
+var commandCrawl = cli.Command{
+	Name:      "crawl",
+	Usage:     "collect node records from the discovery network",
+	ArgsUsage: "<directory>",
+	Description: `
+Walk the discovery network starting from the bootstrap nodes and request the node
+record of every node found. The records replace the nodes.json of the tree
+directory, from which the tree can then be signed and published.
+`,
+	Flags: []cli.Flag{
+		bootnodesFlag,
+		crawlTimeFlag,
+		listenAddrFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() < 1 {
+			return fmt.Errorf("need tree directory as argument")
+		}
+		dir := ctx.Args().Get(0)
+
+		info, err := loadTreeInfo(dir)
+		if err != nil {
+			return err
+		}
+		urls := params.MainnetBootnodes
+		if ctx.IsSet(bootnodesFlag.Name) {
+			urls = strings.Split(ctx.String(bootnodesFlag.Name), ",")
+		}
+		bootnodes := make([]*discover.Node, 0, len(urls))
+		for _, url := range urls {
+			node, err := discover.ParseNode(strings.TrimSpace(url))
+			if err != nil {
+				return fmt.Errorf("invalid bootnode %q: %v", url, err)
+			}
+			bootnodes = append(bootnodes, node)
+		}
+		tab, err := startDiscovery(ctx.String(listenAddrFlag.Name), bootnodes)
+		if err != nil {
+			return err
+		}
+		defer tab.Close()
+
+		records := newCrawler(tab).run(ctx.Duration(crawlTimeFlag.Name))
+		if err := writeTreeDir(dir, info, records); err != nil {
+			return err
+		}
+		fmt.Printf("Crawled %d node records into %s\n", len(records), dir)
+		return nil
+	},
+}
+
+// startDiscovery creates a discovery v4 table with an ephemeral node key.
+func startDiscovery(addr string, bootnodes []*discover.Node) (*discover.Table, error) {
+	key, err := ecdsa.GenerateKey(crypto.S256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	laddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+	return discover.ListenUDP(conn, discover.Config{PrivateKey: key, Bootnodes: bootnodes})
+}
+
+// crawler collects the node records of all nodes reachable via discovery.
+type crawler struct {
+	tab     *discover.Table
+	asked   map[discover.NodeID]bool
+	records map[discover.NodeID]*enr.Record
+}
+
+func newCrawler(tab *discover.Table) *crawler {
+	return &crawler{
+		tab:     tab,
+		asked:   make(map[discover.NodeID]bool),
+		records: make(map[discover.NodeID]*enr.Record),
+	}
+}
+
+// run performs random lookups until the timeout expires, requesting the record
+// of every newly found node. The records are returned sorted by node ID.
+func (c *crawler) run(timeout time.Duration) []*enr.Record {
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		var target discover.NodeID
+		rand.Read(target[:])
+
+		found := 0
+		for _, n := range c.tab.Lookup(target) {
+			if c.asked[n.ID] {
+				continue
+			}
+			c.asked[n.ID] = true
+			found++
+
+			record, err := c.tab.RequestENR(n)
+			if err != nil {
+				log.Debug("Failed to retrieve node record", "id", n.ID, "err", err)
+				continue
+			}
+			c.records[n.ID] = record
+		}
+		log.Info("Crawling discovery network", "found", found, "records", len(c.records))
+		if found == 0 {
+			time.Sleep(time.Second)
+		}
+	}
+	ids := make([]discover.NodeID, 0, len(c.records))
+	for id := range c.records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].String() < ids[j].String() })
+
+	records := make([]*enr.Record, len(ids))
+	for i, id := range ids {
+		records[i] = c.records[id]
+	}
+	return records
+}
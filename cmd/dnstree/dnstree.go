@@ -0,0 +1,317 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of go-irchain.
+//
+// go-irchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-irchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-irchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/irchain/go-irchain/accounts/keystore"
+	"github.com/irchain/go-irchain/cmd/utils"
+	"github.com/irchain/go-irchain/console"
+	"github.com/irchain/go-irchain/p2p/dnsdisc"
+	"github.com/irchain/go-irchain/p2p/enr"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	nodesFile = "nodes.json"        // list of node records, e.g. the output of a crawl
+	infoFile  = "enrtree-info.json" // tree metadata: domain, sequence number, signature and links
+	zoneTTL   = 86400               // TTL of the published TXT records
+	txtMaxLen = 255                 // Maximum length of a single TXT character-string
+)
+
+var (
+	domainFlag = cli.StringFlag{
+		Name:  "domain",
+		Usage: "domain name of the tree",
+	}
+	seqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "sequence number of the tree (defaults to the previous one plus one)",
+	}
+	passphraseFlag = cli.StringFlag{
+		Name:  "passwordfile",
+		Usage: "the file that contains the passphrase for the keyfile",
+	}
+)
+
+var commandSync = cli.Command{
+	Name:      "sync",
+	Usage:     "download a DNS node list",
+	ArgsUsage: "<url> [<directory>]",
+	Description: `
+Download the tree at the given enrtree:// URL and store it in a tree directory.
+The directory defaults to the domain name of the tree.
+`,
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() < 1 {
+			return fmt.Errorf("need tree URL as argument")
+		}
+		url := ctx.Args().Get(0)
+		domain, _, err := dnsdisc.ParseURL(url)
+		if err != nil {
+			return err
+		}
+		dir := ctx.Args().Get(1)
+		if dir == "" {
+			dir = domain
+		}
+		client, err := dnsdisc.NewClient(dnsdisc.Config{})
+		if err != nil {
+			return err
+		}
+		t, err := client.SyncTree(url)
+		if err != nil {
+			return err
+		}
+		info := &treeInfo{
+			URL:       url,
+			Domain:    domain,
+			Seq:       t.Seq(),
+			Signature: t.Signature(),
+			Links:     t.Links(),
+		}
+		if err := writeTreeDir(dir, info, t.Nodes()); err != nil {
+			return err
+		}
+		fmt.Printf("Synced %d nodes and %d links to %s\n", len(t.Nodes()), len(t.Links()), dir)
+		return nil
+	},
+}
+
+var commandSign = cli.Command{
+	Name:      "sign",
+	Usage:     "sign a DNS node list",
+	ArgsUsage: "<directory> <keyfile>",
+	Description: `
+Build the tree from the node records in the directory's nodes.json and the links
+in its enrtree-info.json, and sign it with the given keyfile. The signature and
+the resulting enrtree:// URL are stored in enrtree-info.json.
+`,
+	Flags: []cli.Flag{
+		domainFlag,
+		seqFlag,
+		passphraseFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() < 2 {
+			return fmt.Errorf("need tree directory and keyfile as arguments")
+		}
+		dir, keyfile := ctx.Args().Get(0), ctx.Args().Get(1)
+		info, nodes, err := loadTreeDir(dir)
+		if err != nil {
+			return err
+		}
+		if ctx.IsSet(domainFlag.Name) {
+			info.Domain = ctx.String(domainFlag.Name)
+		}
+		if info.Domain == "" {
+			return fmt.Errorf("missing tree domain, use --%s", domainFlag.Name)
+		}
+		info.Seq++
+		if ctx.IsSet(seqFlag.Name) {
+			info.Seq = ctx.Uint(seqFlag.Name)
+		}
+		key := loadKey(ctx, keyfile)
+
+		t, err := dnsdisc.MakeTree(info.Seq, nodes, info.Links)
+		if err != nil {
+			return err
+		}
+		if info.URL, err = t.Sign(key.PrivateKey, info.Domain); err != nil {
+			return err
+		}
+		info.Signature = t.Signature()
+		if err := writeTreeDir(dir, info, nodes); err != nil {
+			return err
+		}
+		fmt.Println(info.URL)
+		return nil
+	},
+}
+
+var commandToZonefile = cli.Command{
+	Name:      "to-zonefile",
+	Usage:     "create a DNS zone file from a signed tree",
+	ArgsUsage: "<directory> [<output-file>]",
+	Description: `
+Verify the signature of the tree and print all TXT records needed to publish it
+in zone file format. The output can be served by any DNS server.
+`,
+	Action: func(ctx *cli.Context) error {
+		if ctx.NArg() < 1 {
+			return fmt.Errorf("need tree directory as argument")
+		}
+		info, nodes, err := loadTreeDir(ctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+		if info.URL == "" || info.Signature == "" {
+			return fmt.Errorf("tree is not signed")
+		}
+		_, pubkey, err := dnsdisc.ParseURL(info.URL)
+		if err != nil {
+			return err
+		}
+		t, err := dnsdisc.MakeTree(info.Seq, nodes, info.Links)
+		if err != nil {
+			return err
+		}
+		if err := t.SetSignature(pubkey, info.Signature); err != nil {
+			return fmt.Errorf("tree signature invalid: %v", err)
+		}
+		out := io.Writer(os.Stdout)
+		if file := ctx.Args().Get(1); file != "" {
+			f, err := os.Create(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			out = f
+		}
+		return writeZonefile(out, t.ToTXT(info.Domain))
+	},
+}
+
+// treeInfo is the content of the enrtree-info.json file of a tree directory.
+type treeInfo struct {
+	URL       string   `json:"url,omitempty"`
+	Domain    string   `json:"domain,omitempty"`
+	Seq       uint     `json:"seq"`
+	Signature string   `json:"signature,omitempty"`
+	Links     []string `json:"links,omitempty"`
+}
+
+// loadTreeInfo reads the tree metadata from a directory. A missing metadata
+// file is treated as an unsigned tree without links.
+func loadTreeInfo(dir string) (*treeInfo, error) {
+	info := new(treeInfo)
+	if blob, err := ioutil.ReadFile(filepath.Join(dir, infoFile)); err == nil {
+		if err := json.Unmarshal(blob, info); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", infoFile, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	return info, nil
+}
+
+// loadTreeDir reads the tree metadata and node records from a directory.
+func loadTreeDir(dir string) (*treeInfo, []*enr.Record, error) {
+	info, err := loadTreeInfo(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	blob, err := ioutil.ReadFile(filepath.Join(dir, nodesFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	var texts []string
+	if err := json.Unmarshal(blob, &texts); err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %v", nodesFile, err)
+	}
+	nodes := make([]*enr.Record, len(texts))
+	for i, text := range texts {
+		if nodes[i], err = dnsdisc.ParseENR(text); err != nil {
+			return nil, nil, fmt.Errorf("invalid node record %d in %s: %v", i, nodesFile, err)
+		}
+	}
+	return info, nodes, nil
+}
+
+// writeTreeDir stores the tree metadata and node records in a directory.
+func writeTreeDir(dir string, info *treeInfo, nodes []*enr.Record) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	texts := make([]string, len(nodes))
+	for i, n := range nodes {
+		texts[i] = dnsdisc.FormatENR(n)
+	}
+	if err := writeJSON(filepath.Join(dir, nodesFile), texts); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(dir, infoFile), info)
+}
+
+func writeJSON(file string, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, append(blob, '\n'), 0644)
+}
+
+// writeZonefile writes the given TXT records in zone file format, sorted by name.
+func writeZonefile(w io.Writer, records map[string]string) error {
+	names := make([]string, 0, len(records))
+	for name := range records {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s.\t%d\tIN\tTXT\t%s\n", name, zoneTTL, txtStrings(records[name])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// txtStrings formats a TXT record value as a sequence of quoted character-strings,
+// splitting it up as a single one can't be longer than 255 bytes.
+func txtStrings(value string) string {
+	var parts []string
+	for len(value) > txtMaxLen {
+		parts = append(parts, strconv.Quote(value[:txtMaxLen]))
+		value = value[txtMaxLen:]
+	}
+	parts = append(parts, strconv.Quote(value))
+	return strings.Join(parts, " ")
+}
+
+// loadKey decrypts the keyfile, asking for the passphrase if needed.
+func loadKey(ctx *cli.Context, keyfile string) *keystore.Key {
+	keyjson, err := ioutil.ReadFile(keyfile)
+	if err != nil {
+		utils.Fatalf("Failed to read the keyfile at '%s': %v", keyfile, err)
+	}
+	var passphrase string
+	if file := ctx.String(passphraseFlag.Name); file != "" {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			utils.Fatalf("Failed to read passphrase file '%s': %v", file, err)
+		}
+		passphrase = strings.TrimRight(string(content), "\r\n")
+	} else {
+		if passphrase, err = console.Stdin.PromptPassword("Passphrase: "); err != nil {
+			utils.Fatalf("Failed to read passphrase: %v", err)
+		}
+	}
+	key, err := keystore.DecryptKey(keyjson, passphrase)
+	if err != nil {
+		utils.Fatalf("Error decrypting key: %v", err)
+	}
+	return key
+}
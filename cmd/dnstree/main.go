@@ -0,0 +1,48 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of go-irchain.
+//
+// go-irchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-irchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-irchain. If not, see <http://www.gnu.org/licenses/>.
+
+// dnstree is a tool to create, sign and publish DNS node lists (EIP-1459).
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/irchain/go-irchain/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var app *cli.App
+
+func init() {
+	app = utils.NewApp(gitCommit, "an IrChain DNS node list manager")
+	app.Commands = []cli.Command{
+		commandCrawl,
+		commandSync,
+		commandSign,
+		commandToZonefile,
+	}
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -171,6 +171,14 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterShhService(stack, &cfg.Shh)
 	}
 
+	// Add the GraphQL query service if requested.
+	if cfg.Node.GraphQL {
+		if cfg.Node.HTTPHost == "" {
+			utils.Fatalf("GraphQL requires the HTTP-RPC server to be enabled (--%s)", utils.RPCEnabledFlag.Name)
+		}
+		utils.RegisterGraphQLService(stack, cfg.Node.GraphQLUI)
+	}
+
 	// Add the IrChain Stats daemon if requested.
 	if cfg.Ircstats.URL != "" {
 		utils.RegisterIrcStatsService(stack, cfg.Ircstats.URL)
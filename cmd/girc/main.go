@@ -82,12 +82,23 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolMaxTxSizeFlag,
+		utils.TxPoolNoContractsFlag,
+		utils.TxPoolAllowListFlag,
+		utils.TxPoolDenyListFlag,
+		utils.TxPoolSenderClassFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
+		utils.CheckpointOracleFlag,
+		utils.CheckpointSignersFlag,
+		utils.CheckpointThresholdFlag,
+		utils.CheckpointSignerFlag,
+		utils.ULCServersFlag,
+		utils.ULCFractionFlag,
 		utils.LightKDFFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
@@ -104,7 +115,15 @@ var (
 		utils.NATFlag,
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
+		utils.DNSDiscoveryFlag,
 		utils.NetrestrictFlag,
+		utils.BandwidthIngressFlag,
+		utils.BandwidthEgressFlag,
+		utils.BandwidthPeerIngressFlag,
+		utils.BandwidthPeerEgressFlag,
+		utils.BandwidthSharesFlag,
+		utils.CaptureFileFlag,
+		utils.CaptureSizeFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DeveloperFlag,
@@ -115,6 +134,10 @@ var (
 		utils.NetworkIdFlag,
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
+		utils.RPCJWTSecretFlag,
+		utils.RPCBatchLimitFlag,
+		utils.RPCResponseLimitFlag,
+		utils.RPCTimeoutFlag,
 		utils.IrcStatsURLFlag,
 		utils.MetricsEnabledFlag,
 		utils.FakePoWFlag,
@@ -122,6 +145,9 @@ var (
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
 		utils.ExtraDataFlag,
+		utils.MinerTxOrderingFlag,
+		utils.MinerSenderCapFlag,
+		utils.MinerPriorityAccountsFlag,
 		configFileFlag,
 	}
 
@@ -135,6 +161,8 @@ var (
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.GraphQLEnabledFlag,
+		utils.GraphQLUIFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 	}
@@ -299,6 +327,18 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 			}
 		}
 	}()
+	// Let a light server vote on checkpoints through the local node
+	if ctx.GlobalIsSet(utils.CheckpointSignerFlag.Name) {
+		var irchain *irc.IrChain
+		if err := stack.Service(&irchain); err != nil {
+			utils.Fatalf("Checkpoint voting requires a full IrChain node: %v", err)
+		}
+		rpcClient, err := stack.Attach()
+		if err != nil {
+			utils.Fatalf("Failed to attach to self: %v", err)
+		}
+		irchain.SetContractBackend(irclient.NewClient(rpcClient))
+	}
 	// Start auxiliary services if enabled
 	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
 		// Mining only makes sense if a full IrChain node is running
@@ -80,6 +80,12 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IdentityFlag,
 			utils.LightServFlag,
 			utils.LightPeersFlag,
+			utils.CheckpointOracleFlag,
+			utils.CheckpointSignersFlag,
+			utils.CheckpointThresholdFlag,
+			utils.CheckpointSignerFlag,
+			utils.ULCServersFlag,
+			utils.ULCFractionFlag,
 			utils.LightKDFFlag,
 		},
 	},
@@ -123,6 +129,11 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolMaxTxSizeFlag,
+			utils.TxPoolNoContractsFlag,
+			utils.TxPoolAllowListFlag,
+			utils.TxPoolDenyListFlag,
+			utils.TxPoolSenderClassFlag,
 		},
 	},
 	{
@@ -153,10 +164,16 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.WSPortFlag,
 			utils.WSApiFlag,
 			utils.WSAllowedOriginsFlag,
+			utils.GraphQLEnabledFlag,
+			utils.GraphQLUIFlag,
 			utils.IPCDisabledFlag,
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
+			utils.RPCJWTSecretFlag,
+			utils.RPCBatchLimitFlag,
+			utils.RPCResponseLimitFlag,
+			utils.RPCTimeoutFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
@@ -174,7 +191,15 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
+			utils.DNSDiscoveryFlag,
 			utils.NetrestrictFlag,
+			utils.BandwidthIngressFlag,
+			utils.BandwidthEgressFlag,
+			utils.BandwidthPeerIngressFlag,
+			utils.BandwidthPeerEgressFlag,
+			utils.BandwidthSharesFlag,
+			utils.CaptureFileFlag,
+			utils.CaptureSizeFlag,
 			utils.NodeKeyFileFlag,
 			utils.NodeKeyHexFlag,
 		},
@@ -188,6 +213,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.MinerTxOrderingFlag,
+			utils.MinerSenderCapFlag,
+			utils.MinerPriorityAccountsFlag,
 		},
 	},
 	{
@@ -0,0 +1,158 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of go-irchain.
+//
+// go-irchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-irchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-irchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/p2p"
+	"github.com/irchain/go-irchain/p2p/discover"
+	p2ptest "github.com/irchain/go-irchain/p2p/testing"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+const (
+	replayConnectTimeout = 10 * time.Second // Time to wait for the connection to the replay target
+	replayTimeout        = 5 * time.Second  // Time to wait for each message sent to or expected from the replay target
+)
+
+// readCapture reads all capture records from r, keeping those of peers whose ID
+// starts with the given prefix.
+func readCapture(r io.Reader, peer string) ([]*p2p.CaptureRecord, error) {
+	var (
+		records []*p2p.CaptureRecord
+		cr      = p2p.NewCaptureReader(r)
+	)
+	for {
+		rec, err := cr.Next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		if strings.HasPrefix(rec.Peer.String(), strings.TrimPrefix(peer, "0x")) {
+			records = append(records, rec)
+		}
+	}
+}
+
+// dumpCapture prints all records of a capture, decoding messages of known
+// protocols.
+func dumpCapture(records []*p2p.CaptureRecord) {
+	for _, rec := range records {
+		dir := "<-"
+		if !rec.Inbound {
+			dir = "->"
+		}
+		at := time.Unix(0, int64(rec.Time))
+		fmt.Printf("%s %s %x %s/%d ", at.Format("2006-01-02 15:04:05.000"), dir, rec.Peer[:8], rec.Protocol, rec.Version)
+		dumpMessage(rec.Protocol, rec.Code, rec.Payload)
+	}
+}
+
+// dumpMessage prints a single message, decoding it if the type is known and
+// dumping the plain RLP structure otherwise.
+func dumpMessage(proto string, code uint64, payload []byte) {
+	typ, known := lookupMessage(proto, code)
+	if !known {
+		typ.name = "Unknown"
+	}
+	fmt.Printf("%s (%#x) %d bytes\n", typ.name, code, len(payload))
+
+	msg, err := decodeMessage(typ, payload)
+	if err != nil {
+		fmt.Println("  error:", err)
+	}
+	if msg != nil {
+		out, _ := json.MarshalIndent(msg, "  ", "  ")
+		fmt.Printf("  %s\n", out)
+		return
+	}
+	if err := dump(rlp.NewStream(bytes.NewReader(payload), 0), 1); err != nil {
+		fmt.Print("  error: ", err)
+	}
+	fmt.Println()
+}
+
+// replayCapture connects to the given node and replays a captured connection
+// against it: messages the capturing node received are sent to the node, which
+// is expected to respond with the messages the capturing node sent.
+func replayCapture(url string, records []*p2p.CaptureRecord) error {
+	node, err := discover.ParseNode(url)
+	if err != nil {
+		return fmt.Errorf("invalid hnode: %v", err)
+	}
+	// Select the messages of a single connection and protocol.
+	var replay []*p2p.CaptureRecord
+	for _, rec := range records {
+		if len(replay) > 0 {
+			if first := replay[0]; rec.Peer != first.Peer {
+				return errors.New("capture contains multiple peers, select one with -peer")
+			} else if rec.Protocol != first.Protocol || rec.Version != first.Version {
+				continue
+			}
+		}
+		replay = append(replay, rec)
+	}
+	if len(replay) == 0 {
+		return errors.New("no messages to replay")
+	}
+	// Unknown protocols accept all captured message codes.
+	proto := p2p.Protocol{Name: replay[0].Protocol, Version: replay[0].Version}
+	if proto.Length = protocolLength(proto.Name, proto.Version); proto.Length == 0 {
+		for _, rec := range replay {
+			if rec.Code >= proto.Length {
+				proto.Length = rec.Code + 1
+			}
+		}
+	}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return err
+	}
+	session, err := p2ptest.NewRemoteSession(key, node, proto, replayConnectTimeout)
+	if err != nil {
+		return err
+	}
+	defer session.Stop()
+
+	for _, e := range p2ptest.CaptureExchanges(session.IDs[0], replay) {
+		for i := range e.Triggers {
+			e.Triggers[i].Timeout = replayTimeout
+			fmt.Printf("-> ")
+			dumpMessage(proto.Name, e.Triggers[i].Code, e.Triggers[i].Msg.(rlp.RawValue))
+		}
+		for i := range e.Expects {
+			e.Expects[i].Timeout = replayTimeout
+			fmt.Printf("<- ")
+			dumpMessage(proto.Name, e.Expects[i].Code, e.Expects[i].Msg.(rlp.RawValue))
+		}
+		e.Timeout = replayTimeout * time.Duration(1+len(e.Triggers)+len(e.Expects))
+		if err := session.TestExchanges(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
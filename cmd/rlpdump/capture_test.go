@@ -0,0 +1,183 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of go-irchain.
+//
+// go-irchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-irchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-irchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/irc"
+	"github.com/irchain/go-irchain/p2p"
+	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+func encodeCapture(t *testing.T, records ...*p2p.CaptureRecord) *bytes.Buffer {
+	buf := new(bytes.Buffer)
+	for _, rec := range records {
+		if err := rlp.Encode(buf, rec); err != nil {
+			t.Fatalf("can't encode record: %v", err)
+		}
+	}
+	return buf
+}
+
+func TestReadCapture(t *testing.T) {
+	var peer1, peer2 discover.NodeID
+	peer1[0], peer2[0] = 0x11, 0x22
+
+	capture := encodeCapture(t,
+		&p2p.CaptureRecord{Peer: peer1, Protocol: "irc", Version: 63, Inbound: true, Code: 1, Payload: []byte{0xc0}},
+		&p2p.CaptureRecord{Peer: peer2, Protocol: "irc", Version: 63, Code: 2, Payload: []byte{0xc0}},
+		&p2p.CaptureRecord{Peer: peer1, Protocol: "irc", Version: 63, Code: 3, Payload: []byte{0xc0}},
+	)
+	records, err := readCapture(bytes.NewReader(capture.Bytes()), "")
+	if err != nil {
+		t.Fatalf("can't read capture: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("wrong number of records: got %d, want 3", len(records))
+	}
+	records, err = readCapture(bytes.NewReader(capture.Bytes()), "0x11")
+	if err != nil {
+		t.Fatalf("can't read capture: %v", err)
+	}
+	if len(records) != 2 || records[0].Code != 1 || records[1].Code != 3 {
+		t.Fatalf("wrong records of filtered peer: %v", records)
+	}
+	// Truncated captures return the records read so far.
+	records, err = readCapture(bytes.NewReader(capture.Bytes()[:capture.Len()-1]), "")
+	if err == nil {
+		t.Fatal("no error for truncated capture")
+	}
+	if len(records) != 2 {
+		t.Fatalf("wrong number of records of truncated capture: got %d, want 2", len(records))
+	}
+}
+
+func TestDecodeMessage(t *testing.T) {
+	status := &ircStatus{
+		ProtocolVersion: 63,
+		NetworkId:       1,
+		TD:              big.NewInt(131072),
+		CurrentBlock:    common.HexToHash("0x01"),
+		GenesisBlock:    common.HexToHash("0x02"),
+	}
+	payload, err := rlp.EncodeToBytes(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ, known := lookupMessage(irc.ProtocolName, irc.StatusMsg)
+	if !known || typ.name != "Status" {
+		t.Fatalf("status message not known: %v", typ.name)
+	}
+	msg, err := decodeMessage(typ, payload)
+	if err != nil {
+		t.Fatalf("can't decode status: %v", err)
+	}
+	decoded := msg.(*ircStatus)
+	if decoded.NetworkId != 1 || decoded.TD.Cmp(status.TD) != 0 || decoded.GenesisBlock != status.GenesisBlock {
+		t.Fatalf("wrong decoded status: %+v", decoded)
+	}
+	if _, err := decodeMessage(typ, []byte{0xc1, 0x01}); err == nil {
+		t.Fatal("no error for invalid status")
+	}
+}
+
+func TestProtocolLength(t *testing.T) {
+	if l := protocolLength(irc.ProtocolName, 63); l != 17 {
+		t.Errorf("wrong irc/63 length: got %d, want 17", l)
+	}
+	if l := protocolLength("les", 2); l != 22 {
+		t.Errorf("wrong les/2 length: got %d, want 22", l)
+	}
+	if l := protocolLength("unknown", 1); l != 0 {
+		t.Errorf("wrong length of unknown protocol: got %d, want 0", l)
+	}
+}
+
+// startEchoNode starts a node responding to every message of the test protocol
+// with code 1 and the same payload.
+func startEchoNode(t *testing.T) *p2p.Server {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &p2p.Server{Config: p2p.Config{
+		PrivateKey:  key,
+		MaxPeers:    10,
+		ListenAddr:  "127.0.0.1:0",
+		NoDiscovery: true,
+		Protocols: []p2p.Protocol{{
+			Name:    "test",
+			Version: 1,
+			Length:  2,
+			Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+				for {
+					msg, err := rw.ReadMsg()
+					if err != nil {
+						return err
+					}
+					payload, err := ioutil.ReadAll(msg.Payload)
+					if err != nil {
+						return err
+					}
+					if err := p2p.Send(rw, 1, rlp.RawValue(payload)); err != nil {
+						return err
+					}
+				}
+			},
+		}},
+	}}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("can't start node: %v", err)
+	}
+	return srv
+}
+
+func TestReplayCapture(t *testing.T) {
+	srv := startEchoNode(t)
+	defer srv.Stop()
+
+	var peer discover.NodeID
+	record := func(inbound bool, code uint64, payload string) *p2p.CaptureRecord {
+		enc, _ := rlp.EncodeToBytes(payload)
+		return &p2p.CaptureRecord{Peer: peer, Protocol: "test", Version: 1, Inbound: inbound, Code: code, Payload: enc}
+	}
+	records := []*p2p.CaptureRecord{
+		record(true, 0, "ping"),
+		record(false, 1, "ping"),
+		record(true, 0, "foo"),
+		record(true, 0, "bar"),
+		record(false, 1, "foo"),
+		record(false, 1, "bar"),
+	}
+	if err := replayCapture(srv.Self().String(), records); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	// Diverging responses fail the replay.
+	records[5] = record(false, 1, "baz")
+	err := replayCapture(srv.Self().String(), records)
+	if err == nil || !strings.Contains(err.Error(), "unexpected message") {
+		t.Fatalf("wrong error for diverging capture: %v", err)
+	}
+}
@@ -33,15 +33,24 @@ var (
 	hexMode = flag.String("hex", "", "dump given hex data")
 	noASCII = flag.Bool("noascii", false, "don't print ASCII strings readably")
 	single  = flag.Bool("single", false, "print only the first element, discard the rest")
+
+	captureMode = flag.Bool("capture", false, "decode the input as a devp2p message capture")
+	peerFilter  = flag.String("peer", "", "only use captured messages of peers whose ID starts with the given prefix")
+	replayNode  = flag.String("replay", "", "replay captured messages received from a peer against the given hnode")
 )
 
 func init() {
 	flag.Usage = func() {
 		fmt.Fprintln(os.Stderr, "Usage:", os.Args[0], "[-noascii] [-hex <data>] [filename]")
+		fmt.Fprintln(os.Stderr, "      ", os.Args[0], "-capture [-peer <id>] [-replay <hnode>] [filename]")
 		flag.PrintDefaults()
 		fmt.Fprintln(os.Stderr, `
 Dumps RLP data from the given file in readable form.
-If the filename is omitted, data is read from stdin.`)
+If the filename is omitted, data is read from stdin.
+
+In capture mode, the input is a devp2p message capture written by a node
+running with --capture.file. Messages of known protocols are decoded, and
+can be replayed against a running node.`)
 	}
 }
 
@@ -74,6 +83,21 @@ func main() {
 		os.Exit(2)
 	}
 
+	if *captureMode {
+		records, err := readCapture(r, *peerFilter)
+		if err != nil {
+			die(err)
+		}
+		if *replayNode != "" {
+			err = replayCapture(*replayNode, records)
+		} else {
+			dumpCapture(records)
+		}
+		if err != nil {
+			die(err)
+		}
+		return
+	}
 	s := rlp.NewStream(r, 0)
 	for {
 		if err := dump(s, 0); err != nil {
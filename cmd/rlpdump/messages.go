@@ -0,0 +1,231 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of go-irchain.
+//
+// go-irchain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-irchain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-irchain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/irc"
+	"github.com/irchain/go-irchain/les"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+// msgType describes a known protocol message, with a constructor for a value
+// its payload can be decoded into.
+type msgType struct {
+	name string
+	new  func() interface{}
+}
+
+// knownMessages maps protocol names and message codes to message types.
+var knownMessages = map[string]map[uint64]msgType{
+	irc.ProtocolName: {
+		irc.StatusMsg:                     {"Status", func() interface{} { return new(ircStatus) }},
+		irc.NewBlockHashesMsg:             {"NewBlockHashes", func() interface{} { return new([]blockAnnounce) }},
+		irc.TxMsg:                         {"Transactions", func() interface{} { return new([]*types.Transaction) }},
+		irc.GetBlockHeadersMsg:            {"GetBlockHeaders", func() interface{} { return new(headersQuery) }},
+		irc.BlockHeadersMsg:               {"BlockHeaders", func() interface{} { return new([]*types.Header) }},
+		irc.GetBlockBodiesMsg:             {"GetBlockBodies", func() interface{} { return new([]common.Hash) }},
+		irc.BlockBodiesMsg:                {"BlockBodies", func() interface{} { return new([]*types.Body) }},
+		irc.NewBlockMsg:                   {"NewBlock", func() interface{} { return new(newBlock) }},
+		irc.GetNodeDataMsg:                {"GetNodeData", func() interface{} { return new([]common.Hash) }},
+		irc.NodeDataMsg:                   {"NodeData", func() interface{} { return new([]hexutil.Bytes) }},
+		irc.GetReceiptsMsg:                {"GetReceipts", func() interface{} { return new([]common.Hash) }},
+		irc.ReceiptsMsg:                   {"Receipts", func() interface{} { return new([][]*types.Receipt) }},
+		irc.NewPooledTransactionHashesMsg: {"NewPooledTransactionHashes", func() interface{} { return new([]common.Hash) }},
+		irc.GetPooledTransactionsMsg:      {"GetPooledTransactions", func() interface{} { return new([]common.Hash) }},
+		irc.PooledTransactionsMsg:         {"PooledTransactions", func() interface{} { return new([]*types.Transaction) }},
+	},
+	"les": {
+		les.StatusMsg:              {"Status", func() interface{} { return new([]keyValue) }},
+		les.AnnounceMsg:            {"Announce", func() interface{} { return new(lesAnnounce) }},
+		les.GetBlockHeadersMsg:     {"GetBlockHeaders", func() interface{} { return new(lesHeadersQuery) }},
+		les.BlockHeadersMsg:        {"BlockHeaders", func() interface{} { return new(lesHeaders) }},
+		les.GetBlockBodiesMsg:      {"GetBlockBodies", func() interface{} { return new(lesHashesQuery) }},
+		les.BlockBodiesMsg:         {"BlockBodies", func() interface{} { return new(lesBodies) }},
+		les.GetReceiptsMsg:         {"GetReceipts", func() interface{} { return new(lesHashesQuery) }},
+		les.ReceiptsMsg:            {"Receipts", func() interface{} { return new(lesReceipts) }},
+		les.GetProofsV1Msg:         {"GetProofsV1", nil},
+		les.ProofsV1Msg:            {"ProofsV1", nil},
+		les.GetCodeMsg:             {"GetCode", func() interface{} { return new(lesCodeQuery) }},
+		les.CodeMsg:                {"Code", func() interface{} { return new(lesData) }},
+		les.SendTxMsg:              {"SendTx", func() interface{} { return new([]*types.Transaction) }},
+		les.GetHeaderProofsMsg:     {"GetHeaderProofs", nil},
+		les.HeaderProofsMsg:        {"HeaderProofs", nil},
+		les.GetProofsV2Msg:         {"GetProofsV2", nil},
+		les.ProofsV2Msg:            {"ProofsV2", func() interface{} { return new(lesData) }},
+		les.GetHelperTrieProofsMsg: {"GetHelperTrieProofs", nil},
+		les.HelperTrieProofsMsg:    {"HelperTrieProofs", nil},
+		les.SendTxV2Msg:            {"SendTxV2", func() interface{} { return new(lesTxs) }},
+		les.GetTxStatusMsg:         {"GetTxStatus", func() interface{} { return new(lesHashesQuery) }},
+		les.TxStatusMsg:            {"TxStatus", nil},
+	},
+}
+
+// lookupMessage returns the type of a message, if known.
+func lookupMessage(proto string, code uint64) (msgType, bool) {
+	typ, ok := knownMessages[proto][code]
+	return typ, ok
+}
+
+// protocolLength returns the number of message codes of a known protocol
+// version, or zero if the version is unknown.
+func protocolLength(proto string, version uint) uint64 {
+	switch proto {
+	case irc.ProtocolName:
+		for i, v := range irc.ProtocolVersions {
+			if v == version {
+				return irc.ProtocolLengths[i]
+			}
+		}
+	case "les":
+		return les.ProtocolLengths[version]
+	}
+	return 0
+}
+
+// decodeMessage decodes a message payload into its known type. It returns nil
+// if the type has no decoder.
+func decodeMessage(typ msgType, payload []byte) (interface{}, error) {
+	if typ.new == nil {
+		return nil, nil
+	}
+	v := typ.new()
+	if err := rlp.DecodeBytes(payload, v); err != nil {
+		return nil, fmt.Errorf("invalid %s message: %v", typ.name, err)
+	}
+	return v, nil
+}
+
+// Wire formats of the irc messages.
+
+type ircStatus struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	Rest            []rlp.RawValue `rlp:"tail" json:"-"`
+}
+
+type blockAnnounce struct {
+	Hash   common.Hash
+	Number uint64
+}
+
+type headersQuery struct {
+	Origin  hashOrNumber
+	Amount  uint64
+	Skip    uint64
+	Reverse bool
+}
+
+type newBlock struct {
+	Block struct {
+		Header *types.Header
+		Txs    []*types.Transaction
+		Uncles []*types.Header
+		Rest   []rlp.RawValue `rlp:"tail" json:"-"`
+	}
+	TD *big.Int
+}
+
+// hashOrNumber is a block referenced by either its hash or number.
+type hashOrNumber struct {
+	Hash   *common.Hash `json:",omitempty"`
+	Number *uint64      `json:",omitempty"`
+}
+
+func (hn *hashOrNumber) DecodeRLP(s *rlp.Stream) error {
+	_, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if size == common.HashLength {
+		hn.Hash = new(common.Hash)
+		return s.Decode(hn.Hash)
+	}
+	hn.Number = new(uint64)
+	return s.Decode(hn.Number)
+}
+
+// Wire formats of the les messages.
+
+type keyValue struct {
+	Key   string
+	Value rlp.RawValue
+}
+
+func (kv keyValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]hexutil.Bytes{kv.Key: hexutil.Bytes(kv.Value)})
+}
+
+type lesAnnounce struct {
+	Hash       common.Hash
+	Number     uint64
+	Td         *big.Int
+	ReorgDepth uint64
+	Update     []keyValue
+}
+
+type lesHeadersQuery struct {
+	ReqID uint64
+	Query headersQuery
+}
+
+type lesHashesQuery struct {
+	ReqID  uint64
+	Hashes []common.Hash
+}
+
+type lesCodeQuery struct {
+	ReqID uint64
+	Reqs  []struct {
+		BHash  common.Hash
+		AccKey hexutil.Bytes
+	}
+}
+
+type lesHeaders struct {
+	ReqID, BV uint64
+	Headers   []*types.Header
+}
+
+type lesBodies struct {
+	ReqID, BV uint64
+	Bodies    []*types.Body
+}
+
+type lesReceipts struct {
+	ReqID, BV uint64
+	Receipts  [][]*types.Receipt
+}
+
+type lesData struct {
+	ReqID, BV uint64
+	Data      []hexutil.Bytes
+}
+
+type lesTxs struct {
+	ReqID uint64
+	Txs   []*types.Transaction
+}
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -40,6 +41,7 @@ import (
 	"github.com/irchain/go-irchain/core/vm"
 	"github.com/irchain/go-irchain/crypto"
 	"github.com/irchain/go-irchain/dashboard"
+	"github.com/irchain/go-irchain/graphql"
 	"github.com/irchain/go-irchain/irc"
 	"github.com/irchain/go-irchain/irc/downloader"
 	"github.com/irchain/go-irchain/irc/gasprice"
@@ -48,6 +50,7 @@ import (
 	"github.com/irchain/go-irchain/les"
 	"github.com/irchain/go-irchain/log"
 	"github.com/irchain/go-irchain/metrics"
+	"github.com/irchain/go-irchain/miner"
 	"github.com/irchain/go-irchain/node"
 	"github.com/irchain/go-irchain/p2p"
 	"github.com/irchain/go-irchain/p2p/discover"
@@ -185,6 +188,31 @@ var (
 		Usage: "Maximum number of LES client peers",
 		Value: irc.DefaultConfig.LightPeers,
 	}
+	CheckpointOracleFlag = cli.StringFlag{
+		Name:  "checkpoint.oracle",
+		Usage: "Address of the checkpoint oracle contract light clients sync from",
+	}
+	CheckpointSignersFlag = cli.StringFlag{
+		Name:  "checkpoint.signers",
+		Usage: "Comma separated accounts trusted to vote on oracle checkpoints",
+	}
+	CheckpointThresholdFlag = cli.Uint64Flag{
+		Name:  "checkpoint.threshold",
+		Usage: "Number of trusted signers required to accept an oracle checkpoint",
+	}
+	CheckpointSignerFlag = cli.StringFlag{
+		Name:  "checkpoint.signer",
+		Usage: "Account a light server votes on oracle checkpoints with (needs to be unlocked)",
+	}
+	ULCServersFlag = cli.StringFlag{
+		Name:  "ulc.servers",
+		Usage: "Comma separated enode URLs of trusted servers, enables ultra-light client mode",
+	}
+	ULCFractionFlag = cli.IntFlag{
+		Name:  "ulc.fraction",
+		Usage: "Percentage of trusted servers that have to announce a head before it's accepted (ultra-light client mode)",
+		Value: irc.DefaultULCMinTrustedFraction,
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -289,6 +317,26 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: irc.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolMaxTxSizeFlag = cli.Uint64Flag{
+		Name:  "txpool.maxtxsize",
+		Usage: "Maximum size in bytes of transactions accepted into the pool (0 = protocol limit)",
+	}
+	TxPoolNoContractsFlag = cli.BoolFlag{
+		Name:  "txpool.nocontracts",
+		Usage: "Rejects contract creation transactions from entering the pool",
+	}
+	TxPoolAllowListFlag = cli.StringFlag{
+		Name:  "txpool.allow",
+		Usage: "Comma separated list of accounts exclusively allowed to submit transactions",
+	}
+	TxPoolDenyListFlag = cli.StringFlag{
+		Name:  "txpool.deny",
+		Usage: "Comma separated list of accounts whose transactions are rejected",
+	}
+	TxPoolSenderClassFlag = cli.StringSliceFlag{
+		Name:  "txpool.senderclass",
+		Usage: "Minimum gas price for a class of senders as name:pricelimit:account,account,... (may be repeated)",
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -339,6 +387,19 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerTxOrderingFlag = cli.StringFlag{
+		Name:  "miner.txordering",
+		Usage: `Transaction ordering strategy for block building ("price", "fifo" or "priority")`,
+		Value: irc.DefaultConfig.Miner.TxOrdering,
+	}
+	MinerSenderCapFlag = cli.IntFlag{
+		Name:  "miner.sendercap",
+		Usage: "Maximum number of transactions per sender in a mined block (0 = unlimited)",
+	}
+	MinerPriorityAccountsFlag = cli.StringFlag{
+		Name:  "miner.priority",
+		Usage: "Comma separated list of accounts whose transactions are mined first by the priority ordering",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -402,6 +463,23 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	RPCJWTSecretFlag = cli.StringFlag{
+		Name:  "rpcjwtsecret",
+		Usage: "File holding the hex encoded secret to authenticate HTTP-RPC and WS-RPC requests with (JWT bearer tokens)",
+		Value: "",
+	}
+	RPCBatchLimitFlag = cli.IntFlag{
+		Name:  "rpcbatchlimit",
+		Usage: "Maximum number of calls in an RPC batch request (0 = unlimited)",
+	}
+	RPCResponseLimitFlag = cli.IntFlag{
+		Name:  "rpcresponselimit",
+		Usage: "Maximum size in bytes of an RPC response (0 = unlimited)",
+	}
+	RPCTimeoutFlag = cli.DurationFlag{
+		Name:  "rpctimeout",
+		Usage: "Maximum execution time of an RPC call (0 = unlimited)",
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -434,6 +512,14 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable GraphQL queries on the HTTP-RPC server (/graphql)",
+	}
+	GraphQLUIFlag = cli.BoolFlag{
+		Name:  "graphqlui",
+		Usage: "Serve the in-browser GraphQL query editor on the HTTP-RPC server (/graphql/ui)",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -495,10 +581,43 @@ var (
 		Name:  "v5disc",
 		Usage: "Enables the experimental RLPx V5 (Topic Discovery) mechanism",
 	}
+	DNSDiscoveryFlag = cli.StringFlag{
+		Name:  "discovery.dns",
+		Usage: "Comma separated list of enrtree:// URLs of DNS node lists to dial nodes from",
+	}
 	NetrestrictFlag = cli.StringFlag{
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
 	}
+	BandwidthIngressFlag = cli.Uint64Flag{
+		Name:  "bandwidth.ingress",
+		Usage: "Maximum total inbound bandwidth of peer connections in KB/s (0 = unlimited)",
+	}
+	BandwidthEgressFlag = cli.Uint64Flag{
+		Name:  "bandwidth.egress",
+		Usage: "Maximum total outbound bandwidth of peer connections in KB/s (0 = unlimited)",
+	}
+	BandwidthPeerIngressFlag = cli.Uint64Flag{
+		Name:  "bandwidth.peeringress",
+		Usage: "Maximum inbound bandwidth of a single peer connection in KB/s (0 = unlimited)",
+	}
+	BandwidthPeerEgressFlag = cli.Uint64Flag{
+		Name:  "bandwidth.peeregress",
+		Usage: "Maximum outbound bandwidth of a single peer connection in KB/s (0 = unlimited)",
+	}
+	BandwidthSharesFlag = cli.StringFlag{
+		Name:  "bandwidth.shares",
+		Usage: "Comma separated bandwidth shares of the sub-protocols (e.g. les=3,irc=1)",
+	}
+	CaptureFileFlag = cli.StringFlag{
+		Name:  "capture.file",
+		Usage: "File to capture all sent and received protocol messages into (for debugging)",
+	}
+	CaptureSizeFlag = cli.Int64Flag{
+		Name:  "capture.size",
+		Usage: "Size in megabytes at which the message capture file is rotated",
+		Value: 64,
+	}
 
 	// ATM the url is left to the user and deployment to
 	JSpathFlag = cli.StringFlag{
@@ -693,6 +812,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(RPCVirtualHostsFlag.Name) {
 		cfg.HTTPVirtualHosts = splitAndTrim(ctx.GlobalString(RPCVirtualHostsFlag.Name))
 	}
+	if ctx.GlobalIsSet(RPCJWTSecretFlag.Name) {
+		cfg.JWTSecret = ctx.GlobalString(RPCJWTSecretFlag.Name)
+	}
 }
 
 // setWS creates the WebSocket RPC listener interface string from the set
@@ -716,6 +838,30 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setGraphQL enables the GraphQL query service from the set command line flags.
+func setGraphQL(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(GraphQLEnabledFlag.Name) {
+		cfg.GraphQL = ctx.GlobalBool(GraphQLEnabledFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLUIFlag.Name) {
+		cfg.GraphQLUI = ctx.GlobalBool(GraphQLUIFlag.Name)
+	}
+}
+
+// setRPCLimits applies the resource limits of RPC requests from the set command
+// line flags, covering all RPC interfaces.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	if ctx.GlobalIsSet(RPCBatchLimitFlag.Name) {
+		cfg.RPCLimits.BatchItems = ctx.GlobalInt(RPCBatchLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCResponseLimitFlag.Name) {
+		cfg.RPCLimits.ResponseSize = ctx.GlobalInt(RPCResponseLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCTimeoutFlag.Name) {
+		cfg.RPCLimits.RequestTimeout = ctx.GlobalDuration(RPCTimeoutFlag.Name)
+	}
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -783,6 +929,66 @@ func setCoinbase(ctx *cli.Context, ks *keystore.KeyStore, cfg *irc.Config) {
 	}
 }
 
+// setCheckpointOracle creates the checkpoint oracle configuration from the
+// command line flags.
+func setCheckpointOracle(ctx *cli.Context, ks *keystore.KeyStore, cfg *irc.Config) {
+	if ctx.GlobalIsSet(CheckpointOracleFlag.Name) {
+		addr := ctx.GlobalString(CheckpointOracleFlag.Name)
+		if !common.IsHexAddress(addr) {
+			Fatalf("Option %q: invalid address %q", CheckpointOracleFlag.Name, addr)
+		}
+		oracle := &params.CheckpointOracleConfig{
+			Address:   common.HexToAddress(addr),
+			Threshold: ctx.GlobalUint64(CheckpointThresholdFlag.Name),
+		}
+		seen := make(map[common.Address]bool)
+		for _, signer := range splitAndTrim(ctx.GlobalString(CheckpointSignersFlag.Name)) {
+			if !common.IsHexAddress(signer) {
+				Fatalf("Option %q: invalid address %q", CheckpointSignersFlag.Name, signer)
+			}
+			if addr := common.HexToAddress(signer); !seen[addr] {
+				seen[addr] = true
+				oracle.Signers = append(oracle.Signers, addr)
+			}
+		}
+		if oracle.Threshold == 0 || oracle.Threshold > uint64(len(oracle.Signers)) {
+			Fatalf("Option %q: threshold must be between 1 and the number of signers", CheckpointThresholdFlag.Name)
+		}
+		cfg.CheckpointOracle = oracle
+	}
+	if ctx.GlobalIsSet(CheckpointSignerFlag.Name) {
+		account, err := MakeAddress(ks, ctx.GlobalString(CheckpointSignerFlag.Name))
+		if err != nil {
+			Fatalf("Option %q: %v", CheckpointSignerFlag.Name, err)
+		}
+		cfg.CheckpointSigner = account.Address
+	}
+}
+
+// setULC creates the ultra-light client configuration from the command line
+// flags.
+func setULC(ctx *cli.Context, cfg *irc.Config) {
+	if !ctx.GlobalIsSet(ULCServersFlag.Name) {
+		return
+	}
+	if cfg.SyncMode != downloader.LightSync {
+		Fatalf("Option %q requires light sync mode", ULCServersFlag.Name)
+	}
+	ulc := &irc.ULCConfig{
+		TrustedServers:     splitAndTrim(ctx.GlobalString(ULCServersFlag.Name)),
+		MinTrustedFraction: ctx.GlobalInt(ULCFractionFlag.Name),
+	}
+	for _, url := range ulc.TrustedServers {
+		if _, err := discover.ParseNode(url); err != nil {
+			Fatalf("Option %q: invalid enode URL %q: %v", ULCServersFlag.Name, url, err)
+		}
+	}
+	if ulc.MinTrustedFraction <= 0 || ulc.MinTrustedFraction > 100 {
+		Fatalf("Option %q: fraction must be between 1 and 100", ULCFractionFlag.Name)
+	}
+	cfg.ULC = ulc
+}
+
 // MakePasswordList reads password lines from the file specified by the global --password flag.
 func MakePasswordList(ctx *cli.Context) []string {
 	path := ctx.GlobalString(PasswordFileFlag.Name)
@@ -848,6 +1054,21 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.DiscoveryV5 = true
 	}
 
+	if urls := ctx.GlobalString(DNSDiscoveryFlag.Name); urls != "" {
+		for _, url := range strings.Split(urls, ",") {
+			if url = strings.TrimSpace(url); url != "" {
+				cfg.DNSDiscovery = append(cfg.DNSDiscovery, url)
+			}
+		}
+	}
+
+	setBandwidth(ctx, cfg)
+
+	if ctx.GlobalIsSet(CaptureFileFlag.Name) {
+		cfg.CaptureFile = ctx.GlobalString(CaptureFileFlag.Name)
+		cfg.CaptureFileSize = ctx.GlobalInt64(CaptureSizeFlag.Name) * 1024 * 1024
+	}
+
 	if netrestrict := ctx.GlobalString(NetrestrictFlag.Name); netrestrict != "" {
 		list, err := netutil.ParseNetlist(netrestrict)
 		if err != nil {
@@ -862,6 +1083,37 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.ListenAddr = ":0"
 		cfg.NoDiscovery = true
 		cfg.DiscoveryV5 = false
+		cfg.DNSDiscovery = nil
+	}
+}
+
+// setBandwidth applies bandwidth related command line flags to the config.
+func setBandwidth(ctx *cli.Context, cfg *p2p.Config) {
+	if ctx.GlobalIsSet(BandwidthIngressFlag.Name) {
+		cfg.MaxIngressRate = ctx.GlobalUint64(BandwidthIngressFlag.Name) * 1024
+	}
+	if ctx.GlobalIsSet(BandwidthEgressFlag.Name) {
+		cfg.MaxEgressRate = ctx.GlobalUint64(BandwidthEgressFlag.Name) * 1024
+	}
+	if ctx.GlobalIsSet(BandwidthPeerIngressFlag.Name) {
+		cfg.MaxPeerIngressRate = ctx.GlobalUint64(BandwidthPeerIngressFlag.Name) * 1024
+	}
+	if ctx.GlobalIsSet(BandwidthPeerEgressFlag.Name) {
+		cfg.MaxPeerEgressRate = ctx.GlobalUint64(BandwidthPeerEgressFlag.Name) * 1024
+	}
+	if shares := ctx.GlobalString(BandwidthSharesFlag.Name); shares != "" {
+		cfg.ProtocolShares = make(map[string]uint)
+		for _, entry := range strings.Split(shares, ",") {
+			parts := strings.Split(strings.TrimSpace(entry), "=")
+			if len(parts) != 2 {
+				Fatalf("Option %q: invalid share %q", BandwidthSharesFlag.Name, entry)
+			}
+			share, err := strconv.ParseUint(parts[1], 10, 32)
+			if err != nil {
+				Fatalf("Option %q: invalid share %q: %v", BandwidthSharesFlag.Name, entry, err)
+			}
+			cfg.ProtocolShares[parts[0]] = uint(share)
+		}
 	}
 }
 
@@ -871,6 +1123,8 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
+	setGraphQL(ctx, cfg)
+	setRPCLimits(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 
 	switch {
@@ -904,6 +1158,58 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	}
 }
 
+func setMiner(ctx *cli.Context, cfg *miner.Config) {
+	if ctx.GlobalIsSet(MinerTxOrderingFlag.Name) {
+		cfg.TxOrdering = ctx.GlobalString(MinerTxOrderingFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerSenderCapFlag.Name) {
+		cfg.MaxTxsPerSender = ctx.GlobalInt(MinerSenderCapFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerPriorityAccountsFlag.Name) {
+		cfg.PriorityAccounts = MakeAddressList(ctx, MinerPriorityAccountsFlag.Name)
+	}
+}
+
+// MakeAddressList parses a comma separated list of hex addresses from the named
+// flag, aborting on any malformed entry.
+func MakeAddressList(ctx *cli.Context, name string) []common.Address {
+	var addrs []common.Address
+	for _, account := range strings.Split(ctx.GlobalString(name), ",") {
+		if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+			Fatalf("Invalid account in --%s: %s", name, trimmed)
+		} else {
+			addrs = append(addrs, common.HexToAddress(trimmed))
+		}
+	}
+	return addrs
+}
+
+// MakeSenderClasses parses the transaction pool sender classes given by the
+// flag of the given name, each formatted as name:pricelimit:account,account,...
+func MakeSenderClasses(ctx *cli.Context, name string) []core.TxSenderClass {
+	var classes []core.TxSenderClass
+	for _, spec := range ctx.GlobalStringSlice(name) {
+		parts := strings.SplitN(spec, ":", 3)
+		if len(parts) != 3 || strings.TrimSpace(parts[0]) == "" {
+			Fatalf("Invalid sender class in --%s: %s", name, spec)
+		}
+		price, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			Fatalf("Invalid price limit in --%s: %s", name, spec)
+		}
+		class := core.TxSenderClass{Name: strings.TrimSpace(parts[0]), PriceLimit: price}
+		for _, account := range strings.Split(parts[2], ",") {
+			if trimmed := strings.TrimSpace(account); !common.IsHexAddress(trimmed) {
+				Fatalf("Invalid account in --%s: %s", name, trimmed)
+			} else {
+				class.Accounts = append(class.Accounts, common.HexToAddress(trimmed))
+			}
+		}
+		classes = append(classes, class)
+	}
+	return classes
+}
+
 func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolNoLocalsFlag.Name) {
 		cfg.NoLocals = ctx.GlobalBool(TxPoolNoLocalsFlag.Name)
@@ -935,6 +1241,21 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolMaxTxSizeFlag.Name) {
+		cfg.MaxTxSize = ctx.GlobalUint64(TxPoolMaxTxSizeFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolNoContractsFlag.Name) {
+		cfg.NoContractCreation = ctx.GlobalBool(TxPoolNoContractsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolAllowListFlag.Name) {
+		cfg.AllowList = MakeAddressList(ctx, TxPoolAllowListFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolDenyListFlag.Name) {
+		cfg.DenyList = MakeAddressList(ctx, TxPoolDenyListFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSenderClassFlag.Name) {
+		cfg.SenderClasses = MakeSenderClasses(ctx, TxPoolSenderClassFlag.Name)
+	}
 }
 
 func setIrchash(ctx *cli.Context, cfg *irc.Config) {
@@ -1016,9 +1337,11 @@ func SetIrcConfig(ctx *cli.Context, stack *node.Node, cfg *irc.Config) {
 
 	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
 	setCoinbase(ctx, ks, cfg)
+	setCheckpointOracle(ctx, ks, cfg)
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
 	setIrchash(ctx, cfg)
+	setMiner(ctx, &cfg.Miner)
 
 	switch {
 	case ctx.GlobalIsSet(SyncModeFlag.Name):
@@ -1034,6 +1357,7 @@ func SetIrcConfig(ctx *cli.Context, stack *node.Node, cfg *irc.Config) {
 	if ctx.GlobalIsSet(LightPeersFlag.Name) {
 		cfg.LightPeers = ctx.GlobalInt(LightPeersFlag.Name)
 	}
+	setULC(ctx, cfg)
 	if ctx.GlobalIsSet(NetworkIdFlag.Name) {
 		cfg.NetworkId = ctx.GlobalUint64(NetworkIdFlag.Name)
 	}
@@ -1172,6 +1496,26 @@ func RegisterIrcStatsService(stack *node.Node, url string) {
 	}
 }
 
+// RegisterGraphQLService configures the GraphQL query service on top of the
+// full or light IrChain service and adds it to the given node.
+func RegisterGraphQLService(stack *node.Node, ui bool) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		// Try to construct the GraphQL service backed by a full node
+		var ircServ *irc.IrChain
+		if err := ctx.Service(&ircServ); err == nil {
+			return graphql.New(ircServ.ApiBackend, ui)
+		}
+		// Try to construct the GraphQL service backed by a light node
+		var lesServ *les.LightIrChain
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend, ui)
+		}
+		return nil, errors.New("no IrChain service")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
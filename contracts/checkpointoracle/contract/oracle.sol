@@ -0,0 +1,134 @@
+pragma solidity ^0.4.24;
+
+/**
+ * @title CheckpointOracle
+ * @dev Collects the votes of trusted signers on light client checkpoints and
+ * publishes the latest checkpoint which reached the vote threshold. Calls are
+ * paid by the contract, so it needs to be funded for votes to be accepted.
+ */
+contract CheckpointOracle {
+    /*
+        Events
+    */
+
+    // NewCheckpointVote is emitted when a new checkpoint vote is accepted.
+    event NewCheckpointVote(uint64 indexed index, bytes32 checkpointHash, uint8 v, bytes32 r, bytes32 s);
+
+    /*
+        Types
+    */
+
+    struct Vote {
+        uint8 v;
+        bytes32 r;
+        bytes32 s;
+    }
+
+    /*
+        Fields
+    */
+
+    // Signers allowed to vote on checkpoints
+    mapping(address => bool) admins;
+
+    // Number of votes required to accept a checkpoint
+    uint threshold;
+
+    // Latest accepted checkpoint
+    uint64 latestIndex;
+    bytes32 latestSectionHead;
+    bytes32 latestChtRoot;
+    bytes32 latestBloomTrieRoot;
+
+    // Number of votes received for each checkpoint hash
+    mapping(bytes32 => uint) voteCounts;
+
+    // Votes of each signer for each section
+    mapping(uint64 => mapping(address => Vote)) votes;
+
+    /*
+        Public Functions
+    */
+
+    constructor(address[] _admins, uint _threshold) public {
+        for (uint i = 0; i < _admins.length; i++) {
+            admins[_admins[i]] = true;
+        }
+        threshold = _threshold;
+    }
+
+    /**
+     * @dev Accept funds to pay for votes.
+     */
+    function() public payable {}
+
+    /**
+     * @dev Get the latest accepted checkpoint.
+     * @return section index and the section head, CHT root and bloom trie root
+     */
+    function GetLatestCheckpoint()
+    view
+    public
+    returns(uint64, bytes32, bytes32, bytes32) {
+        return (latestIndex, latestSectionHead, latestChtRoot, latestBloomTrieRoot);
+    }
+
+    /**
+     * @dev Get the vote of a signer for a section.
+     * @param _index section index
+     * @param _signer address of the signer
+     * @return signature of the vote, all zero if the signer didn't vote
+     */
+    function GetCheckpointVote(uint64 _index, address _signer)
+    view
+    public
+    returns(uint8, bytes32, bytes32) {
+        Vote storage vote = votes[_index][_signer];
+        return (vote.v, vote.r, vote.s);
+    }
+
+    /**
+     * @dev Vote on a checkpoint. The checkpoint is accepted when it has been
+     * signed by enough signers and is newer than the latest checkpoint.
+     * @param _sectionIndex section index
+     * @param _sectionHead hash of the last header in the section
+     * @param _chtRoot root of the canonical hash trie
+     * @param _bloomTrieRoot root of the bloom trie
+     * @param v signature recovery id
+     * @param r signature r value
+     * @param s signature s value
+     * @return true if the vote was accepted
+     */
+    function SetCheckpoint(
+        uint64 _sectionIndex,
+        bytes32 _sectionHead,
+        bytes32 _chtRoot,
+        bytes32 _bloomTrieRoot,
+        uint8 v,
+        bytes32 r,
+        bytes32 s
+    )
+    public
+    returns (bool)
+    {
+        // EIP-191 version 0x00 digest, binding the vote to this oracle
+        bytes32 hash = keccak256(abi.encodePacked(byte(0x19), byte(0), this, _sectionIndex, _sectionHead, _chtRoot, _bloomTrieRoot));
+        address signer = ecrecover(hash, v, r, s);
+
+        require(admins[signer]);
+        require(_sectionIndex >= latestIndex);
+        require(votes[_sectionIndex][signer].v == 0);
+
+        votes[_sectionIndex][signer] = Vote(v, r, s);
+        emit NewCheckpointVote(_sectionIndex, hash, v, r, s);
+
+        voteCounts[hash]++;
+        if (voteCounts[hash] >= threshold && (latestSectionHead == 0 || _sectionIndex > latestIndex)) {
+            latestIndex = _sectionIndex;
+            latestSectionHead = _sectionHead;
+            latestChtRoot = _chtRoot;
+            latestBloomTrieRoot = _bloomTrieRoot;
+        }
+        return true;
+    }
+}
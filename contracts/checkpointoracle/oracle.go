@@ -0,0 +1,168 @@
+// Copyright 2016 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package checkpointoracle is a wrapper of the checkpoint oracle contract, which
+// collects the votes of trusted signers on light client checkpoints.
+package checkpointoracle
+
+//go:generate abigen --sol contract/oracle.sol --pkg contract --out contract/oracle.go
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/irchain/go-irchain/accounts/abi/bind"
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/contracts/checkpointoracle/contract"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/light"
+)
+
+var (
+	ErrNoCheckpoint       = errors.New("no checkpoint registered")
+	ErrNotEnoughVotes     = errors.New("not enough valid checkpoint votes")
+	ErrReadOnly           = errors.New("oracle backend can't send transactions")
+	errInvalidSignatureV  = errors.New("invalid signature recovery id")
+	errInvalidSignatureLn = errors.New("invalid signature length")
+)
+
+// CheckpointOracle is a Go wrapper around an on-chain checkpoint oracle contract.
+type CheckpointOracle struct {
+	address  common.Address
+	backend  bind.ContractCaller
+	contract *contract.CheckpointOracleCaller
+}
+
+// NewCheckpointOracle binds the oracle contract at the given address. Voting
+// requires a backend which also implements bind.ContractTransactor.
+func NewCheckpointOracle(contractAddr common.Address, backend bind.ContractCaller) (*CheckpointOracle, error) {
+	c, err := contract.NewCheckpointOracleCaller(contractAddr, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &CheckpointOracle{address: contractAddr, backend: backend, contract: c}, nil
+}
+
+// DeployCheckpointOracle deploys a new oracle contract accepting checkpoints
+// signed by threshold of the given signers.
+func DeployCheckpointOracle(opts *bind.TransactOpts, backend bind.ContractBackend, signers []common.Address, threshold uint64) (common.Address, *CheckpointOracle, error) {
+	addr, _, _, err := contract.DeployCheckpointOracle(opts, backend, signers, new(big.Int).SetUint64(threshold))
+	if err != nil {
+		return addr, nil, err
+	}
+	oracle, err := NewCheckpointOracle(addr, backend)
+	return addr, oracle, err
+}
+
+// Address returns the address of the oracle contract.
+func (oracle *CheckpointOracle) Address() common.Address {
+	return oracle.address
+}
+
+// LatestCheckpoint returns the latest checkpoint accepted by the oracle.
+func (oracle *CheckpointOracle) LatestCheckpoint(opts *bind.CallOpts) (*light.TrustedCheckpoint, error) {
+	index, head, cht, bloom, err := oracle.contract.GetLatestCheckpoint(opts)
+	if err != nil {
+		return nil, err
+	}
+	if head == (common.Hash{}) {
+		return nil, ErrNoCheckpoint
+	}
+	return &light.TrustedCheckpoint{
+		Name:          "oracle",
+		SectionIdx:    index,
+		SectionHead:   head,
+		ChtRoot:       cht,
+		BloomTrieRoot: bloom,
+	}, nil
+}
+
+// Vote returns the signature of a signer for the given section, or nil if the
+// signer didn't vote.
+func (oracle *CheckpointOracle) Vote(opts *bind.CallOpts, index uint64, signer common.Address) ([]byte, error) {
+	v, r, s, err := oracle.contract.GetCheckpointVote(opts, index, signer)
+	if err != nil {
+		return nil, err
+	}
+	if v == 0 {
+		return nil, nil
+	}
+	sig := make([]byte, 65)
+	copy(sig[:32], r[:])
+	copy(sig[32:64], s[:])
+	sig[64] = v - 27
+	return sig, nil
+}
+
+// VerifyCheckpoint checks that the checkpoint was signed by at least threshold
+// of the given signers. The signatures are checked locally, so the result can be
+// trusted even if the backend can't. Signers listed more than once are counted
+// once.
+func (oracle *CheckpointOracle) VerifyCheckpoint(opts *bind.CallOpts, cp *light.TrustedCheckpoint, signers []common.Address, threshold uint64) error {
+	hash := cp.SignHash(oracle.address)
+
+	var (
+		valid uint64
+		seen  = make(map[common.Address]bool, len(signers))
+	)
+	for _, signer := range signers {
+		if seen[signer] {
+			continue
+		}
+		seen[signer] = true
+
+		sig, err := oracle.Vote(opts, cp.SectionIdx, signer)
+		if err != nil {
+			return err
+		}
+		if sig == nil {
+			continue
+		}
+		pubkey, err := crypto.SigToPub(hash[:], sig)
+		if err != nil || crypto.PubkeyToAddress(*pubkey) != signer {
+			continue
+		}
+		if valid++; valid >= threshold {
+			return nil
+		}
+	}
+	return ErrNotEnoughVotes
+}
+
+// RegisterCheckpoint submits a vote on a checkpoint. The signature is the
+// signer's 65 byte [R || S || V] signature of the checkpoint's signing hash for
+// this oracle.
+func (oracle *CheckpointOracle) RegisterCheckpoint(opts *bind.TransactOpts, cp *light.TrustedCheckpoint, sig []byte) (*types.Transaction, error) {
+	transactor, ok := oracle.backend.(bind.ContractTransactor)
+	if !ok {
+		return nil, ErrReadOnly
+	}
+	if len(sig) != 65 {
+		return nil, errInvalidSignatureLn
+	}
+	if sig[64] > 1 {
+		return nil, errInvalidSignatureV
+	}
+	c, err := contract.NewCheckpointOracleTransactor(oracle.address, transactor)
+	if err != nil {
+		return nil, err
+	}
+	var r, s [32]byte
+	copy(r[:], sig[:32])
+	copy(s[:], sig[32:64])
+	return c.SetCheckpoint(opts, cp.SectionIdx, cp.SectionHead, cp.ChtRoot, cp.BloomTrieRoot, sig[64]+27, r, s)
+}
@@ -0,0 +1,137 @@
+// Copyright 2016 The happyuc-go Authors
+// This file is part of the happyuc-go library.
+//
+// The happyuc-go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The happyuc-go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the happyuc-go library. If not, see <http://www.gnu.org/licenses/>.
+
+package checkpointoracle
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/irchain/go-irchain/accounts/abi/bind"
+	"github.com/irchain/go-irchain/accounts/abi/bind/backends"
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/contracts/checkpointoracle/contract"
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/light"
+)
+
+var (
+	key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	addr   = crypto.PubkeyToAddress(key.PublicKey)
+)
+
+func newTestSigners(n int) ([]*ecdsa.PrivateKey, []common.Address) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	addrs := make([]common.Address, n)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		addrs[i] = crypto.PubkeyToAddress(keys[i].PublicKey)
+	}
+	return keys, addrs
+}
+
+func signCheckpoint(t *testing.T, oracle common.Address, cp *light.TrustedCheckpoint, key *ecdsa.PrivateKey) []byte {
+	hash := cp.SignHash(oracle)
+	sig, err := crypto.Sign(hash[:], key)
+	if err != nil {
+		t.Fatalf("can't sign checkpoint: %v", err)
+	}
+	return sig
+}
+
+func TestCheckpointOracle(t *testing.T) {
+	backend := backends.NewSimulatedBackend(core.GenesisAlloc{addr: {Balance: big.NewInt(1000000000)}})
+	opts := bind.NewKeyedTransactor(key)
+
+	keys, signers := newTestSigners(3)
+	oracleAddr, oracle, err := DeployCheckpointOracle(opts, backend, signers, 2)
+	if err != nil {
+		t.Fatalf("can't deploy oracle: %v", err)
+	}
+	backend.Commit()
+
+	// Fund the oracle, it pays for calls and votes.
+	raw, _ := contract.NewCheckpointOracle(oracleAddr, backend)
+	opts.Value = big.NewInt(500000000)
+	if _, err := (&contract.CheckpointOracleRaw{Contract: raw}).Transfer(opts); err != nil {
+		t.Fatalf("can't fund oracle: %v", err)
+	}
+	opts.Value = nil
+	backend.Commit()
+
+	if _, err := oracle.LatestCheckpoint(nil); err != ErrNoCheckpoint {
+		t.Fatalf("empty oracle: got %v, want %v", err, ErrNoCheckpoint)
+	}
+	cp := &light.TrustedCheckpoint{
+		SectionIdx:    3,
+		SectionHead:   common.HexToHash("0x01"),
+		ChtRoot:       common.HexToHash("0x02"),
+		BloomTrieRoot: common.HexToHash("0x03"),
+	}
+	// Votes signed for another oracle are rejected.
+	if _, err := oracle.RegisterCheckpoint(opts, cp, signCheckpoint(t, common.Address{0x01}, cp, keys[0])); err == nil {
+		t.Fatal("vote for other oracle accepted")
+	}
+	// Votes of unknown signers are rejected.
+	outsider, _ := crypto.GenerateKey()
+	if _, err := oracle.RegisterCheckpoint(opts, cp, signCheckpoint(t, oracleAddr, cp, outsider)); err == nil {
+		t.Fatal("vote of unknown signer accepted")
+	}
+	// A single vote is below the threshold.
+	if _, err := oracle.RegisterCheckpoint(opts, cp, signCheckpoint(t, oracleAddr, cp, keys[0])); err != nil {
+		t.Fatalf("can't vote: %v", err)
+	}
+	backend.Commit()
+	if _, err := oracle.LatestCheckpoint(nil); err != ErrNoCheckpoint {
+		t.Fatalf("checkpoint accepted below threshold: %v", err)
+	}
+	if err := oracle.VerifyCheckpoint(nil, cp, signers, 2); err != ErrNotEnoughVotes {
+		t.Fatalf("verification below threshold: got %v, want %v", err, ErrNotEnoughVotes)
+	}
+	// Signers can't vote twice.
+	if _, err := oracle.RegisterCheckpoint(opts, cp, signCheckpoint(t, oracleAddr, cp, keys[0])); err == nil {
+		t.Fatal("duplicate vote accepted")
+	}
+	// The second vote reaches the threshold.
+	if _, err := oracle.RegisterCheckpoint(opts, cp, signCheckpoint(t, oracleAddr, cp, keys[1])); err != nil {
+		t.Fatalf("can't vote: %v", err)
+	}
+	backend.Commit()
+
+	latest, err := oracle.LatestCheckpoint(nil)
+	if err != nil {
+		t.Fatalf("can't get latest checkpoint: %v", err)
+	}
+	if latest.Hash() != cp.Hash() {
+		t.Fatalf("latest checkpoint mismatch: got %+v, want %+v", latest, cp)
+	}
+	if err := oracle.VerifyCheckpoint(nil, latest, signers, 2); err != nil {
+		t.Fatalf("can't verify checkpoint: %v", err)
+	}
+	if err := oracle.VerifyCheckpoint(nil, latest, signers[1:], 2); err != ErrNotEnoughVotes {
+		t.Fatalf("verification with one trusted signer: got %v, want %v", err, ErrNotEnoughVotes)
+	}
+	if err := oracle.VerifyCheckpoint(nil, latest, []common.Address{signers[1], signers[1]}, 2); err != ErrNotEnoughVotes {
+		t.Fatalf("verification with duplicate signer: got %v, want %v", err, ErrNotEnoughVotes)
+	}
+	// Votes on older sections are rejected.
+	old := &light.TrustedCheckpoint{SectionIdx: 2, SectionHead: common.HexToHash("0x04")}
+	if _, err := oracle.RegisterCheckpoint(opts, old, signCheckpoint(t, oracleAddr, old, keys[2])); err == nil {
+		t.Fatal("vote on old section accepted")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"hash/crc32"
+	"math/big"
+	"testing"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/params"
+)
+
+var (
+	testGenesis = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+	testConfig  = &params.ChainConfig{
+		ChainID:             big.NewInt(1),
+		ByzantiumBlock:      big.NewInt(10),
+		ConstantinopleBlock: big.NewInt(20),
+	}
+)
+
+// testSums returns the expected checksums of the test chain: genesis only, after
+// the first fork and after both forks.
+func testSums() [3][4]byte {
+	var (
+		sum0 = crc32.ChecksumIEEE(testGenesis[:])
+		sum1 = checksumUpdate(sum0, 10)
+		sum2 = checksumUpdate(sum1, 20)
+	)
+	return [3][4]byte{checksumToBytes(sum0), checksumToBytes(sum1), checksumToBytes(sum2)}
+}
+
+// Tests that fork IDs are calculated correctly at various points of the chain.
+func TestCreation(t *testing.T) {
+	sums := testSums()
+
+	tests := []struct {
+		head uint64
+		want ID
+	}{
+		{0, ID{Hash: sums[0], Next: 10}},
+		{9, ID{Hash: sums[0], Next: 10}},
+		{10, ID{Hash: sums[1], Next: 20}},
+		{19, ID{Hash: sums[1], Next: 20}},
+		{20, ID{Hash: sums[2], Next: 0}},
+		{100000, ID{Hash: sums[2], Next: 0}},
+	}
+	for i, tt := range tests {
+		if have := NewID(testConfig, testGenesis, tt.head); have != tt.want {
+			t.Errorf("test %d: fork ID mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that forks at genesis and duplicate fork blocks are not checksummed.
+func TestGatherForks(t *testing.T) {
+	config := &params.ChainConfig{ByzantiumBlock: big.NewInt(0), ConstantinopleBlock: big.NewInt(0)}
+	if forks := gatherForks(config); len(forks) != 0 {
+		t.Errorf("genesis forks gathered: %v", forks)
+	}
+	config = &params.ChainConfig{ByzantiumBlock: big.NewInt(5), ConstantinopleBlock: big.NewInt(5)}
+	if forks := gatherForks(config); len(forks) != 1 || forks[0] != 5 {
+		t.Errorf("duplicate forks mismatch: have %v, want [5]", forks)
+	}
+}
+
+// Tests that the fork ID filter accepts compatible and rejects incompatible
+// remote fork IDs.
+func TestValidation(t *testing.T) {
+	sums := testSums()
+
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local and remote are in the same fork state, no future forks announced.
+		{15, ID{Hash: sums[1], Next: 0}, nil},
+
+		// Local and remote are in the same fork state, same next fork announced.
+		{15, ID{Hash: sums[1], Next: 20}, nil},
+
+		// Local and remote are in the same fork state, remote announces a fork
+		// not yet passed locally.
+		{15, ID{Hash: sums[1], Next: 30}, nil},
+
+		// Local and remote are in the same fork state, remote announces a fork
+		// that is already passed locally.
+		{25, ID{Hash: sums[2], Next: 22}, ErrLocalIncompatibleOrStale},
+
+		// Remote is syncing, its checksum is a subset of ours with the correct
+		// next fork.
+		{25, ID{Hash: sums[1], Next: 20}, nil},
+
+		// Remote is stale, its checksum is a subset of ours but it does not know
+		// about the next fork.
+		{25, ID{Hash: sums[1], Next: 0}, ErrRemoteStale},
+
+		// Local is syncing, the remote checksum is a superset of ours.
+		{5, ID{Hash: sums[2], Next: 0}, nil},
+
+		// Remote is on an entirely different chain.
+		{15, ID{Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}, Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	for i, tt := range tests {
+		head := tt.head
+		filter := newFilter(testConfig, testGenesis, func() uint64 { return head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -435,3 +435,28 @@ func TestCopyOfCopy(t *testing.T) {
 		t.Fatalf("2nd copy fail, expected 42, got %v", got)
 	}
 }
+
+func TestSetStorage(t *testing.T) {
+	sdb, _ := New(common.Hash{}, NewDatabase(ircdb.NewMemDatabase()))
+	addr := common.HexToAddress("aaaa")
+	sdb.SetState(addr, common.Hash{1}, common.Hash{1})
+	sdb.SetState(addr, common.Hash{2}, common.Hash{2})
+	root, _ := sdb.Commit(false)
+	sdb, _ = New(root, sdb.db)
+
+	sdb.SetStorage(addr, map[common.Hash]common.Hash{{2}: {3}})
+	if got := sdb.GetState(addr, common.Hash{1}); got != (common.Hash{}) {
+		t.Errorf("replaced slot not cleared, got %x", got)
+	}
+	if got := sdb.GetState(addr, common.Hash{2}); got != (common.Hash{3}) {
+		t.Errorf("replaced slot mismatch, got %x", got)
+	}
+	// Writes on top of the fake storage are visible, also in copies
+	sdb.SetState(addr, common.Hash{4}, common.Hash{4})
+	cpy := sdb.Copy()
+	for key, want := range map[common.Hash]common.Hash{{1}: {}, {2}: {3}, {4}: {4}} {
+		if got := cpy.GetState(addr, key); got != want {
+			t.Errorf("copy slot %x mismatch: have %x, want %x", key, got, want)
+		}
+	}
+}
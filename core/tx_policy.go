@@ -0,0 +1,196 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/core/types"
+)
+
+var (
+	// ErrContractCreationBlocked is returned if a contract creation transaction
+	// is submitted to a pool configured to reject them.
+	ErrContractCreationBlocked = errors.New("contract creation disabled")
+
+	// ErrSenderDenied is returned if the sender of a transaction is on the
+	// pool's deny list.
+	ErrSenderDenied = errors.New("sender denied")
+
+	// ErrSenderNotAllowed is returned if the pool has an allow list configured
+	// and the sender of a transaction is not on it.
+	ErrSenderNotAllowed = errors.New("sender not allowed")
+)
+
+// TxPolicy is an admission rule consulted for every transaction entering the
+// pool, after the consensus and built in validity checks have passed.
+type TxPolicy interface {
+	// Name returns a short identifier of the policy used in rejection reports.
+	Name() string
+
+	// Admit returns an error if the transaction must not enter the pool.
+	Admit(tx *types.Transaction, from common.Address, local bool) error
+}
+
+// TxPolicyError is returned if a transaction is rejected by an admission policy.
+type TxPolicyError struct {
+	Policy string // Name of the rejecting policy
+	Err    error  // Reason for the rejection
+}
+
+func (e *TxPolicyError) Error() string {
+	return fmt.Sprintf("rejected by %s policy: %v", e.Policy, e.Err)
+}
+
+// TxSenderClass groups a set of accounts under a dedicated minimum gas price.
+type TxSenderClass struct {
+	Name       string           // Name of the class, used in rejection reports
+	Accounts   []common.Address // Accounts belonging to the class
+	PriceLimit uint64           // Minimum gas price to enforce for the class
+}
+
+// policies assembles the admission policy chain described by the config.
+func (config *TxPoolConfig) policies() []TxPolicy {
+	var policies []TxPolicy
+
+	if len(config.DenyList) > 0 || len(config.AllowList) > 0 {
+		policies = append(policies, newSenderListPolicy(config.AllowList, config.DenyList))
+	}
+	if config.MaxTxSize > 0 {
+		policies = append(policies, txSizePolicy(config.MaxTxSize))
+	}
+	if config.NoContractCreation {
+		policies = append(policies, contractCreationPolicy{})
+	}
+	if len(config.SenderClasses) > 0 {
+		policies = append(policies, newSenderClassPolicy(config.SenderClasses))
+	}
+	return append(policies, config.Policies...)
+}
+
+// senderListPolicy admits transactions based on allow and deny lists of senders.
+// Local transactions are subject to the lists too.
+type senderListPolicy struct {
+	allow map[common.Address]struct{}
+	deny  map[common.Address]struct{}
+}
+
+func newSenderListPolicy(allow, deny []common.Address) *senderListPolicy {
+	policy := &senderListPolicy{
+		allow: make(map[common.Address]struct{}),
+		deny:  make(map[common.Address]struct{}),
+	}
+	for _, addr := range allow {
+		policy.allow[addr] = struct{}{}
+	}
+	for _, addr := range deny {
+		policy.deny[addr] = struct{}{}
+	}
+	return policy
+}
+
+func (p *senderListPolicy) Name() string { return "sender list" }
+
+func (p *senderListPolicy) Admit(tx *types.Transaction, from common.Address, local bool) error {
+	if _, ok := p.deny[from]; ok {
+		return ErrSenderDenied
+	}
+	if len(p.allow) > 0 {
+		if _, ok := p.allow[from]; !ok {
+			return ErrSenderNotAllowed
+		}
+	}
+	return nil
+}
+
+// txSizePolicy rejects transactions above a configured size.
+type txSizePolicy uint64
+
+func (p txSizePolicy) Name() string { return "size" }
+
+func (p txSizePolicy) Admit(tx *types.Transaction, from common.Address, local bool) error {
+	if uint64(tx.Size()) > uint64(p) {
+		return ErrOversizedData
+	}
+	return nil
+}
+
+// contractCreationPolicy rejects all contract creation transactions.
+type contractCreationPolicy struct{}
+
+func (contractCreationPolicy) Name() string { return "contract creation" }
+
+func (contractCreationPolicy) Admit(tx *types.Transaction, from common.Address, local bool) error {
+	if tx.To() == nil {
+		return ErrContractCreationBlocked
+	}
+	return nil
+}
+
+// senderClassPolicy enforces per class minimum gas prices. Similarly to the
+// pool wide price limit, local transactions are exempt.
+type senderClassPolicy struct {
+	classes map[common.Address]TxSenderClass
+}
+
+func newSenderClassPolicy(classes []TxSenderClass) *senderClassPolicy {
+	policy := &senderClassPolicy{classes: make(map[common.Address]TxSenderClass)}
+	for _, class := range classes {
+		for _, addr := range class.Accounts {
+			policy.classes[addr] = class
+		}
+	}
+	return policy
+}
+
+func (p *senderClassPolicy) Name() string { return "sender class" }
+
+func (p *senderClassPolicy) Admit(tx *types.Transaction, from common.Address, local bool) error {
+	class, ok := p.classes[from]
+	if !ok || local {
+		return nil
+	}
+	if tx.GasPrice().Cmp(new(big.Int).SetUint64(class.PriceLimit)) < 0 {
+		return fmt.Errorf("%v: class %s requires %d", ErrUnderpriced, class.Name, class.PriceLimit)
+	}
+	return nil
+}
+
+// TxDropKind is the way a transaction left the pool without being included.
+type TxDropKind string
+
+const (
+	TxRejected TxDropKind = "rejected" // Transaction never entered the pool
+	TxDropped  TxDropKind = "dropped"  // Transaction was evicted from the pool
+	TxReplaced TxDropKind = "replaced" // Transaction was replaced by another with the same nonce
+)
+
+// TxDrop is a record of a transaction being rejected, evicted or replaced by
+// the pool, along with the reason.
+type TxDrop struct {
+	Hash        common.Hash    `json:"hash"`
+	From        common.Address `json:"from"`
+	Nonce       uint64         `json:"nonce"`
+	Kind        TxDropKind     `json:"kind"`
+	Reason      string         `json:"reason"`
+	Replacement *common.Hash   `json:"replacement,omitempty"`
+	Time        time.Time      `json:"time"`
+}
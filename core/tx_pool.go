@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hashicorp/golang-lru"
 	"github.com/irchain/go-irchain/common"
 	"github.com/irchain/go-irchain/core/state"
 	"github.com/irchain/go-irchain/core/types"
@@ -144,6 +145,15 @@ type TxPoolConfig struct {
 	AccountQueue uint64        // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64        // Maximum number of non-executable transaction slots for all accounts
 	Lifetime     time.Duration // Maximum amount of time non-executable transaction are queued
+
+	MaxTxSize          uint64           // Maximum transaction size admitted into the pool (0 = consensus cap only)
+	NoContractCreation bool             // Whether contract creation transactions are rejected
+	AllowList          []common.Address // Senders admitted exclusively, if non-empty
+	DenyList           []common.Address // Senders whose transactions are always rejected
+	SenderClasses      []TxSenderClass  // Minimum gas prices enforced for groups of senders
+	Policies           []TxPolicy       `toml:"-"` // Custom admission policies run after the configured ones
+
+	DropHistory int // Number of dropped transaction records retained for lookups
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -158,6 +168,8 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 	Lifetime:     3 * time.Hour,
+
+	DropHistory: 4096,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -176,6 +188,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.DropHistory < 1 {
+		log.Warn("Sanitizing invalid txpool drop history", "provided", conf.DropHistory, "updated", DefaultTxPoolConfig.DropHistory)
+		conf.DropHistory = DefaultTxPoolConfig.DropHistory
+	}
 	return conf
 }
 
@@ -191,7 +207,9 @@ type TxPool struct {
 	chainconfig  *params.ChainConfig
 	chain        blockChain
 	gasPrice     *big.Int
+	policies     []TxPolicy
 	txFeed       event.Feed
+	dropFeed     event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -211,6 +229,9 @@ type TxPool struct {
 	all     *txLookup                    // All transactions to allow lookups
 	priced  *txPricedList                // All transactions sorted by price
 
+	drops       []*TxDrop  // Drops accumulated during the current operation, pending notification
+	dropHistory *lru.Cache // Recently dropped transactions for later lookups
+
 	wg sync.WaitGroup // for shutdown sync
 }
 
@@ -232,7 +253,9 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		all:         newTxLookup(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
+		policies:    config.policies(),
 	}
+	pool.dropHistory, _ = lru.New(config.DropHistory)
 	pool.locals = newAccountSet(pool.signer)
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
@@ -287,6 +310,7 @@ func (pool *TxPool) loop() {
 			if ev.Block != nil {
 				pool.mu.Lock()
 				pool.reset(head.Header(), ev.Block.Header())
+				pool.flushDrops()
 				head = ev.Block
 
 				pool.mu.Unlock()
@@ -319,9 +343,11 @@ func (pool *TxPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash(), true)
+						pool.reportDrop(tx, TxDropped, "queued lifetime exceeded", nil)
 					}
 				}
 			}
+			pool.flushDrops()
 			pool.mu.Unlock()
 
 			// Handle local transaction journal rotation
@@ -344,6 +370,7 @@ func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 	defer pool.mu.Unlock()
 
 	pool.reset(oldHead, newHead)
+	pool.flushDrops()
 }
 
 // reset retrieves the current state of the blockchain and ensures the content
@@ -451,6 +478,54 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeDropTxsEvent registers a subscription of DropTxsEvent, reporting
+// transactions rejected, evicted or replaced by the pool.
+func (pool *TxPool) SubscribeDropTxsEvent(ch chan<- DropTxsEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
+// Dropped retrieves the record of a recently rejected, evicted or replaced
+// transaction, or nil if the pool has no knowledge of it leaving.
+func (pool *TxPool) Dropped(hash common.Hash) *TxDrop {
+	if drop, ok := pool.dropHistory.Get(hash); ok {
+		return drop.(*TxDrop)
+	}
+	return nil
+}
+
+// reportDrop records a transaction leaving the pool (or never entering it) for
+// later lookups and queues it up for subscriber notification.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) reportDrop(tx *types.Transaction, kind TxDropKind, reason string, replacement *types.Transaction) {
+	from, _ := types.Sender(pool.signer, tx)
+	drop := &TxDrop{
+		Hash:   tx.Hash(),
+		From:   from,
+		Nonce:  tx.Nonce(),
+		Kind:   kind,
+		Reason: reason,
+		Time:   time.Now(),
+	}
+	if replacement != nil {
+		hash := replacement.Hash()
+		drop.Replacement = &hash
+	}
+	pool.dropHistory.Add(drop.Hash, drop)
+	pool.drops = append(pool.drops, drop)
+}
+
+// flushDrops notifies subscribers of all the drops accumulated since the last
+// flush.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) flushDrops() {
+	if len(pool.drops) > 0 {
+		go pool.dropFeed.Send(DropTxsEvent{pool.drops})
+		pool.drops = nil
+	}
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -616,7 +691,12 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if assert.Cmp(tx.Fee()) < 0 {
 		return err
 	}
-
+	// Run the configured admission policies
+	for _, policy := range pool.policies {
+		if err := policy.Admit(tx, from, local); err != nil {
+			return &TxPolicyError{Policy: policy.Name(), Err: err}
+		}
+	}
 	return nil
 }
 
@@ -652,6 +732,11 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	if err := pool.validateTx(tx, local); err != nil {
 		log.Trace("Discarding invalid transaction", "hash", hash, "err", err)
 		invalidTxCounter.Inc(1)
+		// Stale nonces are mostly rebroadcasts of already mined transactions,
+		// don't flood the drop reports with them
+		if err != ErrNonceTooLow {
+			pool.reportDrop(tx, TxRejected, err.Error(), nil)
+		}
 		return false, err
 	}
 	// If the transaction pool is full, discard underpriced transactions
@@ -660,6 +745,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		if !local && pool.priced.Underpriced(tx, pool.locals) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
+			pool.reportDrop(tx, TxRejected, "pool full, "+ErrUnderpriced.Error(), nil)
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
@@ -668,6 +754,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			pool.removeTx(tx.Hash(), false)
+			pool.reportDrop(tx, TxDropped, "pool full, evicted by higher priced transaction", nil)
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
@@ -677,6 +764,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		inserted, old := list.Add(tx, pool.config.PriceBump)
 		if !inserted {
 			pendingDiscardCounter.Inc(1)
+			pool.reportDrop(tx, TxRejected, ErrReplaceUnderpriced.Error(), nil)
 			return false, ErrReplaceUnderpriced
 		}
 		// New transaction is better, replace old one
@@ -684,6 +772,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			pool.all.Remove(old.Hash())
 			pool.priced.Removed()
 			pendingReplaceCounter.Inc(1)
+			pool.reportDrop(old, TxReplaced, "replaced by higher priced transaction", tx)
 		}
 		pool.all.Add(tx)
 		pool.priced.Put(tx)
@@ -724,6 +813,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	if !inserted {
 		// An older transaction was better, discard this
 		queuedDiscardCounter.Inc(1)
+		pool.reportDrop(tx, TxRejected, ErrReplaceUnderpriced.Error(), nil)
 		return false, ErrReplaceUnderpriced
 	}
 	// Discard any previous transaction and mark this
@@ -731,6 +821,7 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 		pool.all.Remove(old.Hash())
 		pool.priced.Removed()
 		queuedReplaceCounter.Inc(1)
+		pool.reportDrop(old, TxReplaced, "replaced by higher priced transaction", tx)
 	}
 	if pool.all.Get(hash) == nil {
 		pool.all.Add(tx)
@@ -757,6 +848,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingDiscardCounter.Inc(1)
+		pool.reportDrop(tx, TxDropped, "pending transaction with same nonce is better priced", nil)
 		return false
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -765,6 +857,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingReplaceCounter.Inc(1)
+		pool.reportDrop(old, TxReplaced, "replaced by higher priced transaction", tx)
 	}
 	// Failsafe to work around direct pending inserts (tests)
 	if pool.all.Get(hash) == nil {
@@ -811,6 +904,8 @@ func (pool *TxPool) addTx(tx *types.Transaction, local bool) error {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 
+	defer pool.flushDrops()
+
 	// Try to inject the transaction and update any state
 	replace, err := pool.add(tx, local)
 	if err != nil {
@@ -835,6 +930,8 @@ func (pool *TxPool) addTxs(txs []*types.Transaction, local bool) []error {
 // addTxsLocked attempts to queue a batch of transactions if they are valid,
 // whilst assuming the transaction pool lock is already held.
 func (pool *TxPool) addTxsLocked(txs []*types.Transaction, local bool) []error {
+	defer pool.flushDrops()
+
 	// Add the batch of transaction, tracking the accepted ones
 	dirty := make(map[common.Address]struct{})
 	errs := make([]error, len(txs))
@@ -963,6 +1060,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			pool.all.Remove(hash)
 			pool.priced.Removed()
 			queuedNofundsCounter.Inc(1)
+			pool.reportDrop(tx, TxDropped, "insufficient funds or gas limit exceeded", nil)
 		}
 		// Gather all executable transactions and promote them
 		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
@@ -980,6 +1078,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				pool.priced.Removed()
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
+				pool.reportDrop(tx, TxDropped, "account queue limit exceeded", nil)
 			}
 		}
 		// Delete the entire queue entry if it became empty.
@@ -1033,6 +1132,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 								pool.pendingState.SetNonce(offenders[i], nonce)
 							}
 							log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+							pool.reportDrop(tx, TxDropped, "pending pool limit exceeded", nil)
 						}
 						pending--
 					}
@@ -1055,6 +1155,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							pool.pendingState.SetNonce(addr, nonce)
 						}
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+						pool.reportDrop(tx, TxDropped, "pending pool limit exceeded", nil)
 					}
 					pending--
 				}
@@ -1088,6 +1189,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash(), true)
+					pool.reportDrop(tx, TxDropped, "global queue limit exceeded", nil)
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
@@ -1097,6 +1199,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash(), true)
+				pool.reportDrop(txs[i], TxDropped, "global queue limit exceeded", nil)
 				drop--
 				queuedRateLimitCounter.Inc(1)
 			}
@@ -1127,6 +1230,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			pool.all.Remove(hash)
 			pool.priced.Removed()
 			pendingNofundsCounter.Inc(1)
+			pool.reportDrop(tx, TxDropped, "insufficient funds or gas limit exceeded", nil)
 		}
 		for _, tx := range invalids {
 			hash := tx.Hash()
@@ -18,6 +18,7 @@ package core
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -1567,6 +1568,207 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// fundedTransaction creates a value transfer carrying enough value to pay for
+// its own fees.
+func fundedTransaction(nonce uint64, to *common.Address, gasprice *big.Int, data []byte, key *ecdsa.PrivateKey) *types.Transaction {
+	var tx *types.Transaction
+	if to == nil {
+		tx = types.NewContractCreation(nonce, big.NewInt(1000000000), 100000, gasprice, data)
+	} else {
+		tx = types.NewTransaction(nonce, *to, big.NewInt(1000000000), 100000, gasprice, data)
+	}
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, key)
+	return tx
+}
+
+// Tests that the configured admission policies are enforced and that custom
+// ones can be plugged into the pool.
+func TestTransactionAdmissionPolicies(t *testing.T) {
+	t.Parallel()
+
+	var (
+		denied, _   = crypto.GenerateKey()
+		premium, _  = crypto.GenerateKey()
+		regular, _  = crypto.GenerateKey()
+		custom, _   = crypto.GenerateKey()
+		recipient   = common.Address{0x01}
+		statedb, _  = state.New(common.Hash{}, state.NewDatabase(ircdb.NewMemDatabase()))
+		blockchain  = &testBlockChain{statedb, 1000000, new(event.Feed)}
+		customError = errors.New("custom rejection")
+	)
+	for _, key := range []*ecdsa.PrivateKey{denied, premium, regular, custom} {
+		statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000))
+	}
+	statedb.AddBalance(recipient, big.NewInt(1000000000000)) // contract calls are paid by the callee
+	config := testTxPoolConfig
+	config.MaxTxSize = 256
+	config.NoContractCreation = true
+	config.DenyList = []common.Address{crypto.PubkeyToAddress(denied.PublicKey)}
+	config.SenderClasses = []TxSenderClass{{
+		Name:       "premium",
+		Accounts:   []common.Address{crypto.PubkeyToAddress(premium.PublicKey)},
+		PriceLimit: 10,
+	}}
+	config.Policies = []TxPolicy{testTxPolicy{crypto.PubkeyToAddress(custom.PublicKey), customError}}
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	tests := []struct {
+		tx     *types.Transaction
+		local  bool
+		policy string
+		err    error
+	}{
+		{tx: fundedTransaction(0, &recipient, big.NewInt(1), nil, denied), policy: "sender list", err: ErrSenderDenied},
+		{tx: fundedTransaction(0, &recipient, big.NewInt(1), nil, denied), local: true, policy: "sender list", err: ErrSenderDenied},
+		{tx: fundedTransaction(0, &recipient, big.NewInt(1), make([]byte, 512), regular), policy: "size", err: ErrOversizedData},
+		{tx: fundedTransaction(0, nil, big.NewInt(1), nil, regular), policy: "contract creation", err: ErrContractCreationBlocked},
+		{tx: fundedTransaction(0, &recipient, big.NewInt(9), nil, premium), policy: "sender class"},
+		{tx: fundedTransaction(0, &recipient, big.NewInt(9), nil, premium), local: true},
+		{tx: fundedTransaction(0, &recipient, big.NewInt(1), nil, custom), policy: "test", err: customError},
+		{tx: fundedTransaction(0, &recipient, big.NewInt(1), nil, regular)},
+	}
+	for i, tt := range tests {
+		var err error
+		if tt.local {
+			err = pool.AddLocal(tt.tx)
+		} else {
+			err = pool.AddRemote(tt.tx)
+		}
+		if tt.policy == "" {
+			if err != nil {
+				t.Errorf("test %d: failed to add admissible transaction: %v", i, err)
+			}
+			continue
+		}
+		perr, ok := err.(*TxPolicyError)
+		if !ok {
+			t.Errorf("test %d: error type mismatch: have %v, want policy error", i, err)
+			continue
+		}
+		if perr.Policy != tt.policy {
+			t.Errorf("test %d: rejecting policy mismatch: have %s, want %s", i, perr.Policy, tt.policy)
+		}
+		if tt.err != nil && perr.Err != tt.err {
+			t.Errorf("test %d: rejection reason mismatch: have %v, want %v", i, perr.Err, tt.err)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// testTxPolicy is a custom admission policy rejecting a single sender.
+type testTxPolicy struct {
+	sender common.Address
+	err    error
+}
+
+func (p testTxPolicy) Name() string { return "test" }
+
+func (p testTxPolicy) Admit(tx *types.Transaction, from common.Address, local bool) error {
+	if from == p.sender {
+		return p.err
+	}
+	return nil
+}
+
+// Tests that rejected and replaced transactions are reported on the drop feed
+// and can be looked up afterwards.
+func TestTransactionDropReporting(t *testing.T) {
+	t.Parallel()
+
+	var (
+		key, _     = crypto.GenerateKey()
+		recipient  = common.Address{0x01}
+		statedb, _ = state.New(common.Hash{}, state.NewDatabase(ircdb.NewMemDatabase()))
+		blockchain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+	)
+	statedb.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000000000))
+	statedb.AddBalance(recipient, big.NewInt(1000000000000))
+
+	config := testTxPoolConfig
+	config.NoContractCreation = true
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	drops := make(chan DropTxsEvent, 32)
+	sub := pool.SubscribeDropTxsEvent(drops)
+	defer sub.Unsubscribe()
+
+	var (
+		creation = fundedTransaction(0, nil, big.NewInt(1), nil, key)
+		original = fundedTransaction(0, &recipient, big.NewInt(1), nil, key)
+		cheap    = fundedTransaction(0, &recipient, big.NewInt(1), []byte{0x01}, key)
+		replaced = fundedTransaction(0, &recipient, big.NewInt(2), nil, key)
+	)
+	pool.AddRemote(creation)
+	pool.AddRemote(original)
+	pool.AddRemote(cheap)
+	pool.AddRemote(replaced)
+
+	want := []struct {
+		hash        common.Hash
+		kind        TxDropKind
+		replacement *common.Hash
+	}{
+		{hash: creation.Hash(), kind: TxRejected},
+		{hash: cheap.Hash(), kind: TxRejected},
+		{hash: original.Hash(), kind: TxReplaced, replacement: &[]common.Hash{replaced.Hash()}[0]},
+	}
+	// Notifications are delivered asynchronously, so ordering is not guaranteed
+	have := make(map[common.Hash]*TxDrop)
+	for len(have) < len(want) {
+		select {
+		case ev := <-drops:
+			for _, drop := range ev.Drops {
+				have[drop.Hash] = drop
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("drop #%d not reported", len(have))
+		}
+	}
+	for i, drop := range want {
+		reported := have[drop.hash]
+		if reported == nil || reported.Kind != drop.kind {
+			t.Errorf("drop %d: mismatch: have %v, want %s", i, reported, drop.kind)
+			continue
+		}
+		if (reported.Replacement == nil) != (drop.replacement == nil) || (drop.replacement != nil && *reported.Replacement != *drop.replacement) {
+			t.Errorf("drop %d: replacement mismatch: have %v, want %v", i, reported.Replacement, drop.replacement)
+		}
+		if recorded := pool.Dropped(drop.hash); recorded == nil || recorded.Kind != drop.kind || recorded.Reason == "" {
+			t.Errorf("drop %d: invalid drop history record: %v", i, recorded)
+		}
+	}
+	if pool.Dropped(replaced.Hash()) != nil {
+		t.Errorf("pooled transaction reported as dropped")
+	}
+}
+
+// Tests that transactions with stale nonces, mostly rebroadcasts of already mined
+// ones, are not reported as dropped.
+func TestTransactionStaleNonceNotReported(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.SetNonce(from, 1)
+	pool.currentState.AddBalance(from, big.NewInt(0xffffffffffffff))
+
+	tx := transaction(0, 100000, key)
+	if err := pool.AddRemote(tx); err != ErrNonceTooLow {
+		t.Fatalf("expected %v, got %v", ErrNonceTooLow, err)
+	}
+	if drop := pool.Dropped(tx.Hash()); drop != nil {
+		t.Errorf("stale transaction reported as dropped: %v", drop)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -0,0 +1,68 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import "net/http"
+
+// uiHandler serves the GraphiQL in-browser query editor, configured to run its
+// queries against the /graphql endpoint of the same origin.
+type uiHandler struct{}
+
+func (uiHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(graphiql)
+}
+
+var graphiql = []byte(`<!DOCTYPE html>
+<html>
+  <head>
+    <title>IrChain GraphQL</title>
+    <link href="https://cdnjs.cloudflare.com/ajax/libs/graphiql/0.11.11/graphiql.min.css" rel="stylesheet" />
+    <script src="https://cdnjs.cloudflare.com/ajax/libs/es6-promise/4.1.1/es6-promise.auto.min.js"></script>
+    <script src="https://cdnjs.cloudflare.com/ajax/libs/fetch/2.0.3/fetch.min.js"></script>
+    <script src="https://cdnjs.cloudflare.com/ajax/libs/react/16.2.0/umd/react.production.min.js"></script>
+    <script src="https://cdnjs.cloudflare.com/ajax/libs/react-dom/16.2.0/umd/react-dom.production.min.js"></script>
+    <script src="https://cdnjs.cloudflare.com/ajax/libs/graphiql/0.11.11/graphiql.min.js"></script>
+    <style>body { height: 100vh; margin: 0; overflow: hidden; }</style>
+  </head>
+  <body>
+    <div id="graphiql" style="height: 100vh;">Loading...</div>
+    <script>
+      function graphQLFetcher(graphQLParams) {
+        return fetch("/graphql", {
+          method: "post",
+          headers: { "Content-Type": "application/json" },
+          body: JSON.stringify(graphQLParams),
+          credentials: "include",
+        }).then(function (response) {
+          return response.text();
+        }).then(function (responseBody) {
+          try {
+            return JSON.parse(responseBody);
+          } catch (error) {
+            return responseBody;
+          }
+        });
+      }
+      ReactDOM.render(
+        React.createElement(GraphiQL, {fetcher: graphQLFetcher}),
+        document.getElementById("graphiql")
+      );
+    </script>
+  </body>
+</html>
+`)
@@ -0,0 +1,225 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/internal/ircapi"
+	"github.com/irchain/go-irchain/rpc"
+)
+
+// testBackend serves a fixed chain of blocks, leaving the remaining backend
+// methods unimplemented.
+type testBackend struct {
+	ircapi.Backend
+	blocks []*types.Block
+}
+
+func newTestBackend(n int) *testBackend {
+	b := new(testBackend)
+	parent := common.Hash{}
+	for i := 0; i < n; i++ {
+		header := &types.Header{
+			ParentHash: parent,
+			Number:     big.NewInt(int64(i)),
+			Difficulty: big.NewInt(131072),
+			GasLimit:   8000000,
+			Time:       big.NewInt(int64(1000 + i)),
+			Extra:      []byte("test"),
+		}
+		tx := types.NewTransaction(uint64(i), common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		block := types.NewBlock(header, []*types.Transaction{tx}, nil, nil)
+		b.blocks = append(b.blocks, block)
+		parent = block.Hash()
+	}
+	return b
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.blocks[len(b.blocks)-1], nil
+	}
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	block, _ := b.BlockByNumber(ctx, number)
+	if block == nil {
+		return nil, nil
+	}
+	return block.Header(), nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) CurrentBlock() *types.Block                         { return b.blocks[len(b.blocks)-1] }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int                    { return big.NewInt(131072) }
+func (b *testBackend) SuggestPrice(ctx context.Context) (*big.Int, error) { return big.NewInt(18), nil }
+func (b *testBackend) ProtocolVersion() int                               { return 63 }
+
+func TestSchemaResolvers(t *testing.T) {
+	if _, err := newHandler(newTestBackend(1), true); err != nil {
+		t.Fatalf("schema does not match the resolvers: %v", err)
+	}
+}
+
+func TestLongUnmarshal(t *testing.T) {
+	tests := []struct {
+		input interface{}
+		want  Long
+		fail  bool
+	}{
+		{input: "0x10", want: 16},
+		{input: "42", want: 42},
+		{input: int32(7), want: 7},
+		{input: float64(1e12), want: 1000000000000},
+		{input: int32(-1), fail: true},
+		{input: float64(1.5), fail: true},
+		{input: "nonsense", fail: true},
+		{input: true, fail: true},
+	}
+	for i, tt := range tests {
+		var l Long
+		err := l.UnmarshalGraphQL(tt.input)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error for %v", i, tt.input)
+			}
+			continue
+		}
+		if err != nil || l != tt.want {
+			t.Errorf("test %d: have %d (%v), want %d", i, l, err, tt.want)
+		}
+	}
+}
+
+func TestQueryHandler(t *testing.T) {
+	backend := newTestBackend(3)
+	handler, err := newHandler(backend, false)
+	if err != nil {
+		t.Fatalf("failed to create handler: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	query := func(q string) map[string]interface{} {
+		body, _ := json.Marshal(map[string]interface{}{"query": q})
+		resp, err := http.Post(server.URL+"/graphql", "application/json", strings.NewReader(string(body)))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var result struct {
+			Data   map[string]interface{} `json:"data"`
+			Errors []interface{}          `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(result.Errors) > 0 {
+			t.Fatalf("query %q failed: %v", q, result.Errors)
+		}
+		return result.Data
+	}
+	data := query(`{ block(number: 1) { number hash transactionCount parent { number } transactionAt(index: 0) { nonce gas } } }`)
+	block := data["block"].(map[string]interface{})
+	if block["number"] != "0x1" {
+		t.Errorf("block number mismatch: have %v, want 0x1", block["number"])
+	}
+	if block["hash"] != backend.blocks[1].Hash().Hex() {
+		t.Errorf("block hash mismatch: have %v, want %s", block["hash"], backend.blocks[1].Hash().Hex())
+	}
+	if block["transactionCount"] != float64(1) {
+		t.Errorf("transaction count mismatch: have %v, want 1", block["transactionCount"])
+	}
+	if parent := block["parent"].(map[string]interface{}); parent["number"] != "0x0" {
+		t.Errorf("parent number mismatch: have %v, want 0x0", parent["number"])
+	}
+	if tx := block["transactionAt"].(map[string]interface{}); tx["nonce"] != "0x1" || tx["gas"] != "0x5208" {
+		t.Errorf("transaction mismatch: have %v", tx)
+	}
+	data = query(`{ blocks(from: 1) { number } block(number: 10) { number } gasPrice protocolVersion }`)
+	if blocks := data["blocks"].([]interface{}); len(blocks) != 2 {
+		t.Errorf("block range length mismatch: have %d, want 2", len(blocks))
+	}
+	if data["block"] != nil {
+		t.Errorf("unknown block returned: %v", data["block"])
+	}
+	if data["gasPrice"] != "0x12" {
+		t.Errorf("gas price mismatch: have %v, want 0x12", data["gasPrice"])
+	}
+	if data["protocolVersion"] != float64(63) {
+		t.Errorf("protocol version mismatch: have %v, want 63", data["protocolVersion"])
+	}
+}
+
+func TestBlocksRange(t *testing.T) {
+	r := &Resolver{newTestBackend(maxBlockRange + 10)}
+	long := func(n Long) *Long { return &n }
+
+	tests := []struct {
+		from Long
+		to   *Long
+		want int
+		fail bool
+	}{
+		{from: 0, to: long(9), want: 10},
+		{from: maxBlockRange + 5, want: 5},
+		{from: maxBlockRange + 5, to: long(1 << 40), want: 5},
+		{from: maxBlockRange + 20, want: 0},
+		{from: 5, to: long(4), want: 0},
+		{from: 1, to: long(maxBlockRange), want: maxBlockRange},
+		{from: 0, to: long(maxBlockRange), fail: true},
+		{from: 0, fail: true},
+		{from: 1 << 63, fail: true},
+	}
+	for i, tt := range tests {
+		blocks, err := r.Blocks(context.Background(), struct {
+			From Long
+			To   *Long
+		}{tt.from, tt.to})
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error, got %d blocks", i, len(blocks))
+			}
+			continue
+		}
+		if err != nil || len(blocks) != tt.want {
+			t.Errorf("test %d: have %d blocks (%v), want %d", i, len(blocks), err, tt.want)
+		}
+	}
+}
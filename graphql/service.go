@@ -0,0 +1,124 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/irchain/go-irchain/internal/ircapi"
+	"github.com/irchain/go-irchain/p2p"
+	"github.com/irchain/go-irchain/rpc"
+)
+
+// maxRequestSize is the maximum accepted size of a GraphQL request body.
+const maxRequestSize = 1024 * 1024
+
+// Service encapsulates a GraphQL service. It doesn't listen on its own, but is
+// served on /graphql of the node's HTTP RPC endpoint, behind the same token
+// authentication, access policy and resource limits as the RPC API. Queries
+// count as calls to the graphql_query method.
+type Service struct {
+	backend ircapi.Backend // The backend that queries will operate on.
+	handler http.Handler   // The `http.Handler` used to answer queries.
+}
+
+// New constructs a new GraphQL service instance.
+func New(backend ircapi.Backend, ui bool) (*Service, error) {
+	handler, err := newHandler(backend, ui)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{backend: backend, handler: handler}, nil
+}
+
+// Protocols returns the list of protocols exported by this service.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs returns the list of APIs exported by this service.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// HTTPHandlers returns the handler answering queries on /graphql.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{"graphql": s.handler}
+}
+
+// Start is called after all services have been constructed and the networking
+// layer was also initialized to spawn any goroutines required by the service.
+func (s *Service) Start(server *p2p.Server) error { return nil }
+
+// Stop terminates all goroutines belonging to the service, blocking until they
+// are all terminated.
+func (s *Service) Stop() error { return nil }
+
+// newHandler returns a new `http.Handler` that will answer GraphQL queries on
+// /graphql, and optionally serve the query UI on /graphql/ui.
+func newHandler(backend ircapi.Backend, ui bool) (http.Handler, error) {
+	q := Resolver{backend}
+
+	s, err := graphqlgo.ParseSchema(schema, &q)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/graphql", &queryHandler{schema: s})
+	if ui {
+		mux.Handle("/graphql/ui", uiHandler{})
+	}
+	return mux, nil
+}
+
+// queryHandler executes GraphQL queries posted as JSON against a schema.
+type queryHandler struct {
+	schema *graphqlgo.Schema
+}
+
+func (h *queryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		params.Query = r.URL.Query().Get("query")
+		params.OperationName = r.URL.Query().Get("operationName")
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &params.Variables); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&params); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	response := h.schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseJSON)
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/irchain/go-irchain/consensus/irchash"
 	"github.com/irchain/go-irchain/core"
 	"github.com/irchain/go-irchain/core/rawdb"
+	"github.com/irchain/go-irchain/core/state"
 	"github.com/irchain/go-irchain/core/types"
 	"github.com/irchain/go-irchain/core/vm"
 	"github.com/irchain/go-irchain/crypto"
@@ -142,6 +143,42 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	}
 }
 
+// Dropped returns the reason a transaction was rejected by, evicted from or
+// replaced within the transaction pool, or nil if the pool has no such record.
+func (s *PublicTxPoolAPI) Dropped(hash common.Hash) *core.TxDrop {
+	return s.b.TxPoolDropped(hash)
+}
+
+// DroppedTransactions creates a subscription that is triggered each time a
+// transaction is rejected by, evicted from or replaced within the pool.
+func (s *PublicTxPoolAPI) DroppedTransactions(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		drops := make(chan core.DropTxsEvent, 128)
+		dropSub := s.b.SubscribeDropTxsEvent(drops)
+		defer dropSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-drops:
+				for _, drop := range ev.Drops {
+					notifier.Notify(rpcSub.ID, drop)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // Inspect retrieves the content of the transaction pool and flattens it into an
 // easily inspectable list.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
@@ -612,17 +649,13 @@ type CallArgs struct {
 	// Remark   hexutil.Bytes   `json:"remark"`	// TODO support remark
 }
 
-func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
-	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
-
-	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
-	if state == nil || err != nil {
-		return nil, 0, false, err
-	}
+// ToMessage converts the call arguments to a message, filling in the default
+// sender, gas and gas price if they were not specified.
+func (args *CallArgs) ToMessage(b Backend) types.Message {
 	// Set sender address or use a default if none specified
 	addr := args.From
 	if addr == (common.Address{}) {
-		if wallets := s.b.AccountManager().Wallets(); len(wallets) > 0 {
+		if wallets := b.AccountManager().Wallets(); len(wallets) > 0 {
 			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
 				addr = accounts[0].Address
 			}
@@ -636,9 +669,77 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	if gasPrice.Sign() == 0 {
 		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
 	}
+	return types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+}
 
+// OverrideAccount indicates the overriding fields of an account during the
+// execution of a message call. Note, State and StateDiff can't be specified at
+// the same time. If State is set, the call will be executed with the given
+// storage only, otherwise StateDiff patches the existing storage slots.
+type OverrideAccount struct {
+	Nonce     *hexutil.Uint64              `json:"nonce"`
+	Code      *hexutil.Bytes               `json:"code"`
+	Balance   **hexutil.Big                `json:"balance"`
+	State     *map[common.Hash]common.Hash `json:"state"`
+	StateDiff *map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// StateOverride is the collection of overridden accounts.
+type StateOverride map[common.Address]OverrideAccount
+
+// Apply overrides the fields of specified accounts into the given state.
+func (diff *StateOverride) Apply(state *state.StateDB) error {
+	if diff == nil {
+		return nil
+	}
+	for addr, account := range *diff {
+		// Override account nonce.
+		if account.Nonce != nil {
+			state.SetNonce(addr, uint64(*account.Nonce))
+		}
+		// Override account(contract) code.
+		if account.Code != nil {
+			state.SetCode(addr, *account.Code)
+		}
+		// Override account balance.
+		if account.Balance != nil {
+			state.SetBalance(addr, (*big.Int)(*account.Balance))
+		}
+		if account.State != nil && account.StateDiff != nil {
+			return fmt.Errorf("account %s has both 'state' and 'stateDiff'", addr.Hex())
+		}
+		// Replace entire state if caller requires.
+		if account.State != nil {
+			state.SetStorage(addr, *account.State)
+		}
+		// Apply state diff into specified accounts.
+		if account.StateDiff != nil {
+			for key, value := range *account.StateDiff {
+				state.SetState(addr, key, value)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
+	defer func(start time.Time) { log.Debug("Executing EVM call finished", "runtime", time.Since(start)) }(time.Now())
+
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, 0, false, err
+	}
 	// Create new call message
-	msg := types.NewMessage(addr, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+	msg := args.ToMessage(s.b)
+
+	// The recipient pays for the gas of calls, fund it before applying the
+	// overrides so those take precedence
+	if to := msg.To(); to != nil {
+		state.AddBalance(*to, new(big.Int).Mul(msg.GasPrice(), new(big.Int).SetUint64(msg.Gas())))
+	}
+	if err := overrides.Apply(state); err != nil {
+		return nil, 0, false, err
+	}
 
 	// Setup context so it may be cancelled the call has completed
 	// or, in case of unmetered gas, setup a context with a timeout.
@@ -674,16 +775,48 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 	return res, gas, failed, err
 }
 
+// revertError is an API error carrying the revert reason of a call, returned
+// to RPC clients with the raw revert data attached.
+type revertError struct {
+	error
+	reason string // revert data, hex encoded
+}
+
+// newRevertError creates a revertError from the return data of a reverted
+// call, decoding the reason string if the contract provided one.
+func newRevertError(ret []byte) *revertError {
+	return &revertError{error: unpackRevert(ret), reason: hexutil.Encode(ret)}
+}
+
+// ErrorCode returns the JSON error code of a revert.
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+// ErrorData returns the hex encoded revert data.
+func (e *revertError) ErrorData() interface{} {
+	return e.reason
+}
+
 // Call executes the given transaction on the state for the given block number.
 // It doesn't make and changes in the state/blockchain and is useful to execute and retrieve values.
-func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
-	result, _, _, err := s.doCall(ctx, args, blockNr, vm.Config{}, 5*time.Second)
+//
+// Additionally, the caller can specify a batch of contract for fields overriding.
+//
+// If the call reverts, the returned error contains the decoded revert reason
+// and the raw revert data.
+func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride) (hexutil.Bytes, error) {
+	result, _, failed, err := s.doCall(ctx, args, blockNr, overrides, vm.Config{}, 5*time.Second)
+	if failed && err == vm.ErrExecutionReverted {
+		return nil, newRevertError(result)
+	}
 	return (hexutil.Bytes)(result), err
 }
 
 // EstimateGas returns an estimate of the amount of gas needed to execute the
-// given transaction against the current pending block.
-func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
+// given transaction against the current pending block, optionally with the
+// given state overrides applied.
+func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs, overrides *StateOverride) (hexutil.Uint64, error) {
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
 		lo  = params.TxGas - 1
@@ -703,19 +836,19 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	cap = hi
 
 	// Create a helper to check if a gas allowance results in an executable transaction
-	executable := func(gas uint64) bool {
+	executable := func(gas uint64) (bool, []byte, error) {
 		args.Gas = hexutil.Uint64(gas)
 
-		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
+		ret, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, overrides, vm.Config{}, 0)
 		if err != nil || failed {
-			return false
+			return false, ret, err
 		}
-		return true
+		return true, ret, nil
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
 		mid := (hi + lo) / 2
-		if !executable(mid) {
+		if ok, _, _ := executable(mid); !ok {
 			lo = mid
 		} else {
 			hi = mid
@@ -723,7 +856,10 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 	}
 	// Reject the transaction as invalid if it still fails at the highest allowance
 	if hi == cap {
-		if !executable(hi) {
+		if ok, ret, err := executable(hi); !ok {
+			if err == vm.ErrExecutionReverted {
+				return 0, newRevertError(ret)
+			}
 			return 0, fmt.Errorf("gas required exceeds allowance or always failing transaction")
 		}
 	}
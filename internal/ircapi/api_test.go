@@ -0,0 +1,53 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ircapi
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/params"
+	"github.com/irchain/go-irchain/rpc"
+)
+
+// Tests that a balance override of the callee is visible to the call, and not
+// replaced by the funding of its gas.
+func TestCallBalanceOverride(t *testing.T) {
+	api := NewPublicBlockChainAPI(newBundleTestBackend(t))
+
+	var (
+		callee  = common.Address{0xdd}
+		code    = hexutil.Bytes(common.FromHex("303160005260206000f3")) // Returns its own balance
+		balance = (*hexutil.Big)(big.NewInt(params.Ircer))
+	)
+	overrides := &StateOverride{callee: OverrideAccount{Code: &code, Balance: &balance}}
+	ret, err := api.Call(context.Background(), bundleCall(callee), rpc.LatestBlockNumber, overrides)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have := new(big.Int).SetBytes(ret); have.Cmp(balance.ToInt()) != 0 {
+		t.Fatalf("balance mismatch: have %v, want %v", have, balance.ToInt())
+	}
+	// Without a balance override, the callee is still funded to pay for the gas
+	overrides = &StateOverride{callee: OverrideAccount{Code: &code}}
+	if _, err := api.Call(context.Background(), bundleCall(callee), rpc.LatestBlockNumber, overrides); err != nil {
+		t.Fatalf("call of unfunded callee failed: %v", err)
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ircapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/irchain/go-irchain/accounts/abi"
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/common/math"
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/state"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/rpc"
+)
+
+const (
+	// bundleTimeout is the maximum time a call bundle may execute.
+	bundleTimeout = 5 * time.Second
+
+	// maxBundleCalls is the maximum number of calls accepted in a bundle.
+	maxBundleCalls = 256
+)
+
+var (
+	errEmptyBundle    = errors.New("empty call bundle")
+	errBundleTooLarge = errors.New("too many calls in bundle")
+	errBundleGasFunds = errors.New("insufficient balance to pay for gas")
+)
+
+// BlockOverrides are the fields of the block context to override while
+// simulating calls on top of a block.
+type BlockOverrides struct {
+	Number   *hexutil.Big    `json:"number"`
+	Time     *hexutil.Big    `json:"timestamp"`
+	Coinbase *common.Address `json:"coinbase"`
+}
+
+// apply overrides the header fields the EVM context is derived from. The
+// coinbase is resolved by the consensus engine, so it's set on the EVM instead.
+func (o *BlockOverrides) apply(header *types.Header) {
+	if o == nil {
+		return
+	}
+	if o.Number != nil {
+		header.Number = new(big.Int).Set(o.Number.ToInt())
+	}
+	if o.Time != nil {
+		header.Time = new(big.Int).Set(o.Time.ToInt())
+	}
+}
+
+// BundleCallResult is the outcome of a single call within a bundle.
+type BundleCallResult struct {
+	ReturnData hexutil.Bytes  `json:"returnData"`
+	GasUsed    hexutil.Uint64 `json:"gasUsed"`
+	Logs       []*types.Log   `json:"logs"`
+	Error      string         `json:"error,omitempty"`
+	Revert     hexutil.Bytes  `json:"revert,omitempty"` // Raw revert payload of a failed call
+}
+
+// StorageDiff is the change of a single storage slot.
+type StorageDiff struct {
+	From common.Hash `json:"from"`
+	To   common.Hash `json:"to"`
+}
+
+// AccountDiff is the change of an account caused by a call bundle. Only the
+// modified fields are set.
+type AccountDiff struct {
+	Balance *[2]*hexutil.Big            `json:"balance,omitempty"`
+	Nonce   *[2]hexutil.Uint64          `json:"nonce,omitempty"`
+	Code    *[2]hexutil.Bytes           `json:"code,omitempty"`
+	Storage map[common.Hash]StorageDiff `json:"storage,omitempty"`
+}
+
+// CallBundleResult is the outcome of executing a call bundle.
+type CallBundleResult struct {
+	Results   []*BundleCallResult             `json:"results"`
+	GasUsed   hexutil.Uint64                  `json:"gasUsed"`
+	StateDiff map[common.Address]*AccountDiff `json:"stateDiff"`
+}
+
+// CallBundle executes the given calls sequentially on top of the state of the
+// given block, each call seeing the effects of the previous ones. Failing calls
+// don't abort the bundle. The state can be modified beforehand with overrides,
+// and the number, timestamp and coinbase of the block context replaced.
+//
+// The result contains the return data, gas usage, logs and revert data of each
+// call, as well as the changes the bundle made to the touched accounts.
+func (s *PublicBlockChainAPI) CallBundle(ctx context.Context, calls []CallArgs, blockNr rpc.BlockNumber, overrides *StateOverride, blockOverrides *BlockOverrides) (*CallBundleResult, error) {
+	defer func(start time.Time) { log.Debug("Executing call bundle finished", "runtime", time.Since(start)) }(time.Now())
+
+	if len(calls) == 0 {
+		return nil, errEmptyBundle
+	}
+	if len(calls) > maxBundleCalls {
+		return nil, errBundleTooLarge
+	}
+	statedb, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if statedb == nil || err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(statedb); err != nil {
+		return nil, err
+	}
+	blockHash := header.Hash()
+	header = types.CopyHeader(header)
+	blockOverrides.apply(header)
+
+	ctx, cancel := context.WithTimeout(ctx, bundleTimeout)
+	defer cancel()
+
+	var (
+		prestate = statedb.Copy()
+		tracer   = newTouchTracer()
+		gp       = new(core.GasPool).AddGas(math.MaxUint64)
+		result   = &CallBundleResult{StateDiff: make(map[common.Address]*AccountDiff)}
+	)
+	if blockOverrides != nil && blockOverrides.Coinbase != nil {
+		tracer.touch(*blockOverrides.Coinbase)
+	}
+	for i, args := range calls {
+		msg := args.ToMessage(s.b)
+		tracer.touch(msg.From())
+		if to := msg.To(); to != nil {
+			tracer.touch(*to)
+		}
+		statedb.Prepare(common.BigToHash(big.NewInt(int64(i))), blockHash, i)
+
+		res, err := s.applyBundleCall(ctx, msg, statedb, header, blockOverrides, vm.Config{Debug: true, Tracer: tracer}, gp)
+		if err != nil {
+			return nil, err
+		}
+		logs := statedb.GetLogs(common.BigToHash(big.NewInt(int64(i))))
+		for _, log := range logs {
+			log.TxHash = common.Hash{}
+			tracer.touch(log.Address)
+		}
+		res.Logs = logs
+		if res.Logs == nil {
+			res.Logs = []*types.Log{}
+		}
+		result.Results = append(result.Results, res)
+		result.GasUsed += res.GasUsed
+
+		statedb.Finalise(true)
+	}
+	for addr, slots := range tracer.accounts {
+		if diff := diffAccount(prestate, statedb, addr, slots); diff != nil {
+			result.StateDiff[addr] = diff
+		}
+	}
+	return result, nil
+}
+
+// applyBundleCall executes a single message of a bundle on the given state.
+// Only errors invalidating the whole bundle are returned, execution failures
+// are reported in the call result.
+func (s *PublicBlockChainAPI) applyBundleCall(ctx context.Context, msg types.Message, statedb *state.StateDB, header *types.Header, blockOverrides *BlockOverrides, vmCfg vm.Config, gp *core.GasPool) (*BundleCallResult, error) {
+	// The recipient pays for the gas of calls, fund it for the execution and
+	// undo it afterwards.
+	snapshot := statedb.Snapshot()
+	fee := new(big.Int).Mul(msg.GasPrice(), new(big.Int).SetUint64(msg.Gas()))
+	if to := msg.To(); to != nil {
+		statedb.AddBalance(*to, fee)
+	}
+	evm, vmError, err := s.b.GetEVM(ctx, msg, statedb, header, vmCfg)
+	if err != nil {
+		return nil, err
+	}
+	if blockOverrides != nil && blockOverrides.Coinbase != nil {
+		evm.Coinbase = *blockOverrides.Coinbase
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			evm.Cancel()
+		case <-done:
+		}
+	}()
+	ret, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+	if err := vmError(); err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil && !failed {
+		// The message is invalid, e.g. the sender can't pay for the value. Like an
+		// invalid transaction, it has no effects.
+		statedb.RevertToSnapshot(snapshot)
+		return &BundleCallResult{Error: err.Error()}, nil
+	}
+	if to := msg.To(); to != nil {
+		// A recipient unable to pay for the gas would render the transaction
+		// invalid, drop the call's effects altogether.
+		if statedb.GetBalance(*to).Cmp(fee) < 0 {
+			statedb.RevertToSnapshot(snapshot)
+			return &BundleCallResult{Error: errBundleGasFunds.Error()}, nil
+		}
+		statedb.SubBalance(*to, fee)
+	}
+	res := &BundleCallResult{GasUsed: hexutil.Uint64(gas)}
+	if failed {
+		if err == vm.ErrExecutionReverted {
+			err = unpackRevert(ret)
+		}
+		res.Error = err.Error()
+		res.Revert = ret
+	} else {
+		res.ReturnData = ret
+	}
+	return res, nil
+}
+
+// unpackRevert returns the error of a reverted call, including the reason
+// string if the contract provided one.
+func unpackRevert(ret []byte) error {
+	if reason, err := abi.UnpackRevert(ret); err == nil {
+		return fmt.Errorf("execution reverted: %v", reason)
+	}
+	return errors.New("execution reverted")
+}
+
+// diffAccount returns the changes of an account between two states, or nil if
+// it was not modified.
+func diffAccount(pre, post *state.StateDB, addr common.Address, slots map[common.Hash]struct{}) *AccountDiff {
+	var (
+		diff    = new(AccountDiff)
+		changed bool
+	)
+	if from, to := pre.GetBalance(addr), post.GetBalance(addr); from.Cmp(to) != 0 {
+		diff.Balance = &[2]*hexutil.Big{(*hexutil.Big)(from), (*hexutil.Big)(to)}
+		changed = true
+	}
+	if from, to := pre.GetNonce(addr), post.GetNonce(addr); from != to {
+		diff.Nonce = &[2]hexutil.Uint64{hexutil.Uint64(from), hexutil.Uint64(to)}
+		changed = true
+	}
+	if from, to := pre.GetCode(addr), post.GetCode(addr); !bytes.Equal(from, to) {
+		diff.Code = &[2]hexutil.Bytes{from, to}
+		changed = true
+	}
+	for slot := range slots {
+		if from, to := pre.GetState(addr, slot), post.GetState(addr, slot); from != to {
+			if diff.Storage == nil {
+				diff.Storage = make(map[common.Hash]StorageDiff)
+			}
+			diff.Storage[slot] = StorageDiff{From: from, To: to}
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return diff
+}
+
+// touchTracer is a vm.Tracer collecting the accounts and storage slots a
+// bundle may have modified.
+type touchTracer struct {
+	accounts map[common.Address]map[common.Hash]struct{}
+}
+
+func newTouchTracer() *touchTracer {
+	return &touchTracer{accounts: make(map[common.Address]map[common.Hash]struct{})}
+}
+
+// touch marks an account as potentially modified.
+func (t *touchTracer) touch(addr common.Address) map[common.Hash]struct{} {
+	slots, ok := t.accounts[addr]
+	if !ok {
+		slots = make(map[common.Hash]struct{})
+		t.accounts[addr] = slots
+	}
+	return slots
+}
+
+func (t *touchTracer) CaptureStart(from common.Address, to common.Address, call bool, input []byte, gas uint64, value *big.Int) error {
+	t.touch(from)
+	t.touch(to)
+	return nil
+}
+
+func (t *touchTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err != nil {
+		return nil
+	}
+	switch op {
+	case vm.SSTORE:
+		t.touch(contract.Address())[common.BigToHash(stack.Back(0))] = struct{}{}
+	case vm.CALL, vm.CALLCODE:
+		t.touch(common.BigToAddress(stack.Back(1)))
+	case vm.SELFDESTRUCT:
+		t.touch(contract.Address())
+		t.touch(common.BigToAddress(stack.Back(0)))
+	case vm.CREATE:
+		t.touch(crypto.CreateAddress(contract.Address(), env.StateDB.GetNonce(contract.Address())))
+	}
+	return nil
+}
+
+func (t *touchTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+func (t *touchTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
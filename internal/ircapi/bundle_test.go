@@ -0,0 +1,211 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ircapi
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/state"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/params"
+	"github.com/irchain/go-irchain/rpc"
+)
+
+var (
+	bundleSender   = common.Address{0xaa}
+	bundleCounter  = common.Address{0xcc} // Increments and returns slot 0
+	bundleContext  = common.Address{0xcd} // Returns the number, timestamp and coinbase
+	bundleReverter = common.Address{0xce} // Reverts with the reason "boom"
+	bundleUnfunded = common.Address{0xcf} // Counter unable to pay for gas
+)
+
+var (
+	counterCode  = common.FromHex("6000546001018060005560005260206000f3")
+	contextCode  = common.FromHex("43600052426020524160405260606000f3")
+	reverterCode = common.FromHex("6064600c60003960646000fd" +
+		"08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"626f6f6d00000000000000000000000000000000000000000000000000000000")
+)
+
+// bundleTestBackend is a Backend executing calls on top of a fixed state.
+type bundleTestBackend struct {
+	Backend
+
+	statedb *state.StateDB
+	header  *types.Header
+}
+
+func newBundleTestBackend(t *testing.T) *bundleTestBackend {
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(ircdb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	statedb.SetBalance(bundleSender, big.NewInt(params.Ircer))
+	for addr, code := range map[common.Address][]byte{
+		bundleCounter:  counterCode,
+		bundleContext:  contextCode,
+		bundleReverter: reverterCode,
+	} {
+		statedb.SetCode(addr, code)
+		statedb.SetBalance(addr, big.NewInt(params.Ircer))
+	}
+	statedb.SetCode(bundleUnfunded, counterCode)
+
+	return &bundleTestBackend{
+		statedb: statedb,
+		header: &types.Header{
+			Number:     big.NewInt(1),
+			Time:       big.NewInt(1000),
+			Difficulty: big.NewInt(1),
+			GasLimit:   params.GenesisGasLimit,
+		},
+	}
+}
+
+func (b *bundleTestBackend) StateAndHeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	return b.statedb.Copy(), b.header, nil
+}
+
+func (b *bundleTestBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+	context := core.NewEVMContext(msg, header, nil, &header.Coinbase)
+	return vm.NewEVM(context, state, params.TestChainConfig, vmCfg), func() error { return nil }, nil
+}
+
+// bundleCall creates the arguments of a call from the test sender.
+func bundleCall(to common.Address) CallArgs {
+	return CallArgs{
+		From:     bundleSender,
+		To:       &to,
+		Gas:      hexutil.Uint64(100000),
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Data:     hexutil.Bytes{0x01},
+	}
+}
+
+// Tests that the calls of a bundle see the effects of the previous ones, and
+// that the accumulated changes are reported in the state diff.
+func TestCallBundleSequential(t *testing.T) {
+	api := NewPublicBlockChainAPI(newBundleTestBackend(t))
+
+	calls := []CallArgs{bundleCall(bundleCounter), bundleCall(bundleCounter)}
+	result, err := api.CallBundle(context.Background(), calls, rpc.LatestBlockNumber, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to execute bundle: %v", err)
+	}
+	for i, res := range result.Results {
+		if res.Error != "" {
+			t.Fatalf("call %d failed: %v", i, res.Error)
+		}
+		if want := common.BigToHash(big.NewInt(int64(i + 1))); !bytes.Equal(res.ReturnData, want[:]) {
+			t.Errorf("call %d: return data mismatch: have %x, want %x", i, res.ReturnData, want)
+		}
+	}
+	if result.GasUsed != result.Results[0].GasUsed+result.Results[1].GasUsed {
+		t.Errorf("bundle gas mismatch: have %d, want %d", result.GasUsed, result.Results[0].GasUsed+result.Results[1].GasUsed)
+	}
+	diff := result.StateDiff[bundleCounter]
+	if diff == nil {
+		t.Fatalf("counter missing from state diff")
+	}
+	if slot := diff.Storage[common.Hash{}]; slot.From != (common.Hash{}) || slot.To != common.BigToHash(big.NewInt(2)) {
+		t.Errorf("storage diff mismatch: have %x -> %x, want 0 -> 2", slot.From, slot.To)
+	}
+	gasCost := new(big.Int).SetUint64(uint64(result.GasUsed))
+	if diff.Balance == nil || new(big.Int).Sub(diff.Balance[0].ToInt(), diff.Balance[1].ToInt()).Cmp(gasCost) != 0 {
+		t.Errorf("recipient balance diff mismatch: have %v, want gas cost %v", diff.Balance, gasCost)
+	}
+	if diff := result.StateDiff[bundleSender]; diff == nil || diff.Nonce == nil || diff.Nonce[1] != 2 {
+		t.Errorf("sender nonce diff mismatch: have %+v", diff)
+	}
+	if _, ok := result.StateDiff[bundleContext]; ok {
+		t.Errorf("untouched account in state diff")
+	}
+}
+
+// Tests that the block context overrides are visible to the executed calls.
+func TestCallBundleBlockOverrides(t *testing.T) {
+	api := NewPublicBlockChainAPI(newBundleTestBackend(t))
+
+	coinbase := common.Address{0xbb}
+	overrides := &BlockOverrides{
+		Number:   (*hexutil.Big)(big.NewInt(100)),
+		Time:     (*hexutil.Big)(big.NewInt(2000)),
+		Coinbase: &coinbase,
+	}
+	result, err := api.CallBundle(context.Background(), []CallArgs{bundleCall(bundleContext)}, rpc.LatestBlockNumber, nil, overrides)
+	if err != nil {
+		t.Fatalf("failed to execute bundle: %v", err)
+	}
+	var want []byte
+	want = append(want, common.BigToHash(big.NewInt(100)).Bytes()...)
+	want = append(want, common.BigToHash(big.NewInt(2000)).Bytes()...)
+	want = append(want, coinbase.Hash().Bytes()...)
+	if have := result.Results[0].ReturnData; !bytes.Equal(have, want) {
+		t.Errorf("block context mismatch: have %x, want %x", have, want)
+	}
+	// The overridden coinbase collects the gas fees
+	if diff := result.StateDiff[coinbase]; diff == nil || diff.Balance == nil {
+		t.Errorf("coinbase missing from state diff")
+	}
+}
+
+// Tests that failing calls are reported without aborting the bundle, neither
+// leaving any state changes behind.
+func TestCallBundleFailures(t *testing.T) {
+	api := NewPublicBlockChainAPI(newBundleTestBackend(t))
+
+	overpaid := bundleCall(bundleCounter)
+	overpaid.Value = hexutil.Big(*big.NewInt(2 * params.Ircer))
+
+	calls := []CallArgs{bundleCall(bundleReverter), bundleCall(bundleUnfunded), overpaid, bundleCall(bundleCounter)}
+	result, err := api.CallBundle(context.Background(), calls, rpc.LatestBlockNumber, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to execute bundle: %v", err)
+	}
+	if res := result.Results[0]; res.Error != "execution reverted: boom" || len(res.Revert) != 100 {
+		t.Errorf("revert mismatch: have error %q with %d bytes of data", res.Error, len(res.Revert))
+	}
+	if res := result.Results[1]; res.Error != errBundleGasFunds.Error() || res.GasUsed != 0 {
+		t.Errorf("unfunded call mismatch: have error %q, gas %d", res.Error, res.GasUsed)
+	}
+	if diff, ok := result.StateDiff[bundleUnfunded]; ok {
+		t.Errorf("unfunded recipient modified: %+v", diff)
+	}
+	if res := result.Results[2]; res.Error != vm.ErrInsufficientBalance.Error() || res.GasUsed != 0 {
+		t.Errorf("call exceeding the sender balance mismatch: have error %q, gas %d", res.Error, res.GasUsed)
+	}
+	if res := result.Results[3]; res.Error != "" {
+		t.Errorf("call after failures failed: %v", res.Error)
+	}
+	if want := common.BigToHash(big.NewInt(1)); !bytes.Equal(result.Results[3].ReturnData, want[:]) {
+		t.Errorf("invalid call modified the state: counter %x, want %x", result.Results[3].ReturnData, want)
+	}
+	// Only the reverted and the successful call increment the sender's nonce
+	if diff := result.StateDiff[bundleSender]; diff == nil || diff.Nonce == nil || diff.Nonce[1] != 2 {
+		t.Errorf("sender nonce diff mismatch: have %+v", diff)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ircapi
+
+import (
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/rpc"
+)
+
+// The chain types below have generated JSON encodings that differ from their Go
+// fields. These mirror the encodings, so the OpenRPC description of the API
+// documents the values actually sent over the wire.
+
+type headerEncoding struct {
+	ParentHash  common.Hash      `json:"parentHash"`
+	UncleHash   common.Hash      `json:"sha3Uncles"`
+	Coinbase    common.Address   `json:"miner"`
+	Root        common.Hash      `json:"stateRoot"`
+	TxHash      common.Hash      `json:"transactionsRoot"`
+	ReceiptHash common.Hash      `json:"receiptsRoot"`
+	Bloom       types.Bloom      `json:"logsBloom"`
+	Difficulty  hexutil.Big      `json:"difficulty"`
+	Number      hexutil.Big      `json:"number"`
+	GasLimit    hexutil.Uint64   `json:"gasLimit"`
+	GasUsed     hexutil.Uint64   `json:"gasUsed"`
+	Time        hexutil.Big      `json:"timestamp"`
+	Extra       hexutil.Bytes    `json:"extraData"`
+	MixDigest   common.Hash      `json:"mixHash"`
+	Nonce       types.BlockNonce `json:"nonce"`
+	Hash        common.Hash      `json:"hash"`
+}
+
+type logEncoding struct {
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint   `json:"transactionIndex"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Index       hexutil.Uint   `json:"logIndex"`
+	Removed     bool           `json:"removed"`
+}
+
+type receiptEncoding struct {
+	PostState         hexutil.Bytes  `json:"root"`
+	Status            hexutil.Uint64 `json:"status"`
+	CumulativeGasUsed hexutil.Uint64 `json:"cumulativeGasUsed"`
+	Bloom             types.Bloom    `json:"logsBloom"`
+	Logs              []*types.Log   `json:"logs"`
+	TxHash            common.Hash    `json:"transactionHash"`
+	ContractAddress   common.Address `json:"contractAddress"`
+	GasUsed           hexutil.Uint64 `json:"gasUsed"`
+}
+
+type transactionEncoding struct {
+	AccountNonce hexutil.Uint64  `json:"nonce"`
+	Price        hexutil.Big     `json:"gasPrice"`
+	GasLimit     hexutil.Uint64  `json:"gas"`
+	Recipient    *common.Address `json:"to"`
+	Amount       hexutil.Big     `json:"value"`
+	Payload      hexutil.Bytes   `json:"input"`
+	V            hexutil.Big     `json:"v"`
+	R            hexutil.Big     `json:"r"`
+	S            hexutil.Big     `json:"s"`
+	Hash         *common.Hash    `json:"hash"`
+}
+
+func init() {
+	rpc.RegisterSchemaOverride(types.Header{}, headerEncoding{})
+	rpc.RegisterSchemaOverride(types.Log{}, logEncoding{})
+	rpc.RegisterSchemaOverride(types.Receipt{}, receiptEncoding{})
+	rpc.RegisterSchemaOverride(types.Transaction{}, transactionEncoding{})
+}
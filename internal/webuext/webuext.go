@@ -23,6 +23,7 @@ var Modules = map[string]string{
 	"clique":     Clique_JS,
 	"debug":      Debug_JS,
 	"irc":        Irc_JS,
+	"les":        Les_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
@@ -123,6 +124,16 @@ webu._extend({
 			call: 'admin_removePeer',
 			params: 1
 		}),
+		new webu._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2
+		}),
+		new webu._extend.Method({
+			name: 'unban',
+			call: 'admin_unban',
+			params: 1
+		}),
 		new webu._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -161,6 +172,10 @@ webu._extend({
 		}),
 	],
 	properties: [
+		new webu._extend.Property({
+			name: 'bans',
+			getter: 'admin_listBans'
+		}),
 		new webu._extend.Property({
 			name: 'nodeInfo',
 			getter: 'admin_nodeInfo'
@@ -357,6 +372,12 @@ webu._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new webu._extend.Method({
+			name: 'traceCall',
+			call: 'debug_traceCall',
+			params: 3,
+			inputFormatter: [null, webu._extend.formatters.inputDefaultBlockNumberFormatter, null]
+		}),
 		new webu._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -431,6 +452,12 @@ webu._extend({
 			params: 2,
 			inputFormatter: [webu._extend.formatters.inputBlockNumberFormatter, webu._extend.utils.toHex]
 		}),
+		new webu._extend.Method({
+			name: 'callBundle',
+			call: 'irc_callBundle',
+			params: 4,
+			inputFormatter: [null, webu._extend.formatters.inputDefaultBlockNumberFormatter, null, null]
+		}),
 	],
 	properties: [
 		new webu._extend.Property({
@@ -605,10 +632,47 @@ webu._extend({
 });
 `
 
+const Les_JS = `
+webu._extend({
+	property: 'les',
+	methods: [
+		new webu._extend.Method({
+			name: 'setClientCapacity',
+			call: 'les_setClientCapacity',
+			params: 2
+		}),
+		new webu._extend.Method({
+			name: 'clientInfo',
+			call: 'les_clientInfo',
+			params: 1,
+			inputFormatter: [null]
+		}),
+		new webu._extend.Method({
+			name: 'clientStats',
+			call: 'les_clientStats',
+			params: 1,
+			inputFormatter: [null]
+		}),
+	],
+	properties: [
+		new webu._extend.Property({
+			name: 'servingStats',
+			getter: 'les_servingStats'
+		}),
+	]
+});
+`
+
 const TxPool_JS = `
 webu._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new webu._extend.Method({
+			name: 'dropped',
+			call: 'txpool_dropped',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new webu._extend.Property({
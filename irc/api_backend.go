@@ -22,7 +22,6 @@ import (
 
 	"github.com/irchain/go-irchain/accounts"
 	"github.com/irchain/go-irchain/common"
-	"github.com/irchain/go-irchain/common/math"
 	"github.com/irchain/go-irchain/core"
 	"github.com/irchain/go-irchain/core/bloombits"
 	"github.com/irchain/go-irchain/core/rawdb"
@@ -129,7 +128,6 @@ func (b *IrcApiBackend) GetTd(blockHash common.Hash) *big.Int {
 }
 
 func (b *IrcApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
-	state.SetBalance(*msg.To(), math.MaxBig256)
 	vmError := func() error { return nil }
 	context := core.NewEVMContext(msg, header, b.irc.BlockChain(), nil)
 	return vm.NewEVM(context, state, b.irc.chainConfig, vmCfg), vmError, nil
@@ -191,6 +189,14 @@ func (b *IrcApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.irc.TxPool().SubscribeNewTxsEvent(ch)
 }
 
+func (b *IrcApiBackend) TxPoolDropped(hash common.Hash) *core.TxDrop {
+	return b.irc.TxPool().Dropped(hash)
+}
+
+func (b *IrcApiBackend) SubscribeDropTxsEvent(ch chan<- core.DropTxsEvent) event.Subscription {
+	return b.irc.TxPool().SubscribeDropTxsEvent(ch)
+}
+
 func (b *IrcApiBackend) Downloader() *downloader.Downloader {
 	return b.irc.Downloader()
 }
@@ -0,0 +1,174 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package irc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/consensus/irchash"
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/internal/ircapi"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/params"
+	"github.com/irchain/go-irchain/rpc"
+)
+
+// newTestTraceAPI creates a debug API on top of a chain holding a contract at
+// 0xcc, which reverts with the reason "boom" when called, and an unfunded one
+// at 0xdd returning 1.
+func newTestTraceAPI(t *testing.T) *PrivateDebugAPI {
+	var (
+		db    = ircdb.NewMemDatabase()
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: core.GenesisAlloc{
+				common.Address{0xaa}: {Balance: big.NewInt(params.Ircer)},
+				common.Address{0xcc}: {
+					Balance: big.NewInt(params.Ircer),
+					Code: common.FromHex("6064600c60003960646000fd" +
+						"08c379a0" +
+						"0000000000000000000000000000000000000000000000000000000000000020" +
+						"0000000000000000000000000000000000000000000000000000000000000004" +
+						"626f6f6d00000000000000000000000000000000000000000000000000000000"),
+				},
+				common.Address{0xdd}: {Balance: new(big.Int), Code: common.FromHex("600160005260206000f3")},
+			},
+		}
+	)
+	gspec.MustCommit(db)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, irchash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	irc := &IrChain{blockchain: blockchain, chainDb: db}
+	irc.ApiBackend = &IrcApiBackend{irc, nil}
+	return NewPrivateDebugAPI(gspec.Config, irc)
+}
+
+func TestTraceCall(t *testing.T) {
+	api := newTestTraceAPI(t)
+	args := ircapi.CallArgs{
+		From:     common.Address{0xaa},
+		To:       &common.Address{0xcc},
+		Gas:      hexutil.Uint64(100000),
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Data:     hexutil.Bytes{0x01},
+	}
+	// Trace the reverting call with the struct logger
+	res, err := api.TraceCall(context.Background(), args, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	result := res.(*ircapi.ExecutionResult)
+	if !result.Failed {
+		t.Errorf("reverting call not reported as failed")
+	}
+	if len(result.StructLogs) != 7 {
+		t.Errorf("struct log length mismatch: have %d, want 7", len(result.StructLogs))
+	}
+	if op := result.StructLogs[len(result.StructLogs)-1].Op; op != "REVERT" {
+		t.Errorf("last opcode mismatch: have %s, want REVERT", op)
+	}
+	// Replace the contract code and trace with the call tracer
+	tracer := "callTracer"
+	code := hexutil.Bytes(common.FromHex("600160005260206000f3"))
+	config := &TraceCallConfig{
+		TraceConfig:    TraceConfig{Tracer: &tracer},
+		StateOverrides: &ircapi.StateOverride{common.Address{0xcc}: {Code: &code}},
+	}
+	res, err = api.TraceCall(context.Background(), args, rpc.LatestBlockNumber, config)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	var call struct {
+		Type   string `json:"type"`
+		Output string `json:"output"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(res.(json.RawMessage), &call); err != nil {
+		t.Fatalf("failed to decode call trace: %v", err)
+	}
+	if call.Type != "CALL" || call.Error != "" {
+		t.Errorf("call trace mismatch: %+v", call)
+	}
+	if want := "0x0000000000000000000000000000000000000000000000000000000000000001"; call.Output != want {
+		t.Errorf("output mismatch: have %s, want %s", call.Output, want)
+	}
+	// Tracing on top of unknown blocks should fail
+	if _, err := api.TraceCall(context.Background(), args, rpc.BlockNumber(10), nil); err == nil {
+		t.Errorf("tracing on unknown block succeeded")
+	}
+}
+
+func TestTraceCallUnfundedCallee(t *testing.T) {
+	api := newTestTraceAPI(t)
+	args := ircapi.CallArgs{
+		From:     common.Address{0xaa},
+		To:       &common.Address{0xdd},
+		Gas:      hexutil.Uint64(100000),
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Data:     hexutil.Bytes{0x01},
+	}
+	res, err := api.TraceCall(context.Background(), args, rpc.LatestBlockNumber, nil)
+	if err != nil {
+		t.Fatalf("failed to trace call to unfunded callee: %v", err)
+	}
+	result := res.(*ircapi.ExecutionResult)
+	if result.Failed {
+		t.Errorf("call to unfunded callee reported as failed")
+	}
+	if want := "0000000000000000000000000000000000000000000000000000000000000001"; result.ReturnValue != want {
+		t.Errorf("return value mismatch: have %s, want %s", result.ReturnValue, want)
+	}
+}
+
+// Tests that a balance override of the callee is visible to the traced call,
+// and not replaced by the funding of its gas.
+func TestTraceCallBalanceOverride(t *testing.T) {
+	api := newTestTraceAPI(t)
+	var (
+		code    = hexutil.Bytes(common.FromHex("303160005260206000f3")) // Returns its own balance
+		balance = (*hexutil.Big)(big.NewInt(params.Ircer))
+	)
+	args := ircapi.CallArgs{
+		From:     common.Address{0xaa},
+		To:       &common.Address{0xdd},
+		Gas:      hexutil.Uint64(100000),
+		GasPrice: hexutil.Big(*big.NewInt(1)),
+		Data:     hexutil.Bytes{0x01},
+	}
+	config := &TraceCallConfig{
+		StateOverrides: &ircapi.StateOverride{common.Address{0xdd}: ircapi.OverrideAccount{Code: &code, Balance: &balance}},
+	}
+	res, err := api.TraceCall(context.Background(), args, rpc.LatestBlockNumber, config)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	result := res.(*ircapi.ExecutionResult)
+	if result.Failed {
+		t.Fatalf("call failed")
+	}
+	if want := common.BigToHash(balance.ToInt()).Hex()[2:]; result.ReturnValue != want {
+		t.Errorf("balance mismatch: have %s, want %s", result.ReturnValue, want)
+	}
+}
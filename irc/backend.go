@@ -26,6 +26,7 @@ import (
 	"sync/atomic"
 
 	"github.com/irchain/go-irchain/accounts"
+	"github.com/irchain/go-irchain/accounts/abi/bind"
 	"github.com/irchain/go-irchain/common"
 	"github.com/irchain/go-irchain/common/hexutil"
 	"github.com/irchain/go-irchain/consensus"
@@ -55,7 +56,9 @@ type LesServer interface {
 	Start(srvr *p2p.Server)
 	Stop()
 	Protocols() []p2p.Protocol
+	APIs() []rpc.API
 	SetBloomBitsIndexer(bbIndexer *core.ChainIndexer)
+	SetContractBackend(backend bind.ContractBackend)
 }
 
 // IrChain implements the IrChain full node service.
@@ -99,6 +102,14 @@ func (irc *IrChain) AddLesServer(ls LesServer) {
 	ls.SetBloomBitsIndexer(irc.bloomIndexer)
 }
 
+// SetContractBackend sets the backend the light server uses to interact with
+// on-chain contracts, like voting on checkpoints in the checkpoint oracle.
+func (irc *IrChain) SetContractBackend(backend bind.ContractBackend) {
+	if irc.lesServer != nil {
+		irc.lesServer.SetContractBackend(backend)
+	}
+}
+
 // New creates a new IrChain object (including the
 // initialisation of the common IrChain object)
 func New(ctx *node.ServiceContext, config *Config) (*IrChain, error) {
@@ -166,7 +177,9 @@ func New(ctx *node.ServiceContext, config *Config) (*IrChain, error) {
 	if irc.protocolManager, err = NewProtocolManager(irc.chainConfig, config.SyncMode, config.NetworkId, irc.eventMux, irc.txPool, irc.engine, irc.blockchain, chainDb); err != nil {
 		return nil, err
 	}
-	irc.miner = miner.New(irc, irc.chainConfig, irc.EventMux(), irc.engine)
+	if irc.miner, err = miner.New(irc, &config.Miner, irc.chainConfig, irc.EventMux(), irc.engine); err != nil {
+		return nil, err
+	}
 	irc.miner.SetExtra(makeExtraData(config.ExtraData))
 
 	irc.ApiBackend = &IrcApiBackend{irc, nil}
@@ -247,6 +260,11 @@ func (irc *IrChain) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, irc.engine.APIs(irc.BlockChain())...)
 
+	// Append the light server APIs if serving light clients
+	if irc.lesServer != nil {
+		apis = append(apis, irc.lesServer.APIs()...)
+	}
+
 	// Append all the local APIs and return
 	return append(apis, []rpc.API{
 		{
@@ -400,6 +418,7 @@ func (irc *IrChain) Start(srvr *p2p.Server) error {
 	}
 	// Start the networking layer and the light server if requested
 	irc.protocolManager.Start(maxPeers)
+	irc.protocolManager.startIrcEntryUpdate(srvr)
 	if irc.lesServer != nil {
 		irc.lesServer.Start(srvr)
 	}
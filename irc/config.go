@@ -30,6 +30,7 @@ import (
 	"github.com/irchain/go-irchain/core"
 	"github.com/irchain/go-irchain/irc/downloader"
 	"github.com/irchain/go-irchain/irc/gasprice"
+	"github.com/irchain/go-irchain/miner"
 	"github.com/irchain/go-irchain/params"
 )
 
@@ -50,6 +51,7 @@ var DefaultConfig = Config{
 	TrieTimeout:   60 * time.Minute,
 	GasPrice:      big.NewInt(18 * params.Shannon),
 
+	Miner:  miner.DefaultConfig,
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
@@ -57,6 +59,16 @@ var DefaultConfig = Config{
 	},
 }
 
+// DefaultULCMinTrustedFraction is the default percentage of trusted servers
+// that need to announce the same head before an ultra-light client accepts it.
+const DefaultULCMinTrustedFraction = 75
+
+// ULCConfig contains the ultra-light client options.
+type ULCConfig struct {
+	TrustedServers     []string `toml:",omitempty"` // Enode URLs of the servers whose head announcements are trusted
+	MinTrustedFraction int      `toml:",omitempty"` // Percentage of trusted servers required to agree on a head (1-100)
+}
+
 func init() {
 	home := os.Getenv("HOME")
 	if home == "" {
@@ -87,6 +99,13 @@ type Config struct {
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
 
+	// Checkpoint oracle options
+	CheckpointOracle *params.CheckpointOracleConfig `toml:",omitempty"` // Oracle used by light clients to sync from new checkpoints
+	CheckpointSigner common.Address                 `toml:",omitempty"` // Account a light server votes on checkpoints with
+
+	// Ultra-light client options, nil if the header chain is synced normally
+	ULC *ULCConfig `toml:",omitempty"`
+
 	// Database options
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
@@ -99,6 +118,7 @@ type Config struct {
 	MinerThreads int            `toml:",omitempty"`
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
+	Miner        miner.Config
 
 	// Irchash options
 	Irchash irchash.Config
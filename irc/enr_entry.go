@@ -0,0 +1,82 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package irc
+
+import (
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/forkid"
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/enr"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+// localRecord is the part of the p2p server maintaining the local node record.
+type localRecord interface {
+	SetLocalEntries(entries ...enr.Entry) error
+}
+
+// ircEntry is the "irc" ENR entry which advertises irc protocol
+// on the discovery network.
+type ircEntry struct {
+	ForkID forkid.ID // Fork identifier per EIP-2124
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e ircEntry) ENRKey() string {
+	return "irc"
+}
+
+// currentIrcEntry constructs an `irc` ENR entry based on the current state of
+// the chain.
+func (pm *ProtocolManager) currentIrcEntry() *ircEntry {
+	return &ircEntry{ForkID: forkid.NewIDFromChain(pm.blockchain)}
+}
+
+// startIrcEntryUpdate keeps the `irc` entry of the local node record in sync
+// with the chain, updating it whenever the head crosses a fork block. The loop
+// terminates when the protocol manager is stopped.
+func (pm *ProtocolManager) startIrcEntryUpdate(record localRecord) {
+	heads := make(chan core.ChainHeadEvent, 10)
+	pm.ircEntrySub = pm.blockchain.SubscribeChainHeadEvent(heads)
+
+	go func() {
+		var current *ircEntry
+		update := func() {
+			next := pm.currentIrcEntry()
+			if current != nil && next.ForkID == current.ForkID {
+				return
+			}
+			if err := record.SetLocalEntries(next); err != nil {
+				log.Warn("Failed to update irc node record entry", "err", err)
+				return
+			}
+			current = next
+		}
+		update()
+		for {
+			select {
+			case <-heads:
+				update()
+			case <-pm.ircEntrySub.Err():
+				return
+			}
+		}
+	}()
+}
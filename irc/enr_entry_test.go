@@ -0,0 +1,90 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package irc
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/irchain/go-irchain/consensus/irchash"
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/forkid"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/event"
+	"github.com/irchain/go-irchain/irc/downloader"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/p2p/enr"
+	"github.com/irchain/go-irchain/params"
+)
+
+// testLocalRecord collects the entries set on the local node record.
+type testLocalRecord chan enr.Entry
+
+func (r testLocalRecord) SetLocalEntries(entries ...enr.Entry) error {
+	for _, e := range entries {
+		r <- e
+	}
+	return nil
+}
+
+// Tests that the `irc` entry of the local node record is updated when the chain
+// head crosses a fork block.
+func TestIrcEntryUpdate(t *testing.T) {
+	var (
+		config = &params.ChainConfig{ChainID: big.NewInt(1), ByzantiumBlock: big.NewInt(0), ConstantinopleBlock: big.NewInt(2)}
+		db     = ircdb.NewMemDatabase()
+		gspec  = &core.Genesis{Config: config}
+	)
+	genesis := gspec.MustCommit(db)
+	blockchain, _ := core.NewBlockChain(db, nil, config, irchash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+
+	pm, err := NewProtocolManager(config, downloader.FullSync, DefaultConfig.NetworkId, new(event.TypeMux), &testTxPool{}, irchash.NewFaker(), blockchain, db)
+	if err != nil {
+		t.Fatalf("failed to create protocol manager: %v", err)
+	}
+	record := make(testLocalRecord, 10)
+	pm.startIrcEntryUpdate(record)
+	defer pm.ircEntrySub.Unsubscribe()
+
+	next := func() forkid.ID {
+		select {
+		case e := <-record:
+			return e.(*ircEntry).ForkID
+		case <-time.After(time.Second):
+			t.Fatalf("node record entry not updated")
+		}
+		return forkid.ID{}
+	}
+	if id := next(); id != forkid.NewID(config, genesis.Hash(), 0) {
+		t.Errorf("initial fork ID mismatch: have %x", id)
+	}
+	// Crossing the fork block should update the entry, but only once
+	blocks, _ := core.GenerateChain(config, genesis, irchash.NewFaker(), db, 3, nil)
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	if id := next(); id != forkid.NewID(config, genesis.Hash(), 2) {
+		t.Errorf("post-fork ID mismatch: have %x", id)
+	}
+	select {
+	case e := <-record:
+		t.Errorf("unexpected entry update: %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -40,4 +40,9 @@ var (
 	headerFilterOutMeter = metrics.NewRegisteredMeter("irc/fetcher/filter/headers/out", nil)
 	bodyFilterInMeter    = metrics.NewRegisteredMeter("irc/fetcher/filter/bodies/in", nil)
 	bodyFilterOutMeter   = metrics.NewRegisteredMeter("irc/fetcher/filter/bodies/out", nil)
+
+	txAnnounceInMeter   = metrics.NewRegisteredMeter("irc/fetcher/prop/txannounces/in", nil)
+	txAnnounceDOSMeter  = metrics.NewRegisteredMeter("irc/fetcher/prop/txannounces/dos", nil)
+	txFetchOutMeter     = metrics.NewRegisteredMeter("irc/fetcher/fetch/txs", nil)
+	txFetchTimeoutMeter = metrics.NewRegisteredMeter("irc/fetcher/fetch/txs/timeout", nil)
 )
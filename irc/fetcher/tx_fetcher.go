@@ -0,0 +1,352 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"time"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/log"
+)
+
+const (
+	txArriveTimeout = 500 * time.Millisecond // Time allowance before an announced transaction is explicitly requested
+	txGatherSlack   = 100 * time.Millisecond // Interval used to collate almost-expired announces with fetches
+	txFetchTimeout  = 5 * time.Second        // Maximum allotted time to return an explicitly requested transaction
+	txHashLimit     = 4096                   // Maximum number of unique transactions a peer may have announced
+	txFetchLimit    = 256                    // Maximum number of transactions to request from a peer in one go
+)
+
+// txKnownFn is a callback type for checking whether a transaction is already
+// known locally.
+type txKnownFn func(common.Hash) bool
+
+// txAdderFn is a callback type for injecting a batch of transactions into the
+// local pool.
+type txAdderFn func([]*types.Transaction) []error
+
+// txRequesterFn is a callback type for sending a transaction retrieval request.
+type txRequesterFn func([]common.Hash) error
+
+// txAnnounce is the hash notification of the availability of a transaction at
+// a remote peer.
+type txAnnounce struct {
+	origin string    // Identifier of the peer originating the notification
+	time   time.Time // Timestamp of the announcement (or the request if fetching)
+
+	fetchTxs txRequesterFn // Fetcher function to retrieve the announced transactions
+}
+
+// txNotify is a batch of transaction announcements from a single peer.
+type txNotify struct {
+	origin   string
+	hashes   []common.Hash
+	time     time.Time
+	fetchTxs txRequesterFn
+}
+
+// txDelivery is a batch of transactions received from a single peer, either
+// broadcast directly or as a reply to a retrieval request.
+type txDelivery struct {
+	origin string
+	hashes []common.Hash
+	direct bool
+}
+
+// TxFetcher is responsible for retrieving transactions announced by hash from
+// the irc/64 protocol onwards. It makes sure that every transaction is only
+// requested from a single peer at a time, and drops peers failing to deliver
+// requested transactions in time, retrying the retrieval from an alternate one.
+type TxFetcher struct {
+	// Various event channels
+	notify  chan *txNotify
+	cleanup chan *txDelivery
+	drop    chan string
+	quit    chan struct{}
+
+	// Announce states
+	announces map[string]int                  // Per peer announce counts to prevent memory exhaustion
+	announced map[common.Hash][]*txAnnounce   // Announced transactions, scheduled for fetching
+	fetching  map[common.Hash]*txAnnounce     // Announced transactions, currently fetching
+	requests  map[string]map[common.Hash]bool // In-flight retrieval requests per peer
+
+	// Callbacks
+	hasTx    txKnownFn  // Checks whether a transaction is already in the local pool
+	addTxs   txAdderFn  // Injects a batch of transactions into the local pool
+	dropPeer peerDropFn // Drops a peer for misbehaving
+
+	// Testing hooks
+	fetchingHook func(string, []common.Hash) // Method to call upon starting a transaction retrieval
+}
+
+// NewTxFetcher creates a transaction fetcher to retrieve transactions based on
+// hash announcements.
+func NewTxFetcher(hasTx txKnownFn, addTxs txAdderFn, dropPeer peerDropFn) *TxFetcher {
+	return &TxFetcher{
+		notify:    make(chan *txNotify),
+		cleanup:   make(chan *txDelivery),
+		drop:      make(chan string),
+		quit:      make(chan struct{}),
+		announces: make(map[string]int),
+		announced: make(map[common.Hash][]*txAnnounce),
+		fetching:  make(map[common.Hash]*txAnnounce),
+		requests:  make(map[string]map[common.Hash]bool),
+		hasTx:     hasTx,
+		addTxs:    addTxs,
+		dropPeer:  dropPeer,
+	}
+}
+
+// Start boots up the announcement based transaction retriever.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the announcement based transaction retriever, canceling all
+// pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the fetcher of the potential availability of a batch of new
+// transactions in the network.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash, time time.Time, fetchTxs txRequesterFn) error {
+	notify := &txNotify{
+		origin:   peer,
+		hashes:   hashes,
+		time:     time,
+		fetchTxs: fetchTxs,
+	}
+	select {
+	case f.notify <- notify:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Enqueue injects a batch of received transactions into the local pool and
+// marks them as arrived, cancelling any outstanding retrievals. Direct denotes
+// whether the transactions were explicitly requested.
+func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool) error {
+	f.addTxs(txs)
+
+	delivery := &txDelivery{
+		origin: peer,
+		hashes: make([]common.Hash, len(txs)),
+		direct: direct,
+	}
+	for i, tx := range txs {
+		delivery.hashes[i] = tx.Hash()
+	}
+	select {
+	case f.cleanup <- delivery:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop removes all the announcements and retrievals of a disconnected peer.
+func (f *TxFetcher) Drop(peer string) error {
+	select {
+	case f.drop <- peer:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// loop is the main fetcher loop, checking and processing various notification
+// events.
+func (f *TxFetcher) loop() {
+	ticker := time.NewTicker(txGatherSlack)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.quit:
+			// Fetcher terminating, abort all operations
+			return
+
+		case notification := <-f.notify:
+			// A batch of transactions was announced, make sure the peer isn't DOSing us
+			txAnnounceInMeter.Mark(int64(len(notification.hashes)))
+
+			for _, hash := range notification.hashes {
+				if f.announces[notification.origin] >= txHashLimit {
+					log.Debug("Peer exceeded outstanding transaction announces", "peer", notification.origin, "limit", txHashLimit)
+					txAnnounceDOSMeter.Mark(1)
+					break
+				}
+				if f.hasTx(hash) || f.isAnnouncedBy(hash, notification.origin) {
+					continue
+				}
+				f.announced[hash] = append(f.announced[hash], &txAnnounce{
+					origin:   notification.origin,
+					time:     notification.time,
+					fetchTxs: notification.fetchTxs,
+				})
+				f.announces[notification.origin]++
+			}
+
+		case delivery := <-f.cleanup:
+			// A batch of transactions arrived, forget about all of them
+			for _, hash := range delivery.hashes {
+				f.forgetHash(hash)
+			}
+			// If the batch was a reply, anything not delivered is missing at the
+			// peer, reschedule it for retrieval from an alternate one
+			if delivery.direct {
+				for hash := range f.requests[delivery.origin] {
+					f.forgetFetch(hash)
+				}
+				delete(f.requests, delivery.origin)
+			}
+
+		case peer := <-f.drop:
+			f.forgetPeer(peer)
+
+		case <-ticker.C:
+			// Drop any peers not delivering their requested transactions in time
+			for hash, fetch := range f.fetching {
+				if time.Since(fetch.time) > txFetchTimeout {
+					log.Debug("Transaction retrieval timed out", "peer", fetch.origin, "hash", hash)
+					txFetchTimeoutMeter.Mark(1)
+
+					f.forgetPeer(fetch.origin)
+					go f.dropPeer(fetch.origin)
+				}
+			}
+			// Request all the transactions announced long enough ago and not yet
+			// arrived through a direct broadcast
+			request := make(map[string][]common.Hash)
+			fetchers := make(map[string]txRequesterFn)
+
+			for hash, announces := range f.announced {
+				if _, ok := f.fetching[hash]; ok {
+					continue
+				}
+				if time.Since(announces[0].time) < txArriveTimeout-txGatherSlack {
+					continue
+				}
+				if f.hasTx(hash) {
+					f.forgetHash(hash)
+					continue
+				}
+				// Pick the first announcer not busy with another request
+				for _, announce := range announces {
+					if len(f.requests[announce.origin]) > 0 || len(request[announce.origin]) >= txFetchLimit {
+						continue
+					}
+					request[announce.origin] = append(request[announce.origin], hash)
+					fetchers[announce.origin] = announce.fetchTxs
+					break
+				}
+			}
+			// Send out all the transaction requests
+			for peer, hashes := range request {
+				log.Trace("Fetching announced transactions", "peer", peer, "count", len(hashes))
+
+				f.requests[peer] = make(map[common.Hash]bool)
+				for _, hash := range hashes {
+					f.fetching[hash] = &txAnnounce{origin: peer, time: time.Now(), fetchTxs: fetchers[peer]}
+					f.requests[peer][hash] = true
+				}
+				if f.fetchingHook != nil {
+					f.fetchingHook(peer, hashes)
+				}
+				txFetchOutMeter.Mark(int64(len(hashes)))
+				go fetchers[peer](hashes)
+			}
+		}
+	}
+}
+
+// isAnnouncedBy checks whether a transaction was already announced by a peer.
+func (f *TxFetcher) isAnnouncedBy(hash common.Hash, peer string) bool {
+	for _, announce := range f.announced[hash] {
+		if announce.origin == peer {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetHash removes all traces of a transaction announcement from the fetcher's
+// internal state.
+func (f *TxFetcher) forgetHash(hash common.Hash) {
+	for _, announce := range f.announced[hash] {
+		f.announces[announce.origin]--
+		if f.announces[announce.origin] <= 0 {
+			delete(f.announces, announce.origin)
+		}
+	}
+	delete(f.announced, hash)
+
+	if fetch := f.fetching[hash]; fetch != nil {
+		delete(f.requests[fetch.origin], hash)
+		delete(f.fetching, hash)
+	}
+}
+
+// forgetFetch cancels an in-flight retrieval of a transaction, removing the
+// announcement of the peer it was requested from, so that the next tick will
+// reschedule it from an alternate peer (if any).
+func (f *TxFetcher) forgetFetch(hash common.Hash) {
+	fetch := f.fetching[hash]
+	if fetch == nil {
+		return
+	}
+	delete(f.fetching, hash)
+	delete(f.requests[fetch.origin], hash)
+	f.forgetAnnounce(hash, fetch.origin)
+}
+
+// forgetAnnounce removes a single peer's announcement of a transaction.
+func (f *TxFetcher) forgetAnnounce(hash common.Hash, peer string) {
+	announces := f.announced[hash]
+	for i, announce := range announces {
+		if announce.origin != peer {
+			continue
+		}
+		announces = append(announces[:i], announces[i+1:]...)
+		if f.announces[peer]--; f.announces[peer] <= 0 {
+			delete(f.announces, peer)
+		}
+		break
+	}
+	if len(announces) == 0 {
+		delete(f.announced, hash)
+	} else {
+		f.announced[hash] = announces
+	}
+}
+
+// forgetPeer removes all the announcements and in-flight retrievals of a peer.
+func (f *TxFetcher) forgetPeer(peer string) {
+	for hash := range f.requests[peer] {
+		delete(f.fetching, hash)
+	}
+	delete(f.requests, peer)
+
+	for hash := range f.announced {
+		f.forgetAnnounce(hash, peer)
+	}
+	delete(f.announces, peer)
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/core/types"
+)
+
+// txFetcherTester is a test simulator for mocking out the local transaction pool.
+type txFetcherTester struct {
+	fetcher *TxFetcher
+
+	pool    map[common.Hash]*types.Transaction // Transactions known to the local pool
+	dropped map[string]bool                    // Peers dropped by the fetcher
+	lock    sync.RWMutex
+}
+
+// newTxTester creates a new transaction fetcher test mocker.
+func newTxTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		pool:    make(map[common.Hash]*types.Transaction),
+		dropped: make(map[string]bool),
+	}
+	tester.fetcher = NewTxFetcher(tester.hasTx, tester.addTxs, tester.dropPeer)
+	tester.fetcher.Start()
+
+	return tester
+}
+
+// hasTx checks whether a transaction is contained in the tester's pool.
+func (f *txFetcherTester) hasTx(hash common.Hash) bool {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.pool[hash] != nil
+}
+
+// addTxs injects a batch of transactions into the tester's pool.
+func (f *txFetcherTester) addTxs(txs []*types.Transaction) []error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, tx := range txs {
+		f.pool[tx.Hash()] = tx
+	}
+	return make([]error, len(txs))
+}
+
+// dropPeer is an emulator for the peer removal, simply accumulating the various
+// peers dropped by the fetcher.
+func (f *txFetcherTester) dropPeer(peer string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.dropped[peer] = true
+}
+
+// makeTxs creates a batch of distinct dummy transactions.
+func makeTxs(n int) []*types.Transaction {
+	txs := make([]*types.Transaction, n)
+	for i := range txs {
+		txs[i] = types.NewTransaction(uint64(i), common.Address{}, big.NewInt(0), 0, big.NewInt(0), nil)
+	}
+	return txs
+}
+
+// txRequester creates a transaction requester callback forwarding the requested
+// hashes into the given channel.
+func txRequester(requests chan []common.Hash) txRequesterFn {
+	return func(hashes []common.Hash) error {
+		requests <- hashes
+		return nil
+	}
+}
+
+// verifyTxRequest checks that a transaction request arrives with the expected
+// number of hashes.
+func verifyTxRequest(t *testing.T, requests chan []common.Hash, count int) []common.Hash {
+	select {
+	case hashes := <-requests:
+		if len(hashes) != count {
+			t.Fatalf("requested hash count mismatch: have %d, want %d", len(hashes), count)
+		}
+		return hashes
+	case <-time.After(time.Second):
+		t.Fatalf("transaction request timeout")
+	}
+	return nil
+}
+
+// verifyNoTxRequest checks that no transaction request arrives.
+func verifyNoTxRequest(t *testing.T, requests chan []common.Hash) {
+	select {
+	case hashes := <-requests:
+		t.Fatalf("unexpected transaction request: %v", hashes)
+	case <-time.After(txArriveTimeout + txGatherSlack):
+	}
+}
+
+// Tests that a transaction announced by multiple peers is only requested from
+// one of them.
+func TestTxAnnounceDeduplication(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := makeTxs(4)
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	requests := make(chan []common.Hash, 10)
+	fetcher := txRequester(requests)
+
+	tester.fetcher.Notify("A", hashes, time.Now().Add(-txArriveTimeout), fetcher)
+	tester.fetcher.Notify("B", hashes, time.Now().Add(-txArriveTimeout), fetcher)
+
+	verifyTxRequest(t, requests, len(hashes))
+	verifyNoTxRequest(t, requests)
+}
+
+// Tests that transactions arriving through a direct broadcast in the arrival
+// window are not requested explicitly.
+func TestTxAnnounceBroadcastArrival(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := makeTxs(2)
+	requests := make(chan []common.Hash, 10)
+
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash(), txs[1].Hash()}, time.Now(), txRequester(requests))
+	tester.fetcher.Enqueue("B", txs[:1], false)
+
+	hashes := verifyTxRequest(t, requests, 1)
+	if hashes[0] != txs[1].Hash() {
+		t.Fatalf("requested hash mismatch: have %x, want %x", hashes[0], txs[1].Hash())
+	}
+}
+
+// Tests that known transactions are not requested.
+func TestTxAnnounceKnown(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := makeTxs(1)
+	tester.addTxs(txs)
+
+	requests := make(chan []common.Hash, 10)
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash()}, time.Now().Add(-txArriveTimeout), txRequester(requests))
+
+	verifyNoTxRequest(t, requests)
+}
+
+// Tests that transactions missing from a reply are requested from an alternate
+// announcer.
+func TestTxAnnounceMissingDelivery(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := makeTxs(1)
+	hash := txs[0].Hash()
+
+	requestsA := make(chan []common.Hash, 10)
+	requestsB := make(chan []common.Hash, 10)
+
+	tester.fetcher.Notify("A", []common.Hash{hash}, time.Now().Add(-txArriveTimeout), txRequester(requestsA))
+	verifyTxRequest(t, requestsA, 1)
+	tester.fetcher.Notify("B", []common.Hash{hash}, time.Now().Add(-txArriveTimeout), txRequester(requestsB))
+
+	// Peer A replies without the transaction, which should move on to peer B
+	tester.fetcher.Enqueue("A", nil, true)
+	verifyTxRequest(t, requestsB, 1)
+
+	tester.fetcher.Enqueue("B", txs, true)
+	if !tester.hasTx(hash) {
+		t.Fatalf("delivered transaction not added to the pool")
+	}
+	verifyNoTxRequest(t, requestsA)
+}
+
+// Tests that peers not delivering requested transactions in time are dropped
+// and the retrieval is retried from an alternate announcer.
+func TestTxAnnounceTimeout(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := makeTxs(1)
+	hash := txs[0].Hash()
+
+	requestsA := make(chan []common.Hash, 10)
+	requestsB := make(chan []common.Hash, 10)
+
+	tester.fetcher.Notify("A", []common.Hash{hash}, time.Now().Add(-txArriveTimeout), txRequester(requestsA))
+	verifyTxRequest(t, requestsA, 1)
+	tester.fetcher.Notify("B", []common.Hash{hash}, time.Now().Add(-txArriveTimeout), txRequester(requestsB))
+
+	select {
+	case <-requestsB:
+	case <-time.After(txFetchTimeout + time.Second):
+		t.Fatalf("transaction not requested from alternate peer")
+	}
+	// Peer drops are asynchronous, give it a bit of time
+	for i := 0; i < 10; i++ {
+		tester.lock.RLock()
+		dropped := tester.dropped["A"]
+		tester.lock.RUnlock()
+
+		if dropped {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("slow peer not dropped")
+}
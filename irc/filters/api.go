@@ -275,6 +275,20 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 // Same as ethereum.FilterQuery but with UnmarshalJSON() method.
 type FilterCriteria irchain.FilterQuery
 
+// filterCriteriaEncoding describes the JSON encoding of filter criteria in the
+// API description. Single addresses and topics are also accepted in place of
+// the lists.
+type filterCriteriaEncoding struct {
+	FromBlock *rpc.BlockNumber `json:"fromBlock"`
+	ToBlock   *rpc.BlockNumber `json:"toBlock"`
+	Addresses []common.Address `json:"address,omitempty"`
+	Topics    [][]common.Hash  `json:"topics,omitempty"`
+}
+
+func init() {
+	rpc.RegisterSchemaOverride(FilterCriteria{}, filterCriteriaEncoding{})
+}
+
 // NewFilter creates a new filter and returns the filter id. It can be
 // used to retrieve logs when the state changes. This method cannot be
 // used to fetch logs that are already stored in the state.
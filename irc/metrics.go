@@ -26,6 +26,10 @@ var (
 	propTxnInTrafficMeter     = metrics.NewRegisteredMeter("irc/prop/txns/in/traffic", nil)
 	propTxnOutPacketsMeter    = metrics.NewRegisteredMeter("irc/prop/txns/out/packets", nil)
 	propTxnOutTrafficMeter    = metrics.NewRegisteredMeter("irc/prop/txns/out/traffic", nil)
+	propTxHashInPacketsMeter  = metrics.NewRegisteredMeter("irc/prop/txhashes/in/packets", nil)
+	propTxHashInTrafficMeter  = metrics.NewRegisteredMeter("irc/prop/txhashes/in/traffic", nil)
+	propTxHashOutPacketsMeter = metrics.NewRegisteredMeter("irc/prop/txhashes/out/packets", nil)
+	propTxHashOutTrafficMeter = metrics.NewRegisteredMeter("irc/prop/txhashes/out/traffic", nil)
 	propHashInPacketsMeter    = metrics.NewRegisteredMeter("irc/prop/hashes/in/packets", nil)
 	propHashInTrafficMeter    = metrics.NewRegisteredMeter("irc/prop/hashes/in/traffic", nil)
 	propHashOutPacketsMeter   = metrics.NewRegisteredMeter("irc/prop/hashes/out/packets", nil)
@@ -50,6 +54,10 @@ var (
 	reqReceiptInTrafficMeter  = metrics.NewRegisteredMeter("irc/req/receipts/in/traffic", nil)
 	reqReceiptOutPacketsMeter = metrics.NewRegisteredMeter("irc/req/receipts/out/packets", nil)
 	reqReceiptOutTrafficMeter = metrics.NewRegisteredMeter("irc/req/receipts/out/traffic", nil)
+	reqTxnInPacketsMeter      = metrics.NewRegisteredMeter("irc/req/txns/in/packets", nil)
+	reqTxnInTrafficMeter      = metrics.NewRegisteredMeter("irc/req/txns/in/traffic", nil)
+	reqTxnOutPacketsMeter     = metrics.NewRegisteredMeter("irc/req/txns/out/packets", nil)
+	reqTxnOutTrafficMeter     = metrics.NewRegisteredMeter("irc/req/txns/out/traffic", nil)
 	miscInPacketsMeter        = metrics.NewRegisteredMeter("irc/misc/in/packets", nil)
 	miscInTrafficMeter        = metrics.NewRegisteredMeter("irc/misc/in/traffic", nil)
 	miscOutPacketsMeter       = metrics.NewRegisteredMeter("irc/misc/out/packets", nil)
@@ -96,6 +104,8 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		packets, traffic = reqStateInPacketsMeter, reqStateInTrafficMeter
 	case rw.version >= irc63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptInPacketsMeter, reqReceiptInTrafficMeter
+	case rw.version >= irc64 && msg.Code == PooledTransactionsMsg:
+		packets, traffic = reqTxnInPacketsMeter, reqTxnInTrafficMeter
 
 	case msg.Code == NewBlockHashesMsg:
 		packets, traffic = propHashInPacketsMeter, propHashInTrafficMeter
@@ -103,6 +113,8 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		packets, traffic = propBlockInPacketsMeter, propBlockInTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnInPacketsMeter, propTxnInTrafficMeter
+	case rw.version >= irc64 && msg.Code == NewPooledTransactionHashesMsg:
+		packets, traffic = propTxHashInPacketsMeter, propTxHashInTrafficMeter
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
@@ -123,6 +135,8 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 		packets, traffic = reqStateOutPacketsMeter, reqStateOutTrafficMeter
 	case rw.version >= irc63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptOutPacketsMeter, reqReceiptOutTrafficMeter
+	case rw.version >= irc64 && msg.Code == PooledTransactionsMsg:
+		packets, traffic = reqTxnOutPacketsMeter, reqTxnOutTrafficMeter
 
 	case msg.Code == NewBlockHashesMsg:
 		packets, traffic = propHashOutPacketsMeter, propHashOutTrafficMeter
@@ -130,6 +144,8 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 		packets, traffic = propBlockOutPacketsMeter, propBlockOutTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnOutPacketsMeter, propTxnOutTrafficMeter
+	case rw.version >= irc64 && msg.Code == NewPooledTransactionHashesMsg:
+		packets, traffic = propTxHashOutPacketsMeter, propTxHashOutTrafficMeter
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
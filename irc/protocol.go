@@ -23,6 +23,7 @@ import (
 
 	"github.com/irchain/go-irchain/common"
 	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/forkid"
 	"github.com/irchain/go-irchain/core/types"
 	"github.com/irchain/go-irchain/event"
 	"github.com/irchain/go-irchain/rlp"
@@ -32,16 +33,18 @@ import (
 const (
 	irc62 = 62
 	irc63 = 63
+	irc64 = 64
+	irc65 = 65
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "irc"
 
 // ProtocolVersions are the upported versions of the irc protocol (first is primary).
-var ProtocolVersions = []uint{irc63, irc62}
+var ProtocolVersions = []uint{irc65, irc64, irc63, irc62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{20, 20, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -62,6 +65,11 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to irc/64
+	NewPooledTransactionHashesMsg = 0x11
+	GetPooledTransactionsMsg      = 0x12
+	PooledTransactionsMsg         = 0x13
 )
 
 type errCode int
@@ -76,6 +84,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -93,6 +102,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
@@ -103,6 +113,10 @@ type txPool interface {
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
 
+	// Get should return a transaction if it is contained in the pool, or nil
+	// otherwise.
+	Get(hash common.Hash) *types.Transaction
+
 	// SubscribeNewTxsEvent should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
@@ -117,6 +131,17 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData65 is the network packet for the status message from irc/65 onwards,
+// extending the legacy one with the EIP-2124 style fork identifier.
+type statusData65 struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
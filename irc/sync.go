@@ -25,6 +25,7 @@ import (
 	"github.com/irchain/go-irchain/core/types"
 	"github.com/irchain/go-irchain/irc/downloader"
 	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p"
 	"github.com/irchain/go-irchain/p2p/discover"
 )
 
@@ -135,6 +136,8 @@ func (pm *ProtocolManager) syncer() {
 	// Start and ensure cleanup of sync mechanisms
 	pm.fetcher.Start()
 	defer pm.fetcher.Stop()
+	pm.txFetcher.Start()
+	defer pm.txFetcher.Stop()
 	defer pm.downloader.Terminate()
 
 	// Wait for different events to fire synchronisation operations
@@ -198,6 +201,10 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 
 	// Run the sync cycle, and disable fast sync if we've went past the pivot block
 	if err := pm.downloader.Synchronise(peer.id, pHead, pTd, mode); err != nil {
+		// Only invalid data counts against the peer, not ordinary sync failures
+		if downloader.IsPeerFault(err) {
+			peer.Report(p2p.FaultInvalid)
+		}
 		return
 	}
 	if atomic.LoadUint32(&pm.fastSync) == 1 {
@@ -0,0 +1,117 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/core/vm"
+)
+
+// ResultTracer is a vm.Tracer producing a JSON result, which can be interrupted
+// before the traced execution finishes. Both the JavaScript and the native
+// tracers implement it.
+type ResultTracer interface {
+	vm.Tracer
+
+	// GetResult returns the result of the tracing, or the error which aborted it.
+	GetResult() (json.RawMessage, error)
+
+	// Stop terminates the tracing at the first opportune moment.
+	Stop(err error)
+}
+
+// native contains the constructors of the native tracers by name.
+var native = make(map[string]func() ResultTracer)
+
+// RegisterNative makes a tracer implemented in Go available by name. Native
+// tracers take precedence over the JavaScript tracers of the same name. It is
+// not safe for concurrent use and should be called from init functions.
+func RegisterNative(name string, ctor func() ResultTracer) {
+	native[name] = ctor
+}
+
+// NewTracer creates the native tracer registered with the given name, or else
+// a JavaScript tracer running the built in tracer of that name or the given
+// code.
+func NewTracer(code string) (ResultTracer, error) {
+	if ctor, ok := native[code]; ok {
+		return ctor(), nil
+	}
+	return New(code)
+}
+
+// interrupter implements the Stop method of the native tracers.
+type interrupter struct {
+	interrupt uint32 // Atomic flag to signal execution interruption
+	reason    error  // Textual reason for the interruption
+}
+
+// Stop terminates execution of the tracer at the first opportune moment.
+func (i *interrupter) Stop(err error) {
+	i.reason = err
+	atomic.StoreUint32(&i.interrupt, 1)
+}
+
+// interrupted returns whether the tracer was stopped.
+func (i *interrupter) interrupted() bool {
+	return atomic.LoadUint32(&i.interrupt) > 0
+}
+
+// toHex encodes an address the same way the JavaScript tracers do.
+func toHex(addr common.Address) string {
+	return hexutil.Encode(addr[:])
+}
+
+// peek returns the nth-from-the-top element of the stack, or zero if the stack
+// is too short, like the JavaScript stack wrapper.
+func peek(stack *vm.Stack, n int) *big.Int {
+	if len(stack.Data()) <= n {
+		return new(big.Int)
+	}
+	return stack.Back(n)
+}
+
+// memorySlice returns a copy of the given range of memory, or nothing if the
+// range is out of bounds, like the JavaScript memory wrapper.
+func memorySlice(memory *vm.Memory, offset, size *big.Int) []byte {
+	if !offset.IsUint64() || !size.IsUint64() {
+		return nil
+	}
+	begin, end := offset.Uint64(), offset.Uint64()+size.Uint64()
+	if end < begin || uint64(memory.Len()) < end {
+		return nil
+	}
+	return memory.Get(int64(begin), int64(end-begin))
+}
+
+// encodeJSON encodes a native tracer result without escaping HTML characters,
+// matching the output of the JavaScript engine.
+func encodeJSON(v interface{}) (json.RawMessage, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
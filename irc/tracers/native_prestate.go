@@ -0,0 +1,214 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/hexutil"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/crypto"
+)
+
+// errNoPrestate is returned if no opcode was executed, so the state could not be
+// accessed to assemble the prestate.
+var errNoPrestate = errors.New("no state accessed during execution")
+
+func init() {
+	RegisterNative("prestateTracer", newPrestateTracer)
+}
+
+// prestateAccount is the state of an account before the traced execution.
+type prestateAccount struct {
+	balance *big.Int
+	nonce   uint64
+	code    []byte
+	slots   []common.Hash // Non-empty storage slots in the order of their first access
+	storage map[common.Hash]common.Hash
+}
+
+// MarshalJSON encodes the account like the JavaScript prestateTracer does,
+// keeping the storage slots in access order.
+func (acc *prestateAccount) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(`{"balance":"` + hexutil.EncodeBig(acc.balance) + `","nonce":`)
+	nonce, _ := json.Marshal(acc.nonce)
+	buf.Write(nonce)
+	buf.WriteString(`,"code":"` + hexutil.Encode(acc.code) + `","storage":{`)
+	for i, key := range acc.slots {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		val := acc.storage[key]
+		buf.WriteString(`"` + hexutil.Encode(key[:]) + `":"` + hexutil.Encode(val[:]) + `"`)
+	}
+	buf.WriteString(`}}`)
+	return buf.Bytes(), nil
+}
+
+// prestateTracer is the native implementation of the JavaScript prestateTracer,
+// which outputs sufficient information to create a local execution of the
+// transaction from a custom assembled genesis block.
+type prestateTracer struct {
+	interrupter
+
+	db       vm.StateDB                          // State database of the last executed opcode
+	accounts []common.Address                    // Accounts in the order of their first access
+	prestate map[common.Address]*prestateAccount // Genesis allocations being assembled
+
+	create bool           // Whether the outer call created a contract
+	from   common.Address // Sender of the outer call
+	to     common.Address // Recipient or created contract of the outer call
+	value  *big.Int       // Value transferred by the outer call
+
+	err error // Error aborting the tracing, if any
+}
+
+func newPrestateTracer() ResultTracer {
+	return new(prestateTracer)
+}
+
+// lookupAccount injects the specified account into the prestate.
+func (t *prestateTracer) lookupAccount(addr common.Address) {
+	if _, ok := t.prestate[addr]; ok {
+		return
+	}
+	t.accounts = append(t.accounts, addr)
+	t.prestate[addr] = &prestateAccount{
+		balance: new(big.Int).Set(t.db.GetBalance(addr)),
+		nonce:   t.db.GetNonce(addr),
+		code:    t.db.GetCode(addr),
+		storage: make(map[common.Hash]common.Hash),
+	}
+}
+
+// lookupStorage injects the specified storage entry of the given account into
+// the prestate. Empty slots are not recorded.
+func (t *prestateTracer) lookupStorage(addr common.Address, key common.Hash) {
+	t.lookupAccount(addr)
+
+	acc := t.prestate[addr]
+	if _, ok := acc.storage[key]; ok {
+		return
+	}
+	if val := t.db.GetState(addr, key); val != (common.Hash{}) {
+		acc.slots = append(acc.slots, key)
+		acc.storage[key] = val
+	}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (t *prestateTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	t.create, t.from, t.to, t.value = create, from, to, value
+	return nil
+}
+
+// CaptureState implements the Tracer interface to trace a single step of VM execution.
+func (t *prestateTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if t.err != nil {
+		return nil
+	}
+	if t.interrupted() {
+		t.err = t.reason
+		return nil
+	}
+	t.db = env.StateDB
+
+	// Add the current account if we just started tracing. Its balance includes the
+	// value sent along with the message, which is fixed up in GetResult.
+	if t.prestate == nil {
+		t.prestate = make(map[common.Address]*prestateAccount)
+		t.lookupAccount(contract.Address())
+	}
+	// Whenever new state is accessed, add it to the prestate
+	switch op {
+	case vm.EXTCODECOPY, vm.EXTCODESIZE, vm.BALANCE:
+		t.lookupAccount(common.BigToAddress(peek(stack, 0)))
+	case vm.CREATE:
+		from := contract.Address()
+		t.lookupAccount(crypto.CreateAddress(from, t.db.GetNonce(from)))
+	case vm.CALL, vm.CALLCODE, vm.DELEGATECALL, vm.STATICCALL:
+		t.lookupAccount(common.BigToAddress(peek(stack, 1)))
+	case vm.SSTORE, vm.SLOAD:
+		t.lookupStorage(contract.Address(), common.BigToHash(peek(stack, 0)))
+	}
+	return nil
+}
+
+// CaptureFault implements the Tracer interface to trace an execution fault
+// while running an opcode.
+func (t *prestateTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	return nil
+}
+
+// CaptureEnd is called after the call finishes to finalize the tracing.
+func (t *prestateTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) error {
+	return nil
+}
+
+// GetResult returns the assembled prestate, or any error which aborted the
+// tracing.
+func (t *prestateTracer) GetResult() (json.RawMessage, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	if t.prestate == nil {
+		return nil, errNoPrestate
+	}
+	// At this point, we need to deduct the 'value' from the outer transaction, and
+	// move it back to the origin
+	t.lookupAccount(t.from)
+	t.lookupAccount(t.to)
+
+	fromBal := new(big.Int).Set(t.prestate[t.from].balance)
+	toBal := new(big.Int).Set(t.prestate[t.to].balance)
+
+	t.prestate[t.to].balance = toBal.Sub(toBal, t.value)
+	t.prestate[t.from].balance = fromBal.Add(fromBal, t.value)
+
+	// Decrement the caller's nonce, and remove empty create targets. Any existing
+	// state would have caused the transaction to be rejected as invalid anyway.
+	t.prestate[t.from].nonce--
+	if t.create {
+		delete(t.prestate, t.to)
+	}
+	// Assemble the allocations in the order of their first access
+	buf := new(bytes.Buffer)
+	buf.WriteByte('{')
+	for _, addr := range t.accounts {
+		acc, ok := t.prestate[addr]
+		if !ok {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		blob, err := acc.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`"` + toHex(addr) + `":`)
+		buf.Write(blob)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
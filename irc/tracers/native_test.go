@@ -0,0 +1,110 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/params"
+)
+
+// timeField matches the execution time reported by the call tracers, which
+// differs between runs.
+var timeField = regexp.MustCompile(`"time":"[^"]*"`)
+
+// Tests that the native tracers produce the same output as their JavaScript
+// counterparts over the call tracer test suite.
+func TestNativeTracers(t *testing.T) {
+	files, err := ioutil.ReadDir("testdata")
+	if err != nil {
+		t.Fatalf("failed to retrieve tracer test suite: %v", err)
+	}
+	for _, file := range files {
+		if !strings.HasPrefix(file.Name(), "call_tracer_") {
+			continue
+		}
+		test, err := loadTracerTest(file.Name())
+		if err != nil {
+			t.Fatalf("failed to load testcase: %v", err)
+		}
+		for _, name := range []string{"callTracer", "prestateTracer"} {
+			name := name // capture range variable
+			t.Run(name+"/"+camel(strings.TrimSuffix(strings.TrimPrefix(file.Name(), "call_tracer_"), ".json")), func(t *testing.T) {
+				jst, err := New(name)
+				if err != nil {
+					t.Fatalf("failed to create JavaScript tracer: %v", err)
+				}
+				want, jsErr := runTracerTest(test, jst)
+				have, err := runTracerTest(test, native[name]())
+				if (err != nil) != (jsErr != nil) {
+					t.Fatalf("error mismatch: have %v, want %v", err, jsErr)
+				}
+				want = timeField.ReplaceAll(want, []byte(`"time":""`))
+				have = timeField.ReplaceAll(have, []byte(`"time":""`))
+				if string(have) != string(want) {
+					t.Errorf("trace mismatch:\nhave %s\nwant %s", have, want)
+				}
+			})
+		}
+	}
+}
+
+func TestNewTracer(t *testing.T) {
+	tracer, err := NewTracer("callTracer")
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	if _, ok := tracer.(*callTracer); !ok {
+		t.Errorf("native tracer not preferred: have %T", tracer)
+	}
+	RegisterNative("testTracer", newPrestateTracer)
+	defer delete(native, "testTracer")
+
+	if tracer, _ := NewTracer("testTracer"); tracer == nil {
+		t.Errorf("registered native tracer not found")
+	}
+	if tracer, _ := NewTracer("opcountTracer"); tracer == nil {
+		t.Errorf("JavaScript tracer not found")
+	} else if _, ok := tracer.(*Tracer); !ok {
+		t.Errorf("JavaScript tracer type mismatch: have %T", tracer)
+	}
+}
+
+func TestNativeTracerStop(t *testing.T) {
+	env := vm.NewEVM(vm.Context{BlockNumber: big.NewInt(1)}, nil, params.TestChainConfig, vm.Config{})
+	contract := vm.NewContract(&account{}, &account{}, big.NewInt(0), 0, false)
+
+	for name, ctor := range native {
+		tracer := ctor()
+		tracer.CaptureStart(common.Address{}, common.Address{}, false, nil, 0, big.NewInt(0))
+
+		timeout := errors.New("stahp")
+		tracer.Stop(timeout)
+		tracer.CaptureState(env, 0, 0, 0, 0, nil, nil, contract, 0, nil)
+
+		if _, err := tracer.GetResult(); err != timeout {
+			t.Errorf("%s: expected timeout error, got %v", name, err)
+		}
+	}
+}
@@ -455,6 +455,18 @@ func (ec *Client) PendingCallContract(ctx context.Context, msg irchain.CallMsg)
 	return hex, nil
 }
 
+// CallContractWithOverrides executes a message call transaction like CallContract,
+// with the state of the given accounts overridden before execution. This allows
+// simulating calls against modified balances, nonces, code or storage.
+func (ec *Client) CallContractWithOverrides(ctx context.Context, msg irchain.CallMsg, blockNumber *big.Int, overrides map[common.Address]OverrideAccount) ([]byte, error) {
+	var hex hexutil.Bytes
+	err := ec.c.CallContext(ctx, &hex, "irc_call", toCallArg(msg), toBlockNumArg(blockNumber), overrides)
+	if err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
+
 // SuggestGasPrice retrieves the currently suggested gas price to allow a timely
 // execution of a transaction.
 func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
@@ -478,6 +490,17 @@ func (ec *Client) EstimateGas(ctx context.Context, msg irchain.CallMsg) (uint64,
 	return uint64(hex), nil
 }
 
+// EstimateGasWithOverrides estimates the gas needed to execute a specific
+// transaction like EstimateGas, with the state of the given accounts overridden.
+func (ec *Client) EstimateGasWithOverrides(ctx context.Context, msg irchain.CallMsg, overrides map[common.Address]OverrideAccount) (uint64, error) {
+	var hex hexutil.Uint64
+	err := ec.c.CallContext(ctx, &hex, "irc_estimateGas", toCallArg(msg), overrides)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(hex), nil
+}
+
 // SendTransaction injects a signed transaction into the pending pool for execution.
 //
 // If the transaction was a contract creation use the TransactionReceipt method to get the
@@ -490,6 +513,45 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 	return ec.c.CallContext(ctx, nil, "irc_sendRawTransaction", common.ToHex(data))
 }
 
+// OverrideAccount specifies the state of an account to be overridden during
+// the execution of a call. Only the set fields are overridden: a zero Nonce, a
+// nil Code and a nil Balance keep the original values. State replaces the whole
+// storage of the account, whereas StateDiff only patches the given slots; they
+// can't be used together.
+type OverrideAccount struct {
+	Nonce     uint64
+	Code      []byte
+	Balance   *big.Int
+	State     map[common.Hash]common.Hash
+	StateDiff map[common.Hash]common.Hash
+}
+
+// MarshalJSON implements json.Marshaler, encoding the override in the format
+// expected by the RPC server.
+func (a OverrideAccount) MarshalJSON() ([]byte, error) {
+	type acc struct {
+		Nonce     hexutil.Uint64               `json:"nonce,omitempty"`
+		Code      *hexutil.Bytes               `json:"code,omitempty"`
+		Balance   *hexutil.Big                 `json:"balance,omitempty"`
+		State     *map[common.Hash]common.Hash `json:"state,omitempty"`
+		StateDiff map[common.Hash]common.Hash  `json:"stateDiff,omitempty"`
+	}
+	output := acc{
+		Nonce:     hexutil.Uint64(a.Nonce),
+		Balance:   (*hexutil.Big)(a.Balance),
+		StateDiff: a.StateDiff,
+	}
+	if a.Code != nil {
+		code := hexutil.Bytes(a.Code)
+		output.Code = &code
+	}
+	// An empty but non-nil state clears the storage, so it must be sent
+	if a.State != nil {
+		output.State = &a.State
+	}
+	return json.Marshal(&output)
+}
+
 func toCallArg(msg irchain.CallMsg) interface{} {
 	arg := map[string]interface{}{
 		"from": msg.From,
@@ -0,0 +1,124 @@
+// Copyright 2016 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+var errNoServer = errors.New("light server not running")
+
+// PrivateLightServerAPI provides an API to manage the capacities assigned to
+// the clients of a light server and to inspect what they consumed.
+type PrivateLightServerAPI struct {
+	server *LesServer
+}
+
+// NewPrivateLightServerAPI creates a new LES server API.
+func NewPrivateLightServerAPI(server *LesServer) *PrivateLightServerAPI {
+	return &PrivateLightServerAPI{server: server}
+}
+
+// SetClientCapacity assigns a guaranteed capacity to a client. A capacity of
+// zero turns the client into a free client.
+func (api *PrivateLightServerAPI) SetClientCapacity(node string, capacity uint64) (bool, error) {
+	if api.server.clientPool == nil {
+		return false, errNoServer
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	if err := api.server.clientPool.setCapacity(id, capacity); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClientInfo returns the capacities of the given clients, or of all connected
+// and priority clients if no IDs are given.
+func (api *PrivateLightServerAPI) ClientInfo(nodes []string) (map[discover.NodeID]*ClientInfo, error) {
+	if api.server.clientPool == nil {
+		return nil, errNoServer
+	}
+	var ids []discover.NodeID
+	if len(nodes) == 0 {
+		ids = api.server.clientPool.clients()
+	}
+	for _, node := range nodes {
+		id, err := parseNodeID(node)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	infos := make(map[discover.NodeID]*ClientInfo)
+	for _, id := range ids {
+		infos[id] = api.server.clientPool.clientInfo(id)
+	}
+	return infos, nil
+}
+
+// ServingStats returns the statistics of all requests served, accumulated
+// across restarts.
+func (api *PrivateLightServerAPI) ServingStats() (*ServingStats, error) {
+	if api.server.servingStats == nil {
+		return nil, errNoServer
+	}
+	return api.server.servingStats.totals(), nil
+}
+
+// ClientStats returns the serving statistics of the given clients, or of all
+// clients served since startup if no IDs are given.
+func (api *PrivateLightServerAPI) ClientStats(nodes []string) (map[discover.NodeID]*ServingStats, error) {
+	if api.server.servingStats == nil {
+		return nil, errNoServer
+	}
+	var ids []discover.NodeID
+	if len(nodes) == 0 {
+		ids = api.server.servingStats.clientIDs()
+	}
+	for _, node := range nodes {
+		id, err := parseNodeID(node)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	stats := make(map[discover.NodeID]*ServingStats)
+	for _, id := range ids {
+		if s := api.server.servingStats.clientStats(id); s != nil {
+			stats[id] = s
+		}
+	}
+	return stats, nil
+}
+
+// parseNodeID parses a node ID given either in hex or as a hnode URL.
+func parseNodeID(node string) (discover.NodeID, error) {
+	if id, err := discover.HexID(node); err == nil {
+		return id, nil
+	}
+	n, err := discover.ParseNode(node)
+	if err != nil {
+		return discover.NodeID{}, fmt.Errorf("invalid hnode: %v", err)
+	}
+	return n.ID, nil
+}
@@ -22,7 +22,6 @@ import (
 
 	"github.com/irchain/go-irchain/accounts"
 	"github.com/irchain/go-irchain/common"
-	"github.com/irchain/go-irchain/common/math"
 	"github.com/irchain/go-irchain/core"
 	"github.com/irchain/go-irchain/core/bloombits"
 	"github.com/irchain/go-irchain/core/rawdb"
@@ -103,7 +102,6 @@ func (b *LesApiBackend) GetTd(hash common.Hash) *big.Int {
 }
 
 func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
-	state.SetBalance(*msg.To(), math.MaxBig256)
 	context := core.NewEVMContext(msg, header, b.irc.blockchain, nil)
 	return vm.NewEVM(context, state, b.irc.chainConfig, vmCfg), state.Error, nil
 }
@@ -140,6 +138,21 @@ func (b *LesApiBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.irc.txPool.SubscribeNewTxsEvent(ch)
 }
 
+// TxPoolDropped always returns nil, the light pool doesn't validate or evict
+// transactions, leaving that to the servers.
+func (b *LesApiBackend) TxPoolDropped(hash common.Hash) *core.TxDrop {
+	return nil
+}
+
+// SubscribeDropTxsEvent returns a subscription never firing, the light pool
+// doesn't validate or evict transactions, leaving that to the servers.
+func (b *LesApiBackend) SubscribeDropTxsEvent(ch chan<- core.DropTxsEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.irc.blockchain.SubscribeChainEvent(ch)
 }
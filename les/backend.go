@@ -128,9 +128,10 @@ func New(ctx *node.ServiceContext, config *irc.Config) (*LightIrChain, error) {
 	}
 
 	lirc.txPool = light.NewTxPool(lirc.chainConfig, lirc.blockchain, lirc.relay)
-	if lirc.protocolManager, err = NewProtocolManager(lirc.chainConfig, true, ClientProtocolVersions, config.NetworkId, lirc.eventMux, lirc.engine, lirc.peers, lirc.blockchain, nil, chainDb, lirc.odr, lirc.relay, quitSync, &lirc.wg); err != nil {
+	if lirc.protocolManager, err = NewProtocolManager(lirc.chainConfig, true, ClientProtocolVersions, config.NetworkId, lirc.eventMux, lirc.engine, lirc.peers, lirc.blockchain, nil, chainDb, lirc.odr, lirc.relay, config.ULC, quitSync, &lirc.wg); err != nil {
 		return nil, err
 	}
+	lirc.protocolManager.checkpointOracle = config.CheckpointOracle
 	lirc.ApiBackend = &LesApiBackend{lirc, nil}
 	gpoParams := config.GPO
 	if gpoParams.Default == nil {
@@ -224,6 +225,11 @@ func (irc *LightIrChain) Start(srvr *p2p.Server) error {
 	// clients are searching for the first advertised protocol in the list
 	protocolVersion := AdvertiseProtocolVersions[0]
 	irc.serverPool.start(srvr, lesTopic(irc.blockchain.Genesis().Hash(), protocolVersion))
+	if ulc := irc.protocolManager.ulc; ulc != nil {
+		for _, node := range ulc.servers {
+			srvr.AddPeer(node)
+		}
+	}
 	irc.protocolManager.Start(irc.config.LightPeers)
 	return nil
 }
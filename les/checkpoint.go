@@ -0,0 +1,234 @@
+// Copyright 2016 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	irchain "github.com/irchain/go-irchain"
+	"github.com/irchain/go-irchain/accounts"
+	"github.com/irchain/go-irchain/accounts/abi/bind"
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/common/math"
+	"github.com/irchain/go-irchain/contracts/checkpointoracle"
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/state"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/light"
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/params"
+)
+
+const (
+	checkpointSyncTimeout      = 10 * time.Second // Time allowed for fetching a checkpoint from the oracle
+	checkpointRegisterInterval = 10 * time.Minute // Time between checks for new local checkpoints to vote on
+)
+
+var (
+	errBlockNumberUnsupported = errors.New("state caller can't access other blocks")
+	errCallFailed             = errors.New("contract call failed")
+)
+
+// stateCaller is a bind.ContractCaller executing calls on the state of a
+// given block. It's used to call the checkpoint oracle on the ODR backed state
+// of a block which is not part of the local chain yet.
+type stateCaller struct {
+	config *params.ChainConfig
+	chain  core.ChainContext
+	header *types.Header
+	state  *state.StateDB
+}
+
+// CodeAt returns the code of the given account (implementation of bind.ContractCaller).
+func (c *stateCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber != nil && blockNumber.Cmp(c.header.Number) != 0 {
+		return nil, errBlockNumberUnsupported
+	}
+	code := c.state.GetCode(contract)
+	return code, c.state.Error()
+}
+
+// CallContract executes a contract call (implementation of bind.ContractCaller).
+func (c *stateCaller) CallContract(ctx context.Context, call irchain.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if blockNumber != nil && blockNumber.Cmp(c.header.Number) != 0 {
+		return nil, errBlockNumberUnsupported
+	}
+	snapshot := c.state.Snapshot()
+	defer c.state.RevertToSnapshot(snapshot)
+
+	// Contracts pay for the calls made to them, make sure the call doesn't fail on that
+	c.state.SetBalance(*call.To, math.MaxBig256)
+
+	msg := types.NewMessage(call.From, call.To, 0, new(big.Int), math.MaxUint64/2, new(big.Int), call.Data, false)
+	evm := vm.NewEVM(core.NewEVMContext(msg, c.header, c.chain, nil), c.state, c.config, vm.Config{})
+	res, _, failed, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if err := c.state.Error(); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if failed {
+		return nil, errCallFailed
+	}
+	return res, nil
+}
+
+// syncCheckpoint retrieves the latest checkpoint from the checkpoint oracle at
+// the head announced by the peer and adds it to the light chain if it's newer
+// than the local head. The checkpoint is only accepted if it's signed by enough
+// trusted signers, so the peer can't forge it.
+func (pm *ProtocolManager) syncCheckpoint(ctx context.Context, peer *peer) error {
+	lc := pm.blockchain.(*light.LightChain)
+	oracle := pm.checkpointOracle
+
+	head := peer.headBlockInfo()
+	header, err := light.GetUntrustedHeader(ctx, pm.odr, head.Hash, head.Number)
+	if err != nil {
+		return err
+	}
+	caller := &stateCaller{
+		config: pm.chainConfig,
+		chain:  lc,
+		header: header,
+		state:  light.NewState(ctx, header, pm.odr),
+	}
+	contract, err := checkpointoracle.NewCheckpointOracle(oracle.Address, caller)
+	if err != nil {
+		return err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	cp, err := contract.LatestCheckpoint(opts)
+	if err != nil {
+		return err
+	}
+	if cp.HeadNumber() <= lc.CurrentHeader().Number.Uint64() {
+		return nil
+	}
+	if sections, _, _ := pm.odr.ChtIndexer().Sections(); cp.SectionIdx < sections {
+		return nil
+	}
+	if err := contract.VerifyCheckpoint(opts, cp, oracle.Signers, oracle.Threshold); err != nil {
+		return err
+	}
+	lc.AddTrustedCheckpoint(cp)
+	return nil
+}
+
+// localCheckpoint returns the latest checkpoint for which the server has
+// generated both the CHT and the bloom trie, or nil if there is none.
+func localCheckpoint(db ircdb.Database, chtIndexer, bloomTrieIndexer *core.ChainIndexer) *light.TrustedCheckpoint {
+	chtCount, _, _ := chtIndexer.Sections() // indexer still uses LES/1 4k section size
+	chtCount /= light.CHTFrequencyClient / light.CHTFrequencyServer
+
+	bloomTrieCount, _, _ := bloomTrieIndexer.Sections()
+	count := chtCount
+	if bloomTrieCount < count {
+		count = bloomTrieCount
+	}
+	if count == 0 {
+		return nil
+	}
+	index := count - 1
+	head := chtIndexer.SectionHead((index+1)*(light.CHTFrequencyClient/light.CHTFrequencyServer) - 1)
+	if head == (common.Hash{}) || head != bloomTrieIndexer.SectionHead(index) {
+		return nil
+	}
+	cp := &light.TrustedCheckpoint{
+		Name:          "local",
+		SectionIdx:    index,
+		SectionHead:   head,
+		ChtRoot:       light.GetChtV2Root(db, index, head),
+		BloomTrieRoot: light.GetBloomTrieRoot(db, index, head),
+	}
+	if cp.ChtRoot == (common.Hash{}) || cp.BloomTrieRoot == (common.Hash{}) {
+		return nil
+	}
+	return cp
+}
+
+// checkpointRegistrar votes on the checkpoints generated by a light server in
+// the checkpoint oracle.
+type checkpointRegistrar struct {
+	oracle  *checkpointoracle.CheckpointOracle
+	account accounts.Account
+	wallet  accounts.Wallet
+	chainID *big.Int
+
+	db                           ircdb.Database
+	chtIndexer, bloomTrieIndexer *core.ChainIndexer
+	quit                         chan struct{}
+}
+
+// loop periodically votes on the latest local checkpoint.
+func (r *checkpointRegistrar) loop() {
+	ticker := time.NewTicker(checkpointRegisterInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.register(); err != nil {
+			log.Warn("Failed to vote on checkpoint", "err", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// register votes on the latest local checkpoint if it's newer than the one
+// accepted by the oracle and the server hasn't voted on it yet.
+func (r *checkpointRegistrar) register() error {
+	cp := localCheckpoint(r.db, r.chtIndexer, r.bloomTrieIndexer)
+	if cp == nil {
+		return nil
+	}
+	latest, err := r.oracle.LatestCheckpoint(nil)
+	switch {
+	case err == checkpointoracle.ErrNoCheckpoint:
+	case err != nil:
+		return err
+	case latest.SectionIdx >= cp.SectionIdx:
+		return nil
+	}
+	if sig, err := r.oracle.Vote(nil, cp.SectionIdx, r.account.Address); err != nil || sig != nil {
+		return err
+	}
+	hash := cp.SignHash(r.oracle.Address())
+	sig, err := r.wallet.SignHash(r.account, hash[:])
+	if err != nil {
+		return err
+	}
+	opts := &bind.TransactOpts{
+		From: r.account.Address,
+		Signer: func(signer types.Signer, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			return r.wallet.SignTx(r.account, tx, r.chainID)
+		},
+	}
+	tx, err := r.oracle.RegisterCheckpoint(opts, cp, sig)
+	if err != nil {
+		return err
+	}
+	log.Info("Voted on checkpoint", "section", cp.SectionIdx, "head", cp.SectionHead, "hash", hash, "tx", tx.Hash())
+	return nil
+}
@@ -0,0 +1,329 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/irchain/go-irchain/common/mclock"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/les/flowcontrol"
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+var (
+	errNoCapacity       = errors.New("no client capacity available")
+	errCapacityTooLow   = errors.New("capacity below free client capacity")
+	errCapacityTooHigh  = errors.New("assigned capacities exceed total server capacity")
+	errAlreadyConnected = errors.New("client already connected")
+)
+
+var clientCapacityKey = []byte("_lesClientCapacities")
+
+// ClientInfo describes the capacity of a light client.
+type ClientInfo struct {
+	Connected bool   `json:"connected"`
+	Priority  bool   `json:"priority"` // Whether the client has guaranteed capacity
+	Capacity  uint64 `json:"capacity"` // Recharge rate of the client's flow control buffer
+	BufLimit  uint64 `json:"bufLimit"` // Size of the client's flow control buffer
+}
+
+// poolClient is a connected client.
+type poolClient struct {
+	id        discover.NodeID
+	capacity  uint64
+	priority  bool
+	connected mclock.AbsTime
+	kick      func()
+}
+
+// clientPool assigns serving capacity to light clients. Priority clients have
+// a guaranteed capacity assigned by the operator, the remaining capacity is
+// shared by free clients which all get the same minimal capacity. Free clients
+// are kicked when priority clients need their capacity, longest connected
+// first.
+//
+// Capacity is measured as the recharge rate of the client's flow control
+// buffer, the buffer limit scales proportionally.
+type clientPool struct {
+	db       ircdb.Database
+	freeCap  uint64 // Capacity of free clients
+	totalCap uint64 // Total capacity of the server
+	bufRatio uint64 // Ratio of buffer limit to capacity
+
+	lock        sync.Mutex
+	priority    map[discover.NodeID]uint64 // Assigned capacities of priority clients
+	connected   map[discover.NodeID]*poolClient
+	priorityCap uint64 // Capacity used by connected priority clients
+	freeCount   int    // Number of connected free clients
+}
+
+// newClientPool creates a client pool for the given free client parameters and
+// number of free clients the total capacity is sufficient for. The priority
+// assignments are loaded from the database.
+func newClientPool(db ircdb.Database, freeParams *flowcontrol.ServerParams, maxFreeClients int) *clientPool {
+	pool := &clientPool{
+		db:        db,
+		freeCap:   freeParams.MinRecharge,
+		totalCap:  freeParams.MinRecharge * uint64(maxFreeClients),
+		bufRatio:  freeParams.BufLimit / freeParams.MinRecharge,
+		priority:  make(map[discover.NodeID]uint64),
+		connected: make(map[discover.NodeID]*poolClient),
+	}
+	pool.loadPriority()
+	return pool
+}
+
+// params returns the flow control parameters for a capacity.
+func (pool *clientPool) params(capacity uint64) *flowcontrol.ServerParams {
+	return &flowcontrol.ServerParams{BufLimit: capacity * pool.bufRatio, MinRecharge: capacity}
+}
+
+// connect admits a client, returning its flow control parameters. Free clients
+// are kicked if a priority client needs their capacity. The kick function is
+// called if the client has to be disconnected later.
+func (pool *clientPool) connect(id discover.NodeID, kick func()) (*flowcontrol.ServerParams, error) {
+	pool.lock.Lock()
+	if pool.connected[id] != nil {
+		pool.lock.Unlock()
+		return nil, errAlreadyConnected
+	}
+	client := &poolClient{id: id, connected: mclock.Now(), kick: kick}
+	if capacity, ok := pool.priority[id]; ok {
+		client.capacity, client.priority = capacity, true
+	} else {
+		client.capacity = pool.freeCap
+	}
+	kicked, ok := pool.reserve(client)
+	if ok {
+		pool.add(client)
+	}
+	pool.lock.Unlock()
+
+	for _, c := range kicked {
+		log.Debug("Kicking free light client", "id", c.id)
+		c.kick()
+	}
+	if !ok {
+		return nil, errNoCapacity
+	}
+	return pool.params(client.capacity), nil
+}
+
+// reserve makes room for a client, removing the free clients to be kicked from
+// the pool. The caller must hold pool.lock.
+func (pool *clientPool) reserve(client *poolClient) ([]*poolClient, bool) {
+	var free uint64
+	if used := pool.usedCap(); used < pool.totalCap {
+		free = pool.totalCap - used
+	}
+	if client.capacity <= free {
+		return nil, true
+	}
+	if !client.priority || pool.priorityCap+client.capacity > pool.totalCap {
+		return nil, false
+	}
+	// Kick the longest connected free clients.
+	var candidates []*poolClient
+	for _, c := range pool.connected {
+		if !c.priority {
+			candidates = append(candidates, c)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].connected < candidates[j].connected
+	})
+	var kicked []*poolClient
+	for _, c := range candidates {
+		if client.capacity <= free {
+			break
+		}
+		pool.remove(c)
+		kicked = append(kicked, c)
+		free += c.capacity
+	}
+	return kicked, true
+}
+
+// usedCap returns the capacity used by all connected clients. The caller must
+// hold pool.lock.
+func (pool *clientPool) usedCap() uint64 {
+	return pool.priorityCap + uint64(pool.freeCount)*pool.freeCap
+}
+
+func (pool *clientPool) add(c *poolClient) {
+	pool.connected[c.id] = c
+	if c.priority {
+		pool.priorityCap += c.capacity
+	} else {
+		pool.freeCount++
+	}
+}
+
+func (pool *clientPool) remove(c *poolClient) {
+	delete(pool.connected, c.id)
+	if c.priority {
+		pool.priorityCap -= c.capacity
+	} else {
+		pool.freeCount--
+	}
+}
+
+// disconnect releases the capacity of a client.
+func (pool *clientPool) disconnect(id discover.NodeID) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if c := pool.connected[id]; c != nil {
+		pool.remove(c)
+	}
+}
+
+// setCapacity assigns a guaranteed capacity to a client, or turns it into a
+// free client if the capacity is zero. Connected clients are disconnected so
+// they reconnect with the new flow control parameters.
+func (pool *clientPool) setCapacity(id discover.NodeID, capacity uint64) error {
+	pool.lock.Lock()
+	if capacity != 0 {
+		if capacity < pool.freeCap {
+			pool.lock.Unlock()
+			return errCapacityTooLow
+		}
+		var assigned uint64
+		for other, c := range pool.priority {
+			if other != id {
+				assigned += c
+			}
+		}
+		if assigned+capacity > pool.totalCap {
+			pool.lock.Unlock()
+			return errCapacityTooHigh
+		}
+		pool.priority[id] = capacity
+	} else {
+		delete(pool.priority, id)
+	}
+	pool.storePriority()
+
+	client := pool.connected[id]
+	if client != nil {
+		if client.capacity == capacity || (capacity == 0 && !client.priority) {
+			client = nil // nothing changed
+		} else {
+			pool.remove(client)
+		}
+	}
+	pool.lock.Unlock()
+
+	if client != nil {
+		log.Debug("Reconnecting light client with new capacity", "id", id, "capacity", capacity)
+		client.kick()
+	}
+	return nil
+}
+
+// clientInfo returns the capacity of a client.
+func (pool *clientPool) clientInfo(id discover.NodeID) *ClientInfo {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	info := &ClientInfo{Capacity: pool.freeCap}
+	if capacity, ok := pool.priority[id]; ok {
+		info.Priority, info.Capacity = true, capacity
+	}
+	if c := pool.connected[id]; c != nil {
+		info.Connected, info.Priority, info.Capacity = true, c.priority, c.capacity
+	}
+	info.BufLimit = info.Capacity * pool.bufRatio
+	return info
+}
+
+// clients returns the IDs of all connected and all priority clients.
+func (pool *clientPool) clients() []discover.NodeID {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	var ids []discover.NodeID
+	for id := range pool.connected {
+		ids = append(ids, id)
+	}
+	for id := range pool.priority {
+		if pool.connected[id] == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+type clientCapacityRLP []struct {
+	ID       discover.NodeID
+	Capacity uint64
+}
+
+// loadPriority loads the priority assignments from the database. Assignments
+// not fitting the current capacity limits, e.g. after the server was restarted
+// with a lower total capacity, are dropped.
+func (pool *clientPool) loadPriority() {
+	if pool.db == nil {
+		return
+	}
+	data, err := pool.db.Get(clientCapacityKey)
+	if err != nil {
+		return
+	}
+	var list clientCapacityRLP
+	if err := rlp.DecodeBytes(data, &list); err != nil {
+		log.Error("Failed to decode light client capacities", "err", err)
+		return
+	}
+	var assigned uint64
+	for _, e := range list {
+		if e.Capacity < pool.freeCap || assigned+e.Capacity > pool.totalCap {
+			log.Warn("Dropping light client capacity beyond limits", "id", e.ID, "capacity", e.Capacity, "free", pool.freeCap, "total", pool.totalCap)
+			continue
+		}
+		pool.priority[e.ID] = e.Capacity
+		assigned += e.Capacity
+	}
+}
+
+// storePriority persists the priority assignments. The caller must hold
+// pool.lock.
+func (pool *clientPool) storePriority() {
+	if pool.db == nil {
+		return
+	}
+	list := make(clientCapacityRLP, 0, len(pool.priority))
+	for id, capacity := range pool.priority {
+		list = append(list, struct {
+			ID       discover.NodeID
+			Capacity uint64
+		}{id, capacity})
+	}
+	data, err := rlp.EncodeToBytes(list)
+	if err != nil {
+		log.Error("Failed to encode light client capacities", "err", err)
+		return
+	}
+	if err := pool.db.Put(clientCapacityKey, data); err != nil {
+		log.Error("Failed to store light client capacities", "err", err)
+	}
+}
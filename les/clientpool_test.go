@@ -0,0 +1,146 @@
+// Copyright 2016 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"testing"
+
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/les/flowcontrol"
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+func testPoolID(i byte) discover.NodeID {
+	var id discover.NodeID
+	id[0] = i
+	return id
+}
+
+func TestClientPoolFreeClients(t *testing.T) {
+	pool := newClientPool(nil, &flowcontrol.ServerParams{BufLimit: 1000, MinRecharge: 10}, 2)
+
+	for i := byte(0); i < 2; i++ {
+		params, err := pool.connect(testPoolID(i), func() {})
+		if err != nil {
+			t.Fatalf("client %d rejected: %v", i, err)
+		}
+		if params.MinRecharge != 10 || params.BufLimit != 1000 {
+			t.Fatalf("client %d: wrong params %+v", i, params)
+		}
+	}
+	if _, err := pool.connect(testPoolID(0), func() {}); err != errAlreadyConnected {
+		t.Fatalf("duplicate connect: got %v, want %v", err, errAlreadyConnected)
+	}
+	if _, err := pool.connect(testPoolID(2), func() {}); err != errNoCapacity {
+		t.Fatalf("over capacity: got %v, want %v", err, errNoCapacity)
+	}
+	pool.disconnect(testPoolID(0))
+	if _, err := pool.connect(testPoolID(2), func() {}); err != nil {
+		t.Fatalf("client rejected after disconnect: %v", err)
+	}
+}
+
+func TestClientPoolPriority(t *testing.T) {
+	pool := newClientPool(nil, &flowcontrol.ServerParams{BufLimit: 1000, MinRecharge: 10}, 3)
+
+	kicked := make(map[byte]bool)
+	for i := byte(0); i < 3; i++ {
+		i := i
+		if _, err := pool.connect(testPoolID(i), func() { kicked[i] = true }); err != nil {
+			t.Fatalf("client %d rejected: %v", i, err)
+		}
+	}
+	if err := pool.setCapacity(testPoolID(9), 20); err != nil {
+		t.Fatalf("setCapacity failed: %v", err)
+	}
+	params, err := pool.connect(testPoolID(9), func() {})
+	if err != nil {
+		t.Fatalf("priority client rejected: %v", err)
+	}
+	if params.MinRecharge != 20 || params.BufLimit != 2000 {
+		t.Fatalf("wrong priority params %+v", params)
+	}
+	// The two longest connected free clients should have been kicked.
+	if !kicked[0] || !kicked[1] || kicked[2] {
+		t.Fatalf("wrong clients kicked: %v", kicked)
+	}
+	info := pool.clientInfo(testPoolID(9))
+	if !info.Connected || !info.Priority || info.Capacity != 20 {
+		t.Fatalf("wrong client info %+v", info)
+	}
+	// Changing the capacity of a connected client disconnects it.
+	reconnect := false
+	pool.disconnect(testPoolID(9))
+	pool.connect(testPoolID(9), func() { reconnect = true })
+	if err := pool.setCapacity(testPoolID(9), 0); err != nil {
+		t.Fatalf("setCapacity failed: %v", err)
+	}
+	if !reconnect {
+		t.Fatal("client not disconnected after capacity change")
+	}
+	if info := pool.clientInfo(testPoolID(9)); info.Connected || info.Priority || info.Capacity != 10 {
+		t.Fatalf("wrong client info %+v", info)
+	}
+}
+
+func TestClientPoolSetCapacity(t *testing.T) {
+	db := ircdb.NewMemDatabase()
+	params := &flowcontrol.ServerParams{BufLimit: 1000, MinRecharge: 10}
+	pool := newClientPool(db, params, 4)
+
+	if err := pool.setCapacity(testPoolID(1), 5); err != errCapacityTooLow {
+		t.Fatalf("low capacity: got %v, want %v", err, errCapacityTooLow)
+	}
+	if err := pool.setCapacity(testPoolID(1), 30); err != nil {
+		t.Fatalf("setCapacity failed: %v", err)
+	}
+	if err := pool.setCapacity(testPoolID(2), 20); err != errCapacityTooHigh {
+		t.Fatalf("high capacity: got %v, want %v", err, errCapacityTooHigh)
+	}
+	// Reassigning the same client doesn't count its old capacity.
+	if err := pool.setCapacity(testPoolID(1), 40); err != nil {
+		t.Fatalf("setCapacity failed: %v", err)
+	}
+	// Assignments are persisted.
+	pool = newClientPool(db, params, 4)
+	if info := pool.clientInfo(testPoolID(1)); !info.Priority || info.Capacity != 40 {
+		t.Fatalf("capacity not persisted: %+v", info)
+	}
+}
+
+func TestClientPoolLoadBeyondLimits(t *testing.T) {
+	db := ircdb.NewMemDatabase()
+	params := &flowcontrol.ServerParams{BufLimit: 1000, MinRecharge: 10}
+	pool := newClientPool(db, params, 4)
+	if err := pool.setCapacity(testPoolID(1), 30); err != nil {
+		t.Fatalf("setCapacity failed: %v", err)
+	}
+	// Restarting with a lower total capacity drops the assignment instead of
+	// letting the used capacity exceed the total.
+	pool = newClientPool(db, params, 2)
+	if info := pool.clientInfo(testPoolID(1)); info.Priority {
+		t.Fatalf("capacity beyond total loaded: %+v", info)
+	}
+	for i := byte(0); i < 2; i++ {
+		if _, err := pool.connect(testPoolID(i+2), func() {}); err != nil {
+			t.Fatalf("client %d rejected: %v", i, err)
+		}
+	}
+	if _, err := pool.connect(testPoolID(1), func() {}); err != errNoCapacity {
+		t.Fatalf("over capacity: got %v, want %v", err, errNoCapacity)
+	}
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/irchain/go-irchain/core/state"
 	"github.com/irchain/go-irchain/core/types"
 	"github.com/irchain/go-irchain/event"
+	"github.com/irchain/go-irchain/irc"
 	"github.com/irchain/go-irchain/irc/downloader"
 	"github.com/irchain/go-irchain/ircdb"
 	"github.com/irchain/go-irchain/light"
@@ -110,9 +111,12 @@ type ProtocolManager struct {
 
 	downloader *downloader.Downloader
 	fetcher    *lightFetcher
+	ulc        *ulc // nil unless running in ultra-light client mode
 	peers      *peerSet
 	maxPeers   int
 
+	checkpointOracle *params.CheckpointOracleConfig // nil if light clients don't sync from oracle checkpoints
+
 	SubProtocols []p2p.Protocol
 
 	eventMux *event.TypeMux
@@ -129,7 +133,7 @@ type ProtocolManager struct {
 
 // NewProtocolManager returns a new irchain sub protocol manager. The IrChain sub protocol manages peers capable
 // with the irchain network.
-func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protocolVersions []uint, networkId uint64, mux *event.TypeMux, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb ircdb.Database, odr *LesOdr, txrelay *LesTxRelay, quitSync chan struct{}, wg *sync.WaitGroup) (*ProtocolManager, error) {
+func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protocolVersions []uint, networkId uint64, mux *event.TypeMux, engine consensus.Engine, peers *peerSet, blockchain BlockChain, txpool txPool, chainDb ircdb.Database, odr *LesOdr, txrelay *LesTxRelay, ulcConfig *irc.ULCConfig, quitSync chan struct{}, wg *sync.WaitGroup) (*ProtocolManager, error) {
 	// Create the protocol manager with the base fields
 	manager := &ProtocolManager{
 		lightSync:   lightSync,
@@ -207,8 +211,18 @@ func NewProtocolManager(chainConfig *params.ChainConfig, lightSync bool, protoco
 
 	if lightSync {
 		manager.downloader = downloader.New(downloader.LightSync, chainDb, manager.eventMux, nil, blockchain, removePeer)
-		manager.peers.notify((*downloaderPeerNotify)(manager))
-		manager.fetcher = newLightFetcher(manager)
+		if ulcConfig != nil {
+			// Ultra-light clients don't sync the header chain, only the heads agreed
+			// on by the trusted servers are fetched. The downloader is never fed any
+			// peers, it only reports the sync progress.
+			var err error
+			if manager.ulc, err = newULC(manager, ulcConfig); err != nil {
+				return nil, err
+			}
+		} else {
+			manager.peers.notify((*downloaderPeerNotify)(manager))
+			manager.fetcher = newLightFetcher(manager)
+		}
 	}
 
 	return manager, nil
@@ -222,7 +236,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.maxPeers = maxPeers
 
-	if pm.lightSync {
+	if pm.lightSync && pm.ulc == nil {
 		go pm.syncer()
 	} else {
 		go func() {
@@ -242,6 +256,9 @@ func (pm *ProtocolManager) Stop() {
 	pm.noMorePeers <- struct{}{}
 
 	close(pm.quitSync) // quits syncer, fetcher
+	if pm.ulc != nil {
+		pm.downloader.Terminate() // not run by the syncer in ultra-light mode
+	}
 
 	// Disconnect existing sessions.
 	// This also closes the gate for any new registrations on the peer set.
@@ -256,16 +273,32 @@ func (pm *ProtocolManager) Stop() {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, nv uint64, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, nv, p, newMeteredMsgWriter(rw))
+	if pm.server != nil {
+		rw = &statsMsgWriter{MsgReadWriter: rw, id: p.ID(), stats: pm.server.servingStats}
+	}
+	peer := newPeer(pv, nv, p, newMeteredMsgWriter(rw))
+	if pm.ulc != nil {
+		peer.trusted = pm.ulc.isTrusted(p.ID())
+	}
+	return peer
 }
 
 // handle is the callback invoked to manage the life cycle of a les peer. When
 // this function terminates, the peer is disconnected.
 func (pm *ProtocolManager) handle(p *peer) error {
-	// Ignore maxPeers if this is a trusted peer
-	if pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted {
+	// Ignore maxPeers if this is a trusted peer. Servers admit clients based
+	// on the available capacity instead.
+	if pm.server == nil && pm.peers.Len() >= pm.maxPeers && !p.Peer.Info().Network.Trusted && !p.trusted {
 		return p2p.DiscTooManyPeers
 	}
+	if pm.server != nil {
+		params, err := pm.server.clientPool.connect(p.ID(), func() { p.Peer.Disconnect(p2p.DiscTooManyPeers) })
+		if err != nil {
+			return p2p.DiscTooManyPeers
+		}
+		defer pm.server.clientPool.disconnect(p.ID())
+		p.fcParams = params
+	}
 
 	p.Log().Debug("Light IrChain peer connected", "name", p.Name())
 
@@ -303,6 +336,9 @@ func (pm *ProtocolManager) handle(p *peer) error {
 		if pm.fetcher != nil {
 			pm.fetcher.announce(p, head)
 		}
+		if pm.ulc != nil {
+			pm.ulc.announce(p, head)
+		}
 
 		if p.poolEntry != nil {
 			pm.serverPool.registered(p.poolEntry)
@@ -351,12 +387,13 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		bufValue, _ := p.fcClient.AcceptRequest()
 		cost := costs.baseCost + reqCnt*costs.reqCost
-		if cost > pm.server.defParams.BufLimit {
-			cost = pm.server.defParams.BufLimit
+		if cost > p.fcParams.BufLimit {
+			cost = p.fcParams.BufLimit
 		}
 		if cost > bufValue {
-			recharge := time.Duration((cost - bufValue) * 1000000 / pm.server.defParams.MinRecharge)
+			recharge := time.Duration((cost - bufValue) * 1000000 / p.fcParams.MinRecharge)
 			p.Log().Error("Request came too early", "recharge", common.PrettyDuration(recharge))
+			pm.server.servingStats.underrun(p.ID())
 			return true
 		}
 		return false
@@ -400,6 +437,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if pm.fetcher != nil {
 			pm.fetcher.announce(p, &req)
 		}
+		if pm.ulc != nil {
+			pm.ulc.announce(p, &req)
+		}
 
 	case GetBlockHeadersMsg:
 		p.Log().Trace("Received block header request")
@@ -489,7 +529,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + query.Amount*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, query.Amount, rcost)
+		pm.server.requestServed(p, msg.Code, query.Amount, rcost)
 		return p.SendBlockHeaders(req.ReqID, bv, headers)
 
 	case BlockHeadersMsg:
@@ -509,6 +549,12 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
 		if pm.fetcher != nil && pm.fetcher.requestedID(resp.ReqID) {
 			pm.fetcher.deliverHeaders(p, resp.ReqID, resp.Headers)
+		} else if pm.retriever != nil && pm.retriever.requested(resp.ReqID) {
+			deliverMsg = &Msg{
+				MsgType: MsgBlockHeaders,
+				ReqID:   resp.ReqID,
+				Obj:     resp.Headers,
+			}
 		} else {
 			err := pm.downloader.DeliverHeaders(p.id, resp.Headers)
 			if err != nil {
@@ -548,7 +594,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 		return p.SendBlockBodiesRLP(req.ReqID, bv, bodies)
 
 	case BlockBodiesMsg:
@@ -613,7 +659,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 		return p.SendCode(req.ReqID, bv, data)
 
 	case CodeMsg:
@@ -679,7 +725,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 		return p.SendReceiptsRLP(req.ReqID, bv, receipts)
 
 	case ReceiptsMsg:
@@ -753,7 +799,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 		return p.SendProofs(req.ReqID, bv, proofs)
 
 	case GetProofsV2Msg:
@@ -815,7 +861,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 		return p.SendProofsV2(req.ReqID, bv, nodes.NodeList())
 
 	case ProofsV1Msg:
@@ -902,7 +948,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 		return p.SendHeaderProofs(req.ReqID, bv, proofs)
 
 	case GetHelperTrieProofsMsg:
@@ -963,7 +1009,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 		return p.SendHelperTrieProofs(req.ReqID, bv, HelperTrieResps{Proofs: nodes.NodeList(), AuxData: auxData})
 
 	case HeaderProofsMsg:
@@ -1023,7 +1069,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		pm.txpool.AddRemotes(txs)
 
 		_, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 
 	case SendTxV2Msg:
 		if pm.txpool == nil {
@@ -1058,7 +1104,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 
 		return p.SendTxStatus(req.ReqID, bv, stats)
 
@@ -1079,7 +1125,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			return errResp(ErrRequestRejected, "")
 		}
 		bv, rcost := p.fcClient.RequestProcessed(costs.baseCost + uint64(reqCnt)*costs.reqCost)
-		pm.server.fcCostStats.update(msg.Code, uint64(reqCnt), rcost)
+		pm.server.requestServed(p, msg.Code, uint64(reqCnt), rcost)
 
 		return p.SendTxStatus(req.ReqID, bv, pm.txStatus(req.Hashes))
 
@@ -1098,6 +1144,13 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 
 		p.fcServer.GotReply(resp.ReqID, resp.BV)
+		if pm.retriever.requested(resp.ReqID) {
+			deliverMsg = &Msg{
+				MsgType: MsgTxStatus,
+				ReqID:   resp.ReqID,
+				Obj:     resp.Status,
+			}
+		}
 
 	default:
 		p.Log().Trace("Received unknown message", "code", msg.Code)
@@ -135,8 +135,9 @@ func testRCL() RequestCostList {
 
 // newTestProtocolManager creates a new protocol manager for testing purposes,
 // with the given number of blocks already known, and potential notification
-// channels for different events.
-func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *core.BlockGen), peers *peerSet, odr *LesOdr, db ircdb.Database) (*ProtocolManager, error) {
+// channels for different events. Light clients run in ultra-light mode if a ulc
+// config is given.
+func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *core.BlockGen), peers *peerSet, odr *LesOdr, db ircdb.Database, ulcConfig *irc.ULCConfig) (*ProtocolManager, error) {
 	var (
 		evmux  = new(event.TypeMux)
 		engine = irchash.NewFaker()
@@ -178,7 +179,7 @@ func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *cor
 	} else {
 		protocolVersions = ServerProtocolVersions
 	}
-	pm, err := NewProtocolManager(gspec.Config, lightSync, protocolVersions, NetworkId, evmux, engine, peers, chain, nil, db, odr, nil, make(chan struct{}), new(sync.WaitGroup))
+	pm, err := NewProtocolManager(gspec.Config, lightSync, protocolVersions, NetworkId, evmux, engine, peers, chain, nil, db, odr, nil, ulcConfig, make(chan struct{}), new(sync.WaitGroup))
 	if err != nil {
 		return nil, err
 	}
@@ -193,6 +194,8 @@ func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *cor
 
 		srv.fcManager = flowcontrol.NewClientManager(50, 10, 1000000000)
 		srv.fcCostStats = newCostStats(nil)
+		srv.clientPool = newClientPool(nil, srv.defParams, 1000)
+		srv.servingStats = newServingStatsTracker(nil)
 	}
 	pm.Start(1000)
 	return pm, nil
@@ -203,7 +206,7 @@ func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *cor
 // channels for different events. In case of an error, the constructor force-
 // fails the test.
 func newTestProtocolManagerMust(t *testing.T, lightSync bool, blocks int, generator func(int, *core.BlockGen), peers *peerSet, odr *LesOdr, db ircdb.Database) *ProtocolManager {
-	pm, err := newTestProtocolManager(lightSync, blocks, generator, peers, odr, db)
+	pm, err := newTestProtocolManager(lightSync, blocks, generator, peers, odr, db, nil)
 	if err != nil {
 		t.Fatalf("Failed to create protocol manager: %v", err)
 	}
@@ -42,6 +42,7 @@ var (
 	errUncleHashMismatch   = errors.New("uncle hash mismatch")
 	errReceiptHashMismatch = errors.New("receipt hash mismatch")
 	errDataHashMismatch    = errors.New("data hash mismatch")
+	errHeaderHashMismatch  = errors.New("header hash mismatch")
 	errCHTHashMismatch     = errors.New("cht hash mismatch")
 	errCHTNumberMismatch   = errors.New("cht number mismatch")
 	errUselessNodes        = errors.New("useless nodes in merkle proof nodeset")
@@ -64,6 +65,10 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*TrieRequest)(r)
 	case *light.CodeRequest:
 		return (*CodeRequest)(r)
+	case *light.HeaderRequest:
+		return (*HeaderRequest)(r)
+	case *light.TxStatusRequest:
+		return (*TxStatusRequest)(r)
 	case *light.ChtRequest:
 		return (*ChtRequest)(r)
 	case *light.BloomRequest:
@@ -332,6 +337,83 @@ type HelperTrieResps struct { // describes all responses, not just a single one
 	AuxData [][]byte
 }
 
+// HeaderRequest is the ODR request type for a single header by hash
+type HeaderRequest light.HeaderRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *HeaderRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetBlockHeadersMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *HeaderRequest) CanSend(peer *peer) bool {
+	return peer.HasBlock(r.Hash, r.Number)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *HeaderRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting header", "hash", r.Hash)
+	return peer.RequestHeadersByHash(reqID, r.GetCost(peer), r.Hash, 1, 0, false)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *HeaderRequest) Validate(db ircdb.Database, msg *Msg) error {
+	log.Debug("Validating header", "hash", r.Hash)
+
+	if msg.MsgType != MsgBlockHeaders {
+		return errInvalidMessageType
+	}
+	headers := msg.Obj.([]*types.Header)
+	if len(headers) != 1 {
+		return errInvalidEntryCount
+	}
+	header := headers[0]
+	if header.Hash() != r.Hash {
+		return errHeaderHashMismatch
+	}
+	r.Header = header
+	return nil
+}
+
+// TxStatusRequest is the ODR request type for transaction status
+type TxStatusRequest light.TxStatusRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *TxStatusRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetTxStatusMsg, len(r.Hashes))
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *TxStatusRequest) CanSend(peer *peer) bool {
+	return peer.version >= lpv2
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *TxStatusRequest) Request(reqID uint64, peer *peer) error {
+	return peer.RequestTxStatus(reqID, r.GetCost(peer), r.Hashes)
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *TxStatusRequest) Validate(db ircdb.Database, msg *Msg) error {
+	log.Debug("Validating transaction status", "count", len(r.Hashes))
+
+	if msg.MsgType != MsgTxStatus {
+		return errInvalidMessageType
+	}
+	status := msg.Obj.([]light.TxStatus)
+	if len(status) != len(r.Hashes) {
+		return errInvalidEntryCount
+	}
+	r.Status = status
+	return nil
+}
+
 // legacy LES/1
 type ChtReq struct {
 	ChtNum, BlockNum uint64
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"math/big"
+	"reflect"
 	"testing"
 	"time"
 
@@ -218,3 +219,73 @@ func testOdr(t *testing.T, protocol int, expFail uint64, fn odrTestFn) {
 	time.Sleep(time.Millisecond * 10) // ensure that all peerSetNotify callbacks are executed
 	test(5)
 }
+
+// newTestOdrServer connects a light client ODR backend to a LES/2 server that
+// is assumed to have every requested block.
+func newTestOdrServer(t *testing.T) (*ProtocolManager, *LesOdr) {
+	peers := newPeerSet()
+	dist := newRequestDistributor(peers, make(chan struct{}))
+	rm := newRetrieveManager(peers, dist, nil)
+	db := ircdb.NewMemDatabase()
+	ldb := ircdb.NewMemDatabase()
+	odr := NewLesOdr(ldb, light.NewChtIndexer(db, true), light.NewBloomTrieIndexer(db, true), irc.NewBloomIndexer(db, light.BloomTrieFrequency), rm)
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	lpm := newTestProtocolManagerMust(t, true, 0, nil, peers, odr, ldb)
+	_, err1, lpeer, err2 := newTestPeerPair("peer", 2, pm, lpm)
+	select {
+	case <-time.After(time.Millisecond * 100):
+	case err := <-err1:
+		t.Fatalf("peer 1 handshake error: %v", err)
+	case err := <-err2:
+		t.Fatalf("peer 1 handshake error: %v", err)
+	}
+	lpeer.lock.Lock()
+	lpeer.hasBlock = func(common.Hash, uint64) bool { return true }
+	lpeer.lock.Unlock()
+	return pm, odr
+}
+
+func TestOdrGetUntrustedHeaderLes2(t *testing.T) {
+	pm, odr := newTestOdrServer(t)
+
+	head := pm.blockchain.CurrentHeader()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	header, err := light.GetUntrustedHeader(ctx, odr, head.Hash(), head.Number.Uint64())
+	if err != nil {
+		t.Fatalf("failed to retrieve header: %v", err)
+	}
+	if header.Hash() != head.Hash() {
+		t.Fatalf("header mismatch: have %x, want %x", header.Hash(), head.Hash())
+	}
+	// Unknown headers can't be retrieved.
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := light.GetUntrustedHeader(ctx, odr, common.Hash{1}, 1); err == nil {
+		t.Fatal("retrieved unknown header")
+	}
+}
+
+func TestOdrTxStatusLes2(t *testing.T) {
+	pm, odr := newTestOdrServer(t)
+	config := core.DefaultTxPoolConfig
+	config.Journal = ""
+	pm.txpool = core.NewTxPool(config, params.TestChainConfig, pm.blockchain.(*core.BlockChain))
+
+	block := pm.blockchain.(*core.BlockChain).GetBlockByNumber(1)
+	tx := block.Transactions()[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	status, err := light.GetTransactionStatus(ctx, odr, []common.Hash{tx.Hash(), {1}})
+	if err != nil {
+		t.Fatalf("failed to retrieve transaction status: %v", err)
+	}
+	want := []light.TxStatus{
+		{Status: core.TxStatusIncluded, Lookup: &rawdb.TxLookupEntry{BlockHash: block.Hash(), BlockIndex: 1, Index: 0}},
+		{Status: core.TxStatusUnknown},
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Fatalf("status mismatch: have %+v, want %+v", status, want)
+	}
+}
@@ -27,10 +27,9 @@ import (
 	"math/big"
 
 	"github.com/irchain/go-irchain/common"
-	"github.com/irchain/go-irchain/core"
-	"github.com/irchain/go-irchain/core/rawdb"
 	"github.com/irchain/go-irchain/crypto"
 	"github.com/irchain/go-irchain/crypto/secp256k1"
+	"github.com/irchain/go-irchain/light"
 	"github.com/irchain/go-irchain/rlp"
 )
 
@@ -222,8 +221,6 @@ type CodeData []struct {
 
 type proofsData [][]rlp.RawValue
 
-type txStatus struct {
-	Status core.TxStatus
-	Lookup *rawdb.TxLookupEntry `rlp:"nil"`
-	Error  string
-}
+// txStatus is the network encoding of a transaction status, shared with the
+// light client's ODR requests.
+type txStatus = light.TxStatus
@@ -21,9 +21,13 @@ import (
 	"crypto/ecdsa"
 	"encoding/binary"
 	"math"
+	"math/big"
 	"sync"
 
+	"github.com/irchain/go-irchain/accounts"
+	"github.com/irchain/go-irchain/accounts/abi/bind"
 	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/contracts/checkpointoracle"
 	"github.com/irchain/go-irchain/core"
 	"github.com/irchain/go-irchain/core/rawdb"
 	"github.com/irchain/go-irchain/core/types"
@@ -35,14 +39,19 @@ import (
 	"github.com/irchain/go-irchain/p2p"
 	"github.com/irchain/go-irchain/p2p/discv5"
 	"github.com/irchain/go-irchain/rlp"
+	"github.com/irchain/go-irchain/rpc"
 )
 
 type LesServer struct {
 	config          *irc.Config
+	accountManager  *accounts.Manager
+	chainID         *big.Int
 	protocolManager *ProtocolManager
 	fcManager       *flowcontrol.ClientManager // nil if our node is client only
 	fcCostStats     *requestCostStats
 	defParams       *flowcontrol.ServerParams
+	clientPool      *clientPool
+	servingStats    *servingStatsTracker
 	lesTopics       []discv5.Topic
 	privateKey      *ecdsa.PrivateKey
 	quitSync        chan struct{}
@@ -52,7 +61,7 @@ type LesServer struct {
 
 func NewLesServer(irc *irc.IrChain, config *irc.Config) (*LesServer, error) {
 	quitSync := make(chan struct{})
-	pm, err := NewProtocolManager(irc.BlockChain().Config(), false, ServerProtocolVersions, config.NetworkId, irc.EventMux(), irc.Engine(), newPeerSet(), irc.BlockChain(), irc.TxPool(), irc.ChainDb(), nil, nil, quitSync, new(sync.WaitGroup))
+	pm, err := NewProtocolManager(irc.BlockChain().Config(), false, ServerProtocolVersions, config.NetworkId, irc.EventMux(), irc.Engine(), newPeerSet(), irc.BlockChain(), irc.TxPool(), irc.ChainDb(), nil, nil, nil, quitSync, new(sync.WaitGroup))
 	if err != nil {
 		return nil, err
 	}
@@ -64,6 +73,8 @@ func NewLesServer(irc *irc.IrChain, config *irc.Config) (*LesServer, error) {
 
 	srv := &LesServer{
 		config:           config,
+		accountManager:   irc.AccountManager(),
+		chainID:          irc.BlockChain().Config().ChainID,
 		protocolManager:  pm,
 		quitSync:         quitSync,
 		lesTopics:        lesTopics,
@@ -100,9 +111,22 @@ func NewLesServer(irc *irc.IrChain, config *irc.Config) (*LesServer, error) {
 	}
 	srv.fcManager = flowcontrol.NewClientManager(uint64(config.LightServ), 10, 1000000000)
 	srv.fcCostStats = newCostStats(irc.ChainDb())
+	srv.clientPool = newClientPool(irc.ChainDb(), srv.defParams, config.LightPeers)
+	srv.servingStats = newServingStatsTracker(irc.ChainDb())
 	return srv, nil
 }
 
+// APIs returns the LES server specific RPC APIs.
+func (s *LesServer) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "les",
+			Version:   "1.0",
+			Service:   NewPrivateLightServerAPI(s),
+		},
+	}
+}
+
 func (s *LesServer) Protocols() []p2p.Protocol {
 	return s.protocolManager.SubProtocols
 }
@@ -123,9 +147,41 @@ func (s *LesServer) Start(srvr *p2p.Server) {
 		}
 	}
 	s.privateKey = srvr.PrivateKey
+	go s.servingStats.loop(s.quitSync)
 	s.protocolManager.blockLoop()
 }
 
+// SetContractBackend sets the backend used to vote on checkpoints in the
+// checkpoint oracle. Voting only starts if both the oracle and the signer
+// account are configured.
+func (s *LesServer) SetContractBackend(backend bind.ContractBackend) {
+	if s.config.CheckpointOracle == nil || s.config.CheckpointSigner == (common.Address{}) {
+		return
+	}
+	account := accounts.Account{Address: s.config.CheckpointSigner}
+	wallet, err := s.accountManager.Find(account)
+	if err != nil {
+		log.Error("Checkpoint signer account unavailable", "address", account.Address, "err", err)
+		return
+	}
+	oracle, err := checkpointoracle.NewCheckpointOracle(s.config.CheckpointOracle.Address, backend)
+	if err != nil {
+		log.Error("Failed to bind checkpoint oracle", "err", err)
+		return
+	}
+	registrar := &checkpointRegistrar{
+		oracle:           oracle,
+		account:          account,
+		wallet:           wallet,
+		chainID:          s.chainID,
+		db:               s.protocolManager.chainDb,
+		chtIndexer:       s.chtIndexer,
+		bloomTrieIndexer: s.bloomTrieIndexer,
+		quit:             s.quitSync,
+	}
+	go registrar.loop()
+}
+
 func (s *LesServer) SetBloomBitsIndexer(bloomIndexer *core.ChainIndexer) {
 	bloomIndexer.AddChildIndexer(s.bloomTrieIndexer)
 }
@@ -135,6 +191,7 @@ func (s *LesServer) Stop() {
 	s.chtIndexer.Close()
 	// bloom trie indexer is closed by parent bloombits indexer
 	s.fcCostStats.store()
+	s.servingStats.store()
 	s.fcManager.Stop()
 	go func() {
 		<-s.protocolManager.noMorePeers
@@ -142,6 +199,13 @@ func (s *LesServer) Stop() {
 	s.protocolManager.Stop()
 }
 
+// requestServed accounts for a processed client request in the cost and serving
+// statistics.
+func (s *LesServer) requestServed(p *peer, code, reqCnt, cost uint64) {
+	s.fcCostStats.update(code, reqCnt, cost)
+	s.servingStats.request(p.ID(), code, reqCnt, cost)
+}
+
 type requestCosts struct {
 	baseCost, reqCost uint64
 }
@@ -0,0 +1,299 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/metrics"
+	"github.com/irchain/go-irchain/p2p"
+	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+var servingStatsKey = []byte("_lesServingStats")
+
+const (
+	servingStatsClients         = 1000             // Number of most recently served clients to keep statistics of
+	servingStatsPersistInterval = 10 * time.Minute // Time between two writes of the totals to the database
+)
+
+// reqNames are the names request types are reported under.
+var reqNames = map[uint64]string{
+	GetBlockHeadersMsg:     "GetBlockHeaders",
+	GetBlockBodiesMsg:      "GetBlockBodies",
+	GetCodeMsg:             "GetCode",
+	GetReceiptsMsg:         "GetReceipts",
+	GetProofsV1Msg:         "GetProofsV1",
+	SendTxMsg:              "SendTx",
+	SendTxV2Msg:            "SendTxV2",
+	GetTxStatusMsg:         "GetTxStatus",
+	GetHeaderProofsMsg:     "GetHeaderProofs",
+	GetProofsV2Msg:         "GetProofsV2",
+	GetHelperTrieProofsMsg: "GetHelperTrieProofs",
+}
+
+var (
+	servedCostMeter     = metrics.NewRegisteredMeter("les/server/cost", nil)
+	servedTrafficMeter  = metrics.NewRegisteredMeter("les/server/traffic", nil)
+	bufferUnderrunMeter = metrics.NewRegisteredMeter("les/server/underruns", nil)
+
+	servedRequestMeters = make(map[uint64]metrics.Meter)
+)
+
+func init() {
+	for code, name := range reqNames {
+		servedRequestMeters[code] = metrics.NewRegisteredMeter("les/server/requests/"+name, nil)
+	}
+}
+
+// RequestStats contains the serving statistics of a request type.
+type RequestStats struct {
+	Count uint64 `json:"count"` // Number of requests served
+	Items uint64 `json:"items"` // Number of items (headers, proofs, ...) requested
+	Cost  uint64 `json:"cost"`  // Flow control cost units charged
+}
+
+// ServingStats contains the statistics of the requests served by a light server,
+// either in total or to a single client.
+type ServingStats struct {
+	Requests  map[string]*RequestStats `json:"requests"`
+	Bytes     uint64                   `json:"bytes"`     // Bytes sent
+	Underruns uint64                   `json:"underruns"` // Requests rejected due to an exhausted flow control buffer
+}
+
+// servingStats is the internal representation of ServingStats, with requests
+// indexed by message code.
+type servingStats struct {
+	requests         map[uint64]*RequestStats
+	bytes, underruns uint64
+}
+
+// servingStatsRLP is the database encoding of servingStats.
+type servingStatsRLP struct {
+	Requests []struct {
+		Code  uint64
+		Stats RequestStats
+	}
+	Bytes, Underruns uint64
+}
+
+func newServingStats() *servingStats {
+	return &servingStats{requests: make(map[uint64]*RequestStats)}
+}
+
+func (s *servingStats) request(code, items, cost uint64) {
+	stats := s.requests[code]
+	if stats == nil {
+		stats = new(RequestStats)
+		s.requests[code] = stats
+	}
+	stats.Count++
+	stats.Items += items
+	stats.Cost += cost
+}
+
+// export converts the statistics into their API representation.
+func (s *servingStats) export() *ServingStats {
+	stats := &ServingStats{
+		Requests:  make(map[string]*RequestStats),
+		Bytes:     s.bytes,
+		Underruns: s.underruns,
+	}
+	for code, req := range s.requests {
+		r := *req
+		stats.Requests[reqNames[code]] = &r
+	}
+	return stats
+}
+
+// servingStatsTracker records the requests served to light clients. Totals are
+// persisted across restarts, client statistics are only kept in memory for the
+// most recently served clients.
+type servingStatsTracker struct {
+	db ircdb.Database
+
+	lock    sync.Mutex
+	total   *servingStats
+	clients *lru.Cache // discover.NodeID -> *servingStats
+}
+
+// newServingStatsTracker creates a tracker, loading the totals from the database.
+func newServingStatsTracker(db ircdb.Database) *servingStatsTracker {
+	clients, _ := lru.New(servingStatsClients)
+	t := &servingStatsTracker{
+		db:      db,
+		total:   newServingStats(),
+		clients: clients,
+	}
+	if db == nil {
+		return t
+	}
+	data, err := db.Get(servingStatsKey)
+	if err != nil {
+		return t
+	}
+	var enc servingStatsRLP
+	if err := rlp.DecodeBytes(data, &enc); err != nil {
+		log.Error("Failed to decode light serving statistics", "err", err)
+		return t
+	}
+	for _, req := range enc.Requests {
+		stats := req.Stats
+		t.total.requests[req.Code] = &stats
+	}
+	t.total.bytes, t.total.underruns = enc.Bytes, enc.Underruns
+	return t
+}
+
+// client returns the statistics of a client, creating them if needed. The
+// caller must hold t.lock.
+func (t *servingStatsTracker) client(id discover.NodeID) *servingStats {
+	if stats, ok := t.clients.Get(id); ok {
+		return stats.(*servingStats)
+	}
+	stats := newServingStats()
+	t.clients.Add(id, stats)
+	return stats
+}
+
+// request records a served request and the cost charged for it.
+func (t *servingStatsTracker) request(id discover.NodeID, code, items, cost uint64) {
+	if meter := servedRequestMeters[code]; meter != nil {
+		meter.Mark(1)
+	}
+	servedCostMeter.Mark(int64(cost))
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.total.request(code, items, cost)
+	t.client(id).request(code, items, cost)
+}
+
+// underrun records a request rejected because the client's buffer ran out.
+func (t *servingStatsTracker) underrun(id discover.NodeID) {
+	bufferUnderrunMeter.Mark(1)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.total.underruns++
+	t.client(id).underruns++
+}
+
+// sent records the bytes sent to a client.
+func (t *servingStatsTracker) sent(id discover.NodeID, bytes uint64) {
+	servedTrafficMeter.Mark(int64(bytes))
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.total.bytes += bytes
+	t.client(id).bytes += bytes
+}
+
+// totals returns the statistics of all requests served.
+func (t *servingStatsTracker) totals() *ServingStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.total.export()
+}
+
+// clientStats returns the statistics of the given client, or nil if it hasn't
+// been served recently.
+func (t *servingStatsTracker) clientStats(id discover.NodeID) *ServingStats {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if stats, ok := t.clients.Peek(id); ok {
+		return stats.(*servingStats).export()
+	}
+	return nil
+}
+
+// clientIDs returns the recently served clients.
+func (t *servingStatsTracker) clientIDs() []discover.NodeID {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	keys := t.clients.Keys()
+	ids := make([]discover.NodeID, len(keys))
+	for i, key := range keys {
+		ids[i] = key.(discover.NodeID)
+	}
+	return ids
+}
+
+// loop periodically persists the total statistics until quit is closed, so
+// they survive an unclean shutdown.
+func (t *servingStatsTracker) loop(quit chan struct{}) {
+	ticker := time.NewTicker(servingStatsPersistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.store()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// store persists the total statistics.
+func (t *servingStatsTracker) store() {
+	if t.db == nil {
+		return
+	}
+	t.lock.Lock()
+	var enc servingStatsRLP
+	for code, stats := range t.total.requests {
+		enc.Requests = append(enc.Requests, struct {
+			Code  uint64
+			Stats RequestStats
+		}{code, *stats})
+	}
+	enc.Bytes, enc.Underruns = t.total.bytes, t.total.underruns
+	t.lock.Unlock()
+
+	data, err := rlp.EncodeToBytes(&enc)
+	if err != nil {
+		log.Error("Failed to encode light serving statistics", "err", err)
+		return
+	}
+	if err := t.db.Put(servingStatsKey, data); err != nil {
+		log.Error("Failed to store light serving statistics", "err", err)
+	}
+}
+
+// statsMsgWriter wraps the message stream of a client, counting the bytes sent.
+type statsMsgWriter struct {
+	p2p.MsgReadWriter
+	id    discover.NodeID
+	stats *servingStatsTracker
+}
+
+func (w *statsMsgWriter) WriteMsg(msg p2p.Msg) error {
+	w.stats.sent(w.id, uint64(msg.Size))
+	return w.MsgReadWriter.WriteMsg(msg)
+}
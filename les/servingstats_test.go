@@ -0,0 +1,117 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+func TestServingStatsPersistence(t *testing.T) {
+	db := ircdb.NewMemDatabase()
+	stats := newServingStatsTracker(db)
+
+	id1, id2 := discover.NodeID{1}, discover.NodeID{2}
+	stats.request(id1, GetBlockHeadersMsg, 10, 100)
+	stats.request(id1, GetBlockHeadersMsg, 5, 50)
+	stats.request(id2, GetCodeMsg, 1, 20)
+	stats.underrun(id2)
+	stats.sent(id1, 1000)
+	stats.sent(id2, 200)
+
+	want := &ServingStats{
+		Requests: map[string]*RequestStats{
+			"GetBlockHeaders": {Count: 2, Items: 15, Cost: 150},
+			"GetCode":         {Count: 1, Items: 1, Cost: 20},
+		},
+		Bytes:     1200,
+		Underruns: 1,
+	}
+	if have := stats.totals(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("totals mismatch: have %+v, want %+v", have, want)
+	}
+	wantClient := &ServingStats{
+		Requests:  map[string]*RequestStats{"GetCode": {Count: 1, Items: 1, Cost: 20}},
+		Bytes:     200,
+		Underruns: 1,
+	}
+	if have := stats.clientStats(id2); !reflect.DeepEqual(have, wantClient) {
+		t.Fatalf("client stats mismatch: have %+v, want %+v", have, wantClient)
+	}
+	if have := stats.clientStats(discover.NodeID{3}); have != nil {
+		t.Fatalf("stats for unknown client: %+v", have)
+	}
+	// Totals survive a restart, client statistics don't
+	stats.store()
+	stats = newServingStatsTracker(db)
+	if have := stats.totals(); !reflect.DeepEqual(have, want) {
+		t.Fatalf("totals mismatch after restart: have %+v, want %+v", have, want)
+	}
+	if ids := stats.clientIDs(); len(ids) != 0 {
+		t.Fatalf("client stats persisted: %v", ids)
+	}
+}
+
+// Tests that only the statistics of the most recently served clients are kept,
+// while the totals cover all of them.
+func TestServingStatsClientLimit(t *testing.T) {
+	stats := newServingStatsTracker(nil)
+	for i := 0; i < servingStatsClients+10; i++ {
+		var id discover.NodeID
+		binary.BigEndian.PutUint64(id[:], uint64(i))
+		stats.request(id, GetCodeMsg, 1, 1)
+	}
+	if ids := stats.clientIDs(); len(ids) != servingStatsClients {
+		t.Fatalf("tracked client count mismatch: have %d, want %d", len(ids), servingStatsClients)
+	}
+	if have := stats.clientStats(discover.NodeID{}); have != nil {
+		t.Fatalf("stats of least recently served client kept: %+v", have)
+	}
+	if have := stats.totals().Requests["GetCode"]; have.Count != servingStatsClients+10 {
+		t.Fatalf("total request count mismatch: have %d, want %d", have.Count, servingStatsClients+10)
+	}
+}
+
+func TestServingStatsRequests(t *testing.T) {
+	pm := newTestProtocolManagerMust(t, false, 4, nil, nil, nil, ircdb.NewMemDatabase())
+	peer, _ := newTestPeer(t, "peer", 2, pm, true)
+	defer peer.close()
+
+	query := &getBlockHeadersData{Origin: hashOrNumber{Number: 1}, Amount: 2}
+	cost := peer.GetRequestCost(GetBlockHeadersMsg, int(query.Amount))
+	sendRequest(peer.app, GetBlockHeadersMsg, 1, cost, query)
+	headers := []*types.Header{pm.blockchain.GetHeaderByNumber(1), pm.blockchain.GetHeaderByNumber(2)}
+	if err := expectResponse(peer.app, BlockHeadersMsg, 1, testBufLimit, headers); err != nil {
+		t.Fatalf("headers mismatch: %v", err)
+	}
+	stats := pm.server.servingStats.clientStats(peer.ID())
+	if stats == nil {
+		t.Fatal("no statistics recorded for client")
+	}
+	req := stats.Requests["GetBlockHeaders"]
+	if req == nil || req.Count != 1 || req.Items != 2 {
+		t.Fatalf("request statistics mismatch: %+v", req)
+	}
+	if stats.Bytes == 0 {
+		t.Fatal("bytes sent not recorded")
+	}
+}
@@ -72,6 +72,14 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		return
 	}
 
+	// Fetch the latest checkpoint from the oracle, so syncing can start from it
+	if pm.checkpointOracle != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), checkpointSyncTimeout)
+		if err := pm.syncCheckpoint(ctx, peer); err != nil {
+			peer.Log().Debug("Failed to sync oracle checkpoint", "err", err)
+		}
+		cancel()
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 	pm.blockchain.(*light.LightChain).SyncCht(ctx)
@@ -0,0 +1,218 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/irc"
+	"github.com/irchain/go-irchain/light"
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+var (
+	errNoTrustedServers       = errors.New("no trusted servers configured")
+	errInvalidTrustedFraction = errors.New("trusted fraction must be between 1 and 100")
+)
+
+const (
+	ulcHeadTimeout  = 10 * time.Second // time allowed to retrieve a head the trusted servers agreed on
+	ulcMaxAncestors = 64               // maximum number of missing ancestors retrieved to link a head to the local chain
+)
+
+// ulc implements the ultra-light client mode. Instead of downloading and
+// validating the header chain, the client accepts a new head once enough of its
+// trusted servers have announced it with a valid signature, and only retrieves
+// that single header to serve ODR requests against.
+type ulc struct {
+	pm         *ProtocolManager
+	chain      *light.LightChain
+	trusted    map[discover.NodeID]struct{}
+	servers    []*discover.Node
+	minTrusted int // number of trusted servers that need to agree on a head
+
+	lock     sync.Mutex
+	heads    map[*peer]*announceData  // latest signed announcement of each trusted server
+	fetching map[common.Hash]struct{} // agreed heads currently being retrieved
+}
+
+// newULC creates the ultra-light client head tracker and registers it in the
+// peer set of the protocol manager.
+func newULC(pm *ProtocolManager, config *irc.ULCConfig) (*ulc, error) {
+	if len(config.TrustedServers) == 0 {
+		return nil, errNoTrustedServers
+	}
+	if config.MinTrustedFraction <= 0 || config.MinTrustedFraction > 100 {
+		return nil, errInvalidTrustedFraction
+	}
+	u := &ulc{
+		pm:       pm,
+		chain:    pm.blockchain.(*light.LightChain),
+		trusted:  make(map[discover.NodeID]struct{}),
+		heads:    make(map[*peer]*announceData),
+		fetching: make(map[common.Hash]struct{}),
+	}
+	for _, url := range config.TrustedServers {
+		node, err := discover.ParseNode(url)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted server %q: %v", url, err)
+		}
+		if _, ok := u.trusted[node.ID]; ok {
+			continue
+		}
+		u.trusted[node.ID] = struct{}{}
+		u.servers = append(u.servers, node)
+	}
+	u.minTrusted = (len(u.servers)*config.MinTrustedFraction + 99) / 100
+	pm.peers.notify(u)
+
+	log.Info("Ultra-light client mode enabled", "servers", len(u.servers), "quorum", u.minTrusted)
+	return u, nil
+}
+
+// isTrusted returns whether the given node is one of the trusted servers.
+func (u *ulc) isTrusted(id discover.NodeID) bool {
+	_, ok := u.trusted[id]
+	return ok
+}
+
+// registerPeer implements peerSetNotify
+func (u *ulc) registerPeer(p *peer) {
+	p.lock.Lock()
+	p.hasBlock = func(hash common.Hash, number uint64) bool {
+		return u.peerHasBlock(p, hash, number)
+	}
+	p.lock.Unlock()
+}
+
+// unregisterPeer implements peerSetNotify
+func (u *ulc) unregisterPeer(p *peer) {
+	p.lock.Lock()
+	p.hasBlock = nil
+	p.lock.Unlock()
+
+	u.lock.Lock()
+	delete(u.heads, p)
+	u.lock.Unlock()
+}
+
+// peerHasBlock returns true if the given block is not newer than the last head
+// announced by the peer. The ancestry of heads is unknown in ultra-light mode,
+// so false positives for older blocks are accepted.
+func (u *ulc) peerHasBlock(p *peer, hash common.Hash, number uint64) bool {
+	head := p.headBlockInfo()
+	if number == head.Number {
+		return hash == head.Hash
+	}
+	return number < head.Number
+}
+
+// announce processes a new head announcement. Announcements of trusted servers
+// are expected to have been signature checked already. Once enough trusted
+// servers agree on a head with a higher total difficulty than the local one,
+// its header is retrieved and set as the new chain head.
+func (u *ulc) announce(p *peer, head *announceData) {
+	p.Log().Debug("Received new announcement", "number", head.Number, "hash", head.Hash, "trusted", p.trusted)
+
+	p.lock.Lock()
+	p.headInfo = head
+	p.lock.Unlock()
+
+	if !p.trusted {
+		return
+	}
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.heads[p] = head
+	agreed := 0
+	for _, h := range u.heads {
+		if h.Hash == head.Hash && h.Number == head.Number && h.Td.Cmp(head.Td) == 0 {
+			agreed++
+		}
+	}
+	if agreed < u.minTrusted {
+		return
+	}
+	if _, ok := u.fetching[head.Hash]; ok || !u.better(head.Td) {
+		return
+	}
+	u.fetching[head.Hash] = struct{}{}
+
+	u.pm.wg.Add(1)
+	go u.fetchHead(head.Hash, head.Number, head.Td)
+}
+
+// better returns whether the given total difficulty is higher than that of the
+// current chain head.
+func (u *ulc) better(td *big.Int) bool {
+	current := u.chain.CurrentHeader()
+	localTd := u.chain.GetTd(current.Hash(), current.Number.Uint64())
+	return localTd == nil || td.Cmp(localTd) > 0
+}
+
+// fetchHead retrieves the header of an agreed head and makes it the new chain
+// head if nothing better arrived in the meantime. Missing ancestors are
+// retrieved too, up to ulcMaxAncestors, so the canonical chain links up with
+// the local one.
+func (u *ulc) fetchHead(hash common.Hash, number uint64, td *big.Int) {
+	defer u.pm.wg.Done()
+	defer func() {
+		u.lock.Lock()
+		delete(u.fetching, hash)
+		u.lock.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), ulcHeadTimeout)
+	defer cancel()
+
+	header, err := light.GetUntrustedHeader(ctx, u.pm.odr, hash, number)
+	if err != nil {
+		log.Debug("Failed to retrieve trusted head", "number", number, "hash", hash, "err", err)
+		return
+	}
+	chain := []*types.Header{header}
+	for len(chain) <= ulcMaxAncestors {
+		child := chain[len(chain)-1]
+		number := child.Number.Uint64()
+		if number == 0 || u.chain.GetHeader(child.ParentHash, number-1) != nil {
+			break
+		}
+		parent, err := light.GetUntrustedHeader(ctx, u.pm.odr, child.ParentHash, number-1)
+		if err != nil {
+			log.Debug("Failed to retrieve ancestor of trusted head", "number", number-1, "hash", child.ParentHash, "err", err)
+			break
+		}
+		chain = append(chain, parent)
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	if u.better(td) {
+		if err := u.chain.InsertTrustedHeaders(chain, td); err != nil {
+			log.Warn("Failed to insert trusted head", "number", number, "hash", hash, "err", err)
+		}
+	}
+}
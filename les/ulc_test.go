@@ -0,0 +1,157 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package les
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/irc"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/light"
+	"github.com/irchain/go-irchain/p2p"
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+func TestULCConfig(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	url := discover.NewNode(discover.PubkeyID(&key.PublicKey), net.IP{127, 0, 0, 1}, 30303, 30303).String()
+
+	tests := []struct {
+		config *irc.ULCConfig
+		err    bool
+		quorum int
+	}{
+		{config: &irc.ULCConfig{MinTrustedFraction: 50}, err: true},
+		{config: &irc.ULCConfig{TrustedServers: []string{url}, MinTrustedFraction: 0}, err: true},
+		{config: &irc.ULCConfig{TrustedServers: []string{url}, MinTrustedFraction: 101}, err: true},
+		{config: &irc.ULCConfig{TrustedServers: []string{"enode://invalid"}, MinTrustedFraction: 50}, err: true},
+		{config: &irc.ULCConfig{TrustedServers: []string{url, url}, MinTrustedFraction: 50}, quorum: 1},
+		{config: &irc.ULCConfig{TrustedServers: []string{url}, MinTrustedFraction: 1}, quorum: 1},
+	}
+	for i, tt := range tests {
+		db := ircdb.NewMemDatabase()
+		odr := NewLesOdr(db, nil, nil, nil, newRetrieveManager(newPeerSet(), nil, nil))
+		pm, err := newTestProtocolManager(true, 0, nil, nil, odr, db, tt.config)
+		if tt.err {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if pm.fetcher != nil {
+			t.Errorf("test %d: header fetcher running in ultra-light mode", i)
+		}
+		if pm.ulc.minTrusted != tt.quorum {
+			t.Errorf("test %d: quorum mismatch: have %d, want %d", i, pm.ulc.minTrusted, tt.quorum)
+		}
+	}
+}
+
+func TestULCHeadQuorum(t *testing.T) {
+	key1, _ := crypto.GenerateKey()
+	key2, _ := crypto.GenerateKey()
+	id1, id2 := discover.PubkeyID(&key1.PublicKey), discover.PubkeyID(&key2.PublicKey)
+
+	config := &irc.ULCConfig{
+		TrustedServers: []string{
+			discover.NewNode(id1, net.IP{127, 0, 0, 1}, 30303, 30303).String(),
+			discover.NewNode(id2, net.IP{127, 0, 0, 2}, 30303, 30303).String(),
+		},
+		MinTrustedFraction: 100,
+	}
+	peers := newPeerSet()
+	dist := newRequestDistributor(peers, make(chan struct{}))
+	rm := newRetrieveManager(peers, dist, nil)
+	db := ircdb.NewMemDatabase()
+	ldb := ircdb.NewMemDatabase()
+	odr := NewLesOdr(ldb, light.NewChtIndexer(db, true), light.NewBloomTrieIndexer(db, true), irc.NewBloomIndexer(db, light.BloomTrieFrequency), rm)
+
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	pm.server.privateKey = key1
+	lpm, err := newTestProtocolManager(true, 0, nil, peers, odr, ldb, config)
+	if err != nil {
+		t.Fatalf("failed to create ultra-light client: %v", err)
+	}
+	lc := lpm.blockchain.(*light.LightChain)
+
+	// Connect the first trusted server
+	app, rw := p2p.MsgPipe()
+	speer := pm.newPeer(2, NetworkId, p2p.NewPeer(id1, "client", nil), rw)
+	lpeer := lpm.newPeer(2, NetworkId, p2p.NewPeer(id1, "server", nil), app)
+	if !lpeer.trusted {
+		t.Fatal("trusted server not recognized")
+	}
+	errc := make(chan error, 2)
+	go func() { errc <- pm.handle(speer) }()
+	go func() { errc <- lpm.handle(lpeer) }()
+	select {
+	case <-time.After(100 * time.Millisecond):
+	case err := <-errc:
+		t.Fatalf("handshake error: %v", err)
+	}
+	if lc.CurrentHeader().Number.Uint64() != 0 {
+		t.Fatal("ultra-light client synced the header chain")
+	}
+	lpm.ulc.lock.Lock()
+	seeded := lpm.ulc.heads[lpeer]
+	lpm.ulc.lock.Unlock()
+	if seeded == nil || seeded.Hash != pm.blockchain.CurrentHeader().Hash() {
+		t.Fatalf("handshake head not tracked: %v", seeded)
+	}
+	// Announce the server head, a single server isn't enough for the quorum
+	head := pm.blockchain.CurrentHeader()
+	announce := announceData{
+		Hash:   head.Hash(),
+		Number: head.Number.Uint64(),
+		Td:     pm.blockchain.GetTd(head.Hash(), head.Number.Uint64()),
+	}
+	announce.sign(key1)
+	if err := speer.SendAnnounce(announce); err != nil {
+		t.Fatalf("failed to announce head: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if lc.CurrentHeader().Hash() == head.Hash() {
+		t.Fatal("head accepted without quorum")
+	}
+	// Have the second trusted server agree, the head should be accepted
+	other := newPeer(2, NetworkId, p2p.NewPeer(id2, "server2", nil), nil)
+	other.trusted = true
+	lpm.ulc.announce(other, &announce)
+
+	for i := 0; i < 20 && lc.CurrentHeader().Hash() != head.Hash(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if lc.CurrentHeader().Hash() != head.Hash() {
+		t.Fatalf("head not accepted: have #%d, want #%d", lc.CurrentHeader().Number, head.Number)
+	}
+	if td := lc.GetTd(head.Hash(), head.Number.Uint64()); td == nil || td.Cmp(announce.Td) != 0 {
+		t.Fatalf("head td mismatch: have %v, want %v", td, announce.Td)
+	}
+	// The missing ancestors should have been retrieved to link up the chain
+	for n := uint64(0); n <= head.Number.Uint64(); n++ {
+		want := pm.blockchain.GetHeaderByNumber(n)
+		if have := lc.GetHeaderByNumber(n); have == nil || have.Hash() != want.Hash() {
+			t.Errorf("canonical header #%d mismatch: have %v, want %x", n, have, want.Hash())
+		}
+	}
+}
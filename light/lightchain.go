@@ -19,6 +19,7 @@ package light
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math/big"
 	"sync"
 	"sync/atomic"
@@ -99,7 +100,7 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 		return nil, core.ErrNoGenesis
 	}
 	if cp, ok := trustedCheckpoints[bc.genesisBlock.Hash()]; ok {
-		bc.addTrustedCheckpoint(cp)
+		bc.AddTrustedCheckpoint(&cp)
 	}
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
@@ -115,20 +116,20 @@ func NewLightChain(odr OdrBackend, config *params.ChainConfig, engine consensus.
 	return bc, nil
 }
 
-// addTrustedCheckpoint adds a trusted checkpoint to the blockchain
-func (self *LightChain) addTrustedCheckpoint(cp trustedCheckpoint) {
+// AddTrustedCheckpoint adds a trusted checkpoint to the blockchain
+func (self *LightChain) AddTrustedCheckpoint(cp *TrustedCheckpoint) {
 	if self.odr.ChtIndexer() != nil {
-		StoreChtRoot(self.chainDb, cp.sectionIdx, cp.sectionHead, cp.chtRoot)
-		self.odr.ChtIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		StoreChtRoot(self.chainDb, cp.SectionIdx, cp.SectionHead, cp.ChtRoot)
+		self.odr.ChtIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
 	if self.odr.BloomTrieIndexer() != nil {
-		StoreBloomTrieRoot(self.chainDb, cp.sectionIdx, cp.sectionHead, cp.bloomTrieRoot)
-		self.odr.BloomTrieIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		StoreBloomTrieRoot(self.chainDb, cp.SectionIdx, cp.SectionHead, cp.BloomTrieRoot)
+		self.odr.BloomTrieIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
 	if self.odr.BloomIndexer() != nil {
-		self.odr.BloomIndexer().AddKnownSectionHead(cp.sectionIdx, cp.sectionHead)
+		self.odr.BloomIndexer().AddKnownSectionHead(cp.SectionIdx, cp.SectionHead)
 	}
-	log.Info("Added trusted checkpoint", "chain", cp.name, "block", (cp.sectionIdx+1)*CHTFrequencyClient-1, "hash", cp.sectionHead)
+	log.Info("Added trusted checkpoint", "chain", cp.Name, "block", cp.HeadNumber(), "hash", cp.SectionHead)
 }
 
 func (self *LightChain) getProcInterrupt() bool {
@@ -391,6 +392,84 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	return i, err
 }
 
+// InsertTrustedHeaders writes a contiguous segment of headers and makes its
+// last header the new head of the canonical chain, without validating them or
+// requiring their ancestors to be present locally. It is meant for ultra-light
+// clients, which establish the validity of a head by other means (e.g. a quorum
+// of trusted servers) instead of downloading the chain. The td is the total
+// difficulty of the last header.
+//
+// The canonical chain is repaired along the segment and its locally known
+// ancestors. Canonical hashes above the new head are removed. If the segment
+// doesn't link to a known header while overlapping the previous chain, the
+// canonical hashes of the replaced chain below it are removed too.
+func (self *LightChain) InsertTrustedHeaders(chain []*types.Header, td *big.Int) error {
+	if len(chain) == 0 {
+		return nil
+	}
+	for i := 1; i < len(chain); i++ {
+		if chain[i].Number.Uint64() != chain[i-1].Number.Uint64()+1 || chain[i].ParentHash != chain[i-1].Hash() {
+			return fmt.Errorf("non contiguous insert: item %d is #%d [%x…], item %d is #%d [%x…] (parent [%x…])", i-1, chain[i-1].Number,
+				chain[i-1].Hash().Bytes()[:4], i, chain[i].Number, chain[i].Hash().Bytes()[:4], chain[i].ParentHash[:4])
+		}
+	}
+	tds := make([]*big.Int, len(chain))
+	tds[len(chain)-1] = td
+	for i := len(chain) - 2; i >= 0; i-- {
+		if tds[i] = new(big.Int).Sub(tds[i+1], chain[i+1].Difficulty); tds[i].Sign() <= 0 {
+			return fmt.Errorf("total difficulty %v too low for %d headers", td, len(chain))
+		}
+	}
+	self.chainmu.Lock()
+	defer self.chainmu.Unlock()
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	// Write the headers along with their total difficulties, then their canonical
+	// hashes, so every canonical hash has its header present
+	var (
+		head = chain[len(chain)-1]
+		prev = self.hc.CurrentHeader().Number.Uint64()
+	)
+	for i, header := range chain {
+		rawdb.WriteTd(self.chainDb, header.Hash(), header.Number.Uint64(), tds[i])
+		rawdb.WriteHeader(self.chainDb, header)
+	}
+	for _, header := range chain {
+		rawdb.WriteCanonicalHash(self.chainDb, header.Hash(), header.Number.Uint64())
+	}
+	for number := head.Number.Uint64() + 1; number <= prev; number++ {
+		rawdb.DeleteCanonicalHash(self.chainDb, number)
+	}
+	// Repair the canonical chain below the segment
+	hash, number := chain[0].ParentHash, chain[0].Number.Uint64()
+	for number > 0 {
+		number--
+		if rawdb.ReadCanonicalHash(self.chainDb, number) == hash {
+			break
+		}
+		parent := self.hc.GetHeader(hash, number)
+		if parent == nil {
+			// Unknown ancestor, the hashes below belong to the replaced chain if it
+			// overlapped the segment
+			if prev >= chain[0].Number.Uint64() {
+				for ; number > 0 && rawdb.ReadCanonicalHash(self.chainDb, number) != (common.Hash{}); number-- {
+					rawdb.DeleteCanonicalHash(self.chainDb, number)
+				}
+			}
+			break
+		}
+		rawdb.WriteCanonicalHash(self.chainDb, hash, number)
+		hash = parent.ParentHash
+	}
+	self.hc.SetCurrentHeader(head)
+
+	log.Debug("Inserted trusted headers", "count", len(chain), "number", head.Number, "hash", head.Hash())
+	self.postChainEvents([]interface{}{core.ChainEvent{Block: types.NewBlockWithHeader(head), Hash: head.Hash()}})
+	return nil
+}
+
 // CurrentHeader retrieves the current head header of the canonical chain. The
 // header is retrieved from the HeaderChain's internal cache.
 func (self *LightChain) CurrentHeader() *types.Header {
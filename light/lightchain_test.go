@@ -347,3 +347,84 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// checkCanonical verifies the canonical hashes of the given numbers, an empty
+// hash meaning no mapping. Every canonical hash must have its header present.
+func checkCanonical(t *testing.T, lc *LightChain, want map[uint64]common.Hash) {
+	t.Helper()
+	for number, hash := range want {
+		if have := rawdb.ReadCanonicalHash(lc.chainDb, number); have != hash {
+			t.Errorf("canonical hash #%d mismatch: have %x, want %x", number, have, hash)
+		}
+		if hash != (common.Hash{}) && lc.GetHeaderByNumber(number) == nil {
+			t.Errorf("canonical header #%d missing", number)
+		}
+	}
+}
+
+// Tests that inserting trusted headers keeps the canonical chain consistent
+// across gaps and reorgs.
+func TestInsertTrustedHeaders(t *testing.T) {
+	db, lc, _ := newCanonical(0)
+	canon := makeHeaderChain(lc.genesisBlock.Header(), 10, db, canonicalSeed)
+	fork := makeHeaderChain(canon[2], 10, db, forkSeed)
+	td := big.NewInt(1000000000)
+
+	// A head without known ancestors leaves a gap
+	if err := lc.InsertTrustedHeaders(canon[5:6], td); err != nil {
+		t.Fatalf("failed to insert trusted header: %v", err)
+	}
+	checkCanonical(t, lc, map[uint64]common.Hash{0: lc.genesisBlock.Hash(), 5: {}, 6: canon[5].Hash()})
+
+	// Extending the head links up with it
+	if err := lc.InsertTrustedHeaders(canon[6:], td); err != nil {
+		t.Fatalf("failed to insert trusted headers: %v", err)
+	}
+	if lc.CurrentHeader().Hash() != canon[9].Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", lc.CurrentHeader().Number, canon[9].Number)
+	}
+	if have := lc.GetTd(canon[6].Hash(), 7); have == nil || have.Cmp(new(big.Int).Sub(td, new(big.Int).Add(canon[9].Difficulty, new(big.Int).Add(canon[8].Difficulty, canon[7].Difficulty)))) != 0 {
+		t.Errorf("ancestor td mismatch: have %v", have)
+	}
+	checkCanonical(t, lc, map[uint64]common.Hash{6: canon[5].Hash(), 7: canon[6].Hash(), 10: canon[9].Hash()})
+
+	// An unlinked fork overlapping the chain drops the replaced canonical hashes
+	if err := lc.InsertTrustedHeaders(fork[3:6], td); err != nil {
+		t.Fatalf("failed to insert trusted headers: %v", err)
+	}
+	checkCanonical(t, lc, map[uint64]common.Hash{6: {}, 7: fork[3].Hash(), 9: fork[5].Hash(), 10: {}})
+
+	// Non contiguous segments and impossible total difficulties are rejected
+	if err := lc.InsertTrustedHeaders([]*types.Header{canon[0], canon[2]}, td); err == nil {
+		t.Fatal("non contiguous headers inserted")
+	}
+	if err := lc.InsertTrustedHeaders(canon[:2], canon[1].Difficulty); err == nil {
+		t.Fatal("headers with too low total difficulty inserted")
+	}
+}
+
+// Tests that a trusted head reorgs the canonical chain along its locally known
+// ancestors.
+func TestInsertTrustedHeadersReorg(t *testing.T) {
+	db, lc, _ := newCanonical(0)
+	canon := makeHeaderChain(lc.genesisBlock.Header(), 10, db, canonicalSeed)
+	fork := makeHeaderChain(canon[2], 7, db, forkSeed)
+
+	if err := lc.InsertTrustedHeaders(canon, big.NewInt(1000000000)); err != nil {
+		t.Fatalf("failed to insert trusted headers: %v", err)
+	}
+	for _, header := range fork[:6] {
+		rawdb.WriteHeader(db, header)
+	}
+	if err := lc.InsertTrustedHeaders(fork[6:], big.NewInt(2000000000)); err != nil {
+		t.Fatalf("failed to insert trusted header: %v", err)
+	}
+	want := map[uint64]common.Hash{11: {}}
+	for _, header := range canon[:3] {
+		want[header.Number.Uint64()] = header.Hash()
+	}
+	for _, header := range fork {
+		want[header.Number.Uint64()] = header.Hash()
+	}
+	checkCanonical(t, lc, want)
+}
@@ -129,6 +129,38 @@ func (req *ReceiptsRequest) StoreResult(db ircdb.Database) {
 	rawdb.WriteReceipts(db, req.Hash, req.Number, req.Receipts)
 }
 
+// HeaderRequest is the ODR request type for retrieving a single header by hash.
+// The header is only checked against the requested hash, so it should not be
+// trusted unless the hash is. Retrieved headers are not stored.
+type HeaderRequest struct {
+	OdrRequest
+	Hash   common.Hash
+	Number uint64
+	Header *types.Header
+}
+
+// StoreResult is a no-op, untrusted headers are not written into the database
+func (req *HeaderRequest) StoreResult(db ircdb.Database) {}
+
+// TxStatus describes the status of a transaction as reported by a server.
+type TxStatus struct {
+	Status core.TxStatus
+	Lookup *rawdb.TxLookupEntry `rlp:"nil"` // Position of included transactions
+	Error  string
+}
+
+// TxStatusRequest is the ODR request type for retrieving the status of
+// transactions. Servers can't prove the status, so it should not be trusted
+// beyond informing the user. Retrieved statuses are not stored.
+type TxStatusRequest struct {
+	OdrRequest
+	Hashes []common.Hash
+	Status []TxStatus
+}
+
+// StoreResult is a no-op, transaction statuses are not written into the database
+func (req *TxStatusRequest) StoreResult(db ircdb.Database) {}
+
 // ChtRequest is the ODR request type for state/storage trie entries
 type ChtRequest struct {
 	OdrRequest
@@ -87,6 +87,14 @@ func (odr *testOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 		req.Proof = nodes
 	case *CodeRequest:
 		req.Data, _ = odr.sdb.Get(req.Hash[:])
+	case *TxStatusRequest:
+		req.Status = make([]TxStatus, len(req.Hashes))
+		for i, hash := range req.Hashes {
+			if block, number, index := rawdb.ReadTxLookupEntry(odr.sdb, hash); block != (common.Hash{}) {
+				req.Status[i].Status = core.TxStatusIncluded
+				req.Status[i].Lookup = &rawdb.TxLookupEntry{BlockHash: block, BlockIndex: number, Index: index}
+			}
+		}
 	}
 	req.StoreResult(odr.ldb)
 	return nil
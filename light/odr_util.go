@@ -30,6 +30,26 @@ import (
 
 var sha3_nil = crypto.Keccak256Hash(nil)
 
+// GetUntrustedHeader retrieves a header by hash from the network without checking
+// whether it belongs to the canonical chain.
+func GetUntrustedHeader(ctx context.Context, odr OdrBackend, hash common.Hash, number uint64) (*types.Header, error) {
+	r := &HeaderRequest{Hash: hash, Number: number}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Header, nil
+}
+
+// GetTransactionStatus retrieves the status of the given transactions from the
+// network.
+func GetTransactionStatus(ctx context.Context, odr OdrBackend, hashes []common.Hash) ([]TxStatus, error) {
+	r := &TxStatusRequest{Hashes: hashes}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Status, nil
+}
+
 func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*types.Header, error) {
 	db := odr.Database()
 	hash := rawdb.ReadCanonicalHash(db, number)
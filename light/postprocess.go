@@ -27,6 +27,7 @@ import (
 	"github.com/irchain/go-irchain/core"
 	"github.com/irchain/go-irchain/core/rawdb"
 	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/crypto"
 	"github.com/irchain/go-irchain/ircdb"
 	"github.com/irchain/go-irchain/log"
 	"github.com/irchain/go-irchain/params"
@@ -47,35 +48,59 @@ const (
 	HelperTrieProcessConfirmations = 256  // number of confirmations before a HelperTrie is generated
 )
 
-// trustedCheckpoint represents a set of post-processed trie roots (CHT and BloomTrie) associated with
+// TrustedCheckpoint represents a set of post-processed trie roots (CHT and BloomTrie) associated with
 // the appropriate section index and head hash. It is used to start light syncing from this checkpoint
 // and avoid downloading the entire header chain while still being able to securely access old headers/logs.
-type trustedCheckpoint struct {
-	name                                string
-	sectionIdx                          uint64
-	sectionHead, chtRoot, bloomTrieRoot common.Hash
+type TrustedCheckpoint struct {
+	Name                                string
+	SectionIdx                          uint64
+	SectionHead, ChtRoot, BloomTrieRoot common.Hash
+}
+
+// Hash returns the keccak256 hash of the 32 byte big endian section index
+// followed by the section head, CHT root and bloom trie root, identifying the
+// checkpoint.
+func (cp *TrustedCheckpoint) Hash() common.Hash {
+	var index [32]byte
+	binary.BigEndian.PutUint64(index[24:], cp.SectionIdx)
+	return crypto.Keccak256Hash(index[:], cp.SectionHead[:], cp.ChtRoot[:], cp.BloomTrieRoot[:])
+}
+
+// SignHash returns the hash signed by the signers of the given checkpoint
+// oracle, an EIP-191 (version 0x00) digest binding the checkpoint to the oracle
+// contract: keccak256(0x19 || 0x00 || oracle || index || sectionHead || chtRoot ||
+// bloomTrieRoot), with the section index as 8 byte big endian integer.
+func (cp *TrustedCheckpoint) SignHash(oracle common.Address) common.Hash {
+	var index [8]byte
+	binary.BigEndian.PutUint64(index[:], cp.SectionIdx)
+	return crypto.Keccak256Hash([]byte{0x19, 0x00}, oracle[:], index[:], cp.SectionHead[:], cp.ChtRoot[:], cp.BloomTrieRoot[:])
+}
+
+// HeadNumber returns the number of the last block covered by the checkpoint.
+func (cp *TrustedCheckpoint) HeadNumber() uint64 {
+	return (cp.SectionIdx+1)*CHTFrequencyClient - 1
 }
 
 var (
-	mainnetCheckpoint = trustedCheckpoint{
-		name:          "mainnet",
-		sectionIdx:    174,
-		sectionHead:   common.HexToHash("a3ef48cd8f1c3a08419f0237fc7763491fe89497b3144b17adf87c1c43664613"),
-		chtRoot:       common.HexToHash("dcbeed9f4dea1b3cb75601bb27c51b9960c28e5850275402ac49a150a667296e"),
-		bloomTrieRoot: common.HexToHash("6b7497a4a03e33870a2383cb6f5e70570f12b1bf5699063baf8c71d02ca90b02"),
+	mainnetCheckpoint = TrustedCheckpoint{
+		Name:          "mainnet",
+		SectionIdx:    174,
+		SectionHead:   common.HexToHash("a3ef48cd8f1c3a08419f0237fc7763491fe89497b3144b17adf87c1c43664613"),
+		ChtRoot:       common.HexToHash("dcbeed9f4dea1b3cb75601bb27c51b9960c28e5850275402ac49a150a667296e"),
+		BloomTrieRoot: common.HexToHash("6b7497a4a03e33870a2383cb6f5e70570f12b1bf5699063baf8c71d02ca90b02"),
 	}
 
-	ropstenCheckpoint = trustedCheckpoint{
-		name:          "ropsten",
-		sectionIdx:    102,
-		sectionHead:   common.HexToHash("9017ab08465cb2b2dee035ee5b817bbd7fa28e2c8d2cd903e0aed1cccb249e89"),
-		chtRoot:       common.HexToHash("f61c10a7a787a5ef15f0ae1ae6c13c64331e57e79d0466d2bd9b0c06833fe956"),
-		bloomTrieRoot: common.HexToHash("69f2ad19aa46d5213a90137b3d2c9bff8a7c9483f7170f0125096ff450c9a873"),
+	ropstenCheckpoint = TrustedCheckpoint{
+		Name:          "ropsten",
+		SectionIdx:    102,
+		SectionHead:   common.HexToHash("9017ab08465cb2b2dee035ee5b817bbd7fa28e2c8d2cd903e0aed1cccb249e89"),
+		ChtRoot:       common.HexToHash("f61c10a7a787a5ef15f0ae1ae6c13c64331e57e79d0466d2bd9b0c06833fe956"),
+		BloomTrieRoot: common.HexToHash("69f2ad19aa46d5213a90137b3d2c9bff8a7c9483f7170f0125096ff450c9a873"),
 	}
 )
 
 // trustedCheckpoints associates each known checkpoint with the genesis hash of the chain it belongs to
-var trustedCheckpoints = map[common.Hash]trustedCheckpoint{
+var trustedCheckpoints = map[common.Hash]TrustedCheckpoint{
 	params.MainnetGenesisHash: mainnetCheckpoint,
 	params.TestnetGenesisHash: ropstenCheckpoint,
 }
@@ -19,6 +19,7 @@ package light
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -44,6 +45,16 @@ const (
 // considered permanent and no rollback is expected
 var txPermanent = uint64(500)
 
+// txJournalKey is the database key the pending transactions are journaled under.
+var txJournalKey = []byte("_lightTxPoolJournal")
+
+// TxStatusEvent is posted when the status of a local transaction changes.
+type TxStatusEvent struct {
+	Hash   common.Hash
+	Status core.TxStatus
+	Lookup *rawdb.TxLookupEntry // Position of included transactions, nil otherwise
+}
+
 // TxPool implements the transaction pool for light clients, which keeps track
 // of the status of locally created transactions, detecting if they are included
 // in a block (mined) or rolled back. There are no queued transactions since we
@@ -54,6 +65,7 @@ type TxPool struct {
 	signer       types.Signer
 	quit         chan bool
 	txFeed       event.Feed
+	statusFeed   event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan core.ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -67,6 +79,12 @@ type TxPool struct {
 	pending      map[common.Hash]*types.Transaction   // pending transactions by tx hash
 	mined        map[common.Hash][]*types.Transaction // mined transactions by block hash
 	clearIdx     uint64                               // earliest block nr that can contain mined tx info
+	unchecked    map[common.Hash]struct{}             // journaled transactions whose status is not yet confirmed
+	statusEvents []TxStatusEvent                      // status changes not yet posted to the subscribers
+
+	statusLock  sync.Mutex
+	statusQueue []TxStatusEvent // status changes waiting to be sent by statusLoop
+	statusWake  chan struct{}
 }
 
 // TxRelayBackend provides an interface to the mechanism that forwards transacions
@@ -92,7 +110,9 @@ func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBacke
 		nonce:       make(map[common.Address]uint64),
 		pending:     make(map[common.Hash]*types.Transaction),
 		mined:       make(map[common.Hash][]*types.Transaction),
+		unchecked:   make(map[common.Hash]struct{}),
 		quit:        make(chan bool),
+		statusWake:  make(chan struct{}, 1),
 		chainHeadCh: make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chain:       chain,
 		relay:       relay,
@@ -101,9 +121,12 @@ func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBacke
 		head:        chain.CurrentHeader().Hash(),
 		clearIdx:    chain.CurrentHeader().Number.Uint64(),
 	}
+	pool.loadJournal()
+
 	// Subscribe events from blockchain
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 	go pool.eventLoop()
+	go pool.statusLoop()
 
 	return pool
 }
@@ -171,10 +194,18 @@ func (pool *TxPool) checkMinedTxs(ctx context.Context, hash common.Hash, number
 		return err
 	}
 	// Gather all the local transaction mined in this block
-	list := pool.mined[hash]
-	for _, tx := range block.Transactions() {
+	var (
+		list     = pool.mined[hash]
+		included []TxStatusEvent
+	)
+	for i, tx := range block.Transactions() {
 		if _, ok := pool.pending[tx.Hash()]; ok {
 			list = append(list, tx)
+			included = append(included, TxStatusEvent{
+				Hash:   tx.Hash(),
+				Status: core.TxStatusIncluded,
+				Lookup: &rawdb.TxLookupEntry{BlockHash: hash, BlockIndex: number, Index: uint64(i)},
+			})
 		}
 	}
 	// If some transactions have been mined, write the needed data to disk and update
@@ -191,6 +222,7 @@ func (pool *TxPool) checkMinedTxs(ctx context.Context, hash common.Hash, number
 			txc.setState(tx.Hash(), true)
 		}
 		pool.mined[hash] = list
+		pool.statusEvents = append(pool.statusEvents, included...)
 	}
 	return nil
 }
@@ -204,6 +236,7 @@ func (pool *TxPool) rollbackTxs(hash common.Hash, txc txStateChanges) {
 			rawdb.DeleteTxLookupEntry(pool.chainDb, txHash)
 			pool.pending[txHash] = tx
 			txc.setState(txHash, false)
+			pool.statusEvents = append(pool.statusEvents, TxStatusEvent{Hash: txHash, Status: core.TxStatusPending})
 		}
 		delete(pool.mined, hash)
 	}
@@ -225,6 +258,11 @@ func (pool *TxPool) reorgOnNewHead(ctx context.Context, newHeader *types.Header)
 		if oldh.Number.Uint64() >= newh.Number.Uint64() {
 			oldHashes = append(oldHashes, oldh.Hash())
 			oldh = pool.chain.GetHeader(oldh.ParentHash, oldh.Number.Uint64()-1)
+			if oldh == nil {
+				// happens in ultra-light mode, ancestors are not stored locally
+				oldh = newh
+				break
+			}
 		}
 		if oldh.Number.Uint64() < newh.Number.Uint64() {
 			newHashes = append(newHashes, newh.Hash())
@@ -304,9 +342,128 @@ func (pool *TxPool) setNewHead(head *types.Header) {
 	defer cancel()
 
 	txc, _ := pool.reorgOnNewHead(ctx, head)
+	if len(pool.unchecked) > 0 {
+		pool.checkJournaledTxs(ctx, txc)
+	}
 	m, r := txc.getLists()
 	pool.relay.NewHead(pool.head, m, r)
 	pool.signer = types.MakeSigner(pool.config, head.Number)
+
+	if len(txc) > 0 {
+		pool.journalTxs()
+	}
+	pool.postStatusEvents()
+}
+
+// checkJournaledTxs looks up the status of the transactions loaded from the
+// journal at the servers, detecting the ones mined while the client was offline.
+// Transactions stay unchecked if the lookup fails, to be retried at the next
+// chain head event.
+func (pool *TxPool) checkJournaledTxs(ctx context.Context, txc txStateChanges) {
+	hashes := make([]common.Hash, 0, len(pool.unchecked))
+	for hash := range pool.unchecked {
+		hashes = append(hashes, hash)
+	}
+	status, err := GetTransactionStatus(ctx, pool.odr, hashes)
+	if err != nil {
+		log.Debug("Failed to check journaled transactions", "err", err)
+		return
+	}
+	for i, hash := range hashes {
+		if lookup := status[i].Lookup; status[i].Status == core.TxStatusIncluded && lookup != nil {
+			if err := pool.checkMinedTxs(ctx, lookup.BlockHash, lookup.BlockIndex, txc); err != nil {
+				continue
+			}
+		}
+		delete(pool.unchecked, hash)
+	}
+}
+
+// loadJournal restores the pending transactions journaled before the last
+// shutdown and hands them to the relay backend again.
+func (pool *TxPool) loadJournal() {
+	data, err := pool.chainDb.Get(txJournalKey)
+	if err != nil {
+		return
+	}
+	var txs types.Transactions
+	if err := rlp.DecodeBytes(data, &txs); err != nil {
+		log.Error("Failed to decode light transaction journal", "err", err)
+		return
+	}
+	for _, tx := range txs {
+		addr, err := types.Sender(pool.signer, tx)
+		if err != nil {
+			continue
+		}
+		hash := tx.Hash()
+		pool.pending[hash] = tx
+		pool.unchecked[hash] = struct{}{}
+		if nonce := tx.Nonce() + 1; nonce > pool.nonce[addr] {
+			pool.nonce[addr] = nonce
+		}
+	}
+	if len(pool.pending) > 0 {
+		log.Info("Loaded light transaction journal", "transactions", len(pool.pending))
+		pool.relay.Send(txs)
+	}
+}
+
+// journalTxs persists the pending transactions, so they are tracked again after
+// a restart. The caller must hold pool.mu.
+func (pool *TxPool) journalTxs() {
+	txs := make(types.Transactions, 0, len(pool.pending))
+	for _, tx := range pool.pending {
+		txs = append(txs, tx)
+	}
+	sort.Sort(types.TxByNonce(txs))
+
+	data, err := rlp.EncodeToBytes(txs)
+	if err != nil {
+		log.Error("Failed to encode light transaction journal", "err", err)
+		return
+	}
+	if err := pool.chainDb.Put(txJournalKey, data); err != nil {
+		log.Warn("Failed to write light transaction journal", "err", err)
+	}
+}
+
+// postStatusEvents queues the collected status changes for statusLoop to send
+// them to the subscribers. The caller must hold pool.mu. The events aren't sent
+// directly because the subscribers may call back into the pool.
+func (pool *TxPool) postStatusEvents() {
+	if len(pool.statusEvents) == 0 {
+		return
+	}
+	pool.statusLock.Lock()
+	pool.statusQueue = append(pool.statusQueue, pool.statusEvents...)
+	pool.statusLock.Unlock()
+	pool.statusEvents = nil
+
+	select {
+	case pool.statusWake <- struct{}{}:
+	default:
+	}
+}
+
+// statusLoop sends the queued status changes to the subscribers, in the order
+// they happened.
+func (pool *TxPool) statusLoop() {
+	for {
+		select {
+		case <-pool.statusWake:
+			pool.statusLock.Lock()
+			events := pool.statusQueue
+			pool.statusQueue = nil
+			pool.statusLock.Unlock()
+
+			for _, ev := range events {
+				pool.statusFeed.Send(ev)
+			}
+		case <-pool.quit:
+			return
+		}
+	}
 }
 
 // Stop stops the light transaction pool
@@ -325,6 +482,48 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.Subsc
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxStatusEvent registers a subscription of TxStatusEvent, notifying
+// about the status changes of local transactions.
+func (pool *TxPool) SubscribeTxStatusEvent(ch chan<- TxStatusEvent) event.Subscription {
+	return pool.scope.Track(pool.statusFeed.Subscribe(ch))
+}
+
+// GetStatus returns the status of the given transactions. Transactions unknown
+// to the local pool are looked up at the servers, whose answers can't be
+// verified.
+func (pool *TxPool) GetStatus(ctx context.Context, hashes []common.Hash) ([]TxStatus, error) {
+	var (
+		status  = make([]TxStatus, len(hashes))
+		unknown []common.Hash
+		indices []int
+	)
+	pool.mu.RLock()
+	for i, hash := range hashes {
+		if _, ok := pool.pending[hash]; ok {
+			status[i].Status = core.TxStatusPending
+		} else if block, number, index := rawdb.ReadTxLookupEntry(pool.chainDb, hash); block != (common.Hash{}) {
+			status[i].Status = core.TxStatusIncluded
+			status[i].Lookup = &rawdb.TxLookupEntry{BlockHash: block, BlockIndex: number, Index: index}
+		} else {
+			unknown = append(unknown, hash)
+			indices = append(indices, i)
+		}
+	}
+	pool.mu.RUnlock()
+
+	if len(unknown) == 0 {
+		return status, nil
+	}
+	remote, err := GetTransactionStatus(ctx, pool.odr, unknown)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range indices {
+		status[i] = remote[j]
+	}
+	return status, nil
+}
+
 // Stats returns the number of currently pending (locally created) transactions
 func (pool *TxPool) Stats() (pending int) {
 	pool.mu.RLock()
@@ -434,6 +633,7 @@ func (self *TxPool) add(ctx context.Context, tx *types.Transaction) error {
 		// because it's possible that somewhere during the post "Remove transaction"
 		// gets called which will then wait for the global tx pool lock and deadlock.
 		go self.txFeed.Send(core.NewTxsEvent{Txs: types.Transactions{tx}})
+		self.statusEvents = append(self.statusEvents, TxStatusEvent{Hash: hash, Status: core.TxStatusPending})
 	}
 
 	// Print a log message if low enough level is set
@@ -458,6 +658,8 @@ func (self *TxPool) Add(ctx context.Context, tx *types.Transaction) error {
 	self.relay.Send(types.Transactions{tx})
 
 	self.chainDb.Put(tx.Hash().Bytes(), data)
+	self.journalTxs()
+	self.postStatusEvents()
 	return nil
 }
 
@@ -475,6 +677,8 @@ func (self *TxPool) AddBatch(ctx context.Context, txs []*types.Transaction) {
 	}
 	if len(sendTx) > 0 {
 		self.relay.Send(sendTx)
+		self.journalTxs()
+		self.postStatusEvents()
 	}
 }
 
@@ -528,11 +732,12 @@ func (self *TxPool) RemoveTransactions(txs types.Transactions) {
 	for _, tx := range txs {
 		// self.RemoveTx(tx.Hash())
 		hash := tx.Hash()
-		delete(self.pending, hash)
-		self.chainDb.Delete(hash[:])
+		self.remove(hash)
 		hashes = append(hashes, hash)
 	}
 	self.relay.Discard(hashes)
+	self.journalTxs()
+	self.postStatusEvents()
 }
 
 // RemoveTx removes the transaction with the given hash from the pool.
@@ -540,7 +745,19 @@ func (pool *TxPool) RemoveTx(hash common.Hash) {
 	pool.mu.Lock()
 	defer pool.mu.Unlock()
 	// delete from pending pool
+	pool.remove(hash)
+	pool.relay.Discard([]common.Hash{hash})
+	pool.journalTxs()
+	pool.postStatusEvents()
+}
+
+// remove drops a pending transaction, notifying the subscribers if it was
+// tracked. The caller must hold pool.mu.
+func (pool *TxPool) remove(hash common.Hash) {
+	if _, ok := pool.pending[hash]; ok {
+		pool.statusEvents = append(pool.statusEvents, TxStatusEvent{Hash: hash, Status: core.TxStatusUnknown})
+	}
 	delete(pool.pending, hash)
+	delete(pool.unchecked, hash)
 	pool.chainDb.Delete(hash[:])
-	pool.relay.Discard([]common.Hash{hash})
 }
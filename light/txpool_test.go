@@ -141,3 +141,135 @@ func TestTxPool(t *testing.T) {
 		}
 	}
 }
+
+func TestTxPoolJournal(t *testing.T) {
+	var (
+		sdb     = ircdb.NewMemDatabase()
+		ldb     = ircdb.NewMemDatabase()
+		gspec   = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+		genesis = gspec.MustCommit(sdb)
+	)
+	gspec.MustCommit(ldb)
+
+	tx0, _ := types.SignTx(types.NewTransaction(0, acc1Addr, big.NewInt(10000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+	tx1, _ := types.SignTx(types.NewTransaction(1, acc1Addr, big.NewInt(10000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+
+	blockchain, _ := core.NewBlockChain(sdb, nil, params.TestChainConfig, irchash.NewFullFaker(), vm.Config{})
+	gchain, _ := core.GenerateChain(params.TestChainConfig, genesis, irchash.NewFaker(), sdb, 2, func(i int, block *core.BlockGen) {
+		if i == 0 {
+			block.AddTx(tx0)
+		}
+	})
+	if _, err := blockchain.InsertChain(gchain); err != nil {
+		t.Fatal(err)
+	}
+	odr := &testOdr{sdb: sdb, ldb: ldb}
+	relay := &testTxRelay{
+		send:    make(chan int, 10),
+		discard: make(chan int, 10),
+		mined:   make(chan int, 10),
+	}
+	lightchain, _ := NewLightChain(odr, params.TestChainConfig, irchash.NewFullFaker())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	expectEvent := func(events chan TxStatusEvent, hash common.Hash, status core.TxStatus) TxStatusEvent {
+		select {
+		case ev := <-events:
+			if ev.Hash != hash || ev.Status != status {
+				t.Fatalf("status event mismatch: have %x/%d, want %x/%d", ev.Hash, ev.Status, hash, status)
+			}
+			return ev
+		case <-time.After(time.Second):
+			t.Fatalf("missing status event for %x", hash)
+		}
+		return TxStatusEvent{}
+	}
+	// Send two transactions and shut down before any of them is mined
+	pool := NewTxPool(params.TestChainConfig, lightchain, relay)
+	events := make(chan TxStatusEvent, 10)
+	pool.SubscribeTxStatusEvent(events)
+	for _, tx := range []*types.Transaction{tx0, tx1} {
+		if err := pool.Add(ctx, tx); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+		<-relay.send
+		expectEvent(events, tx.Hash(), core.TxStatusPending)
+	}
+	pool.Stop()
+
+	// Mine the first one while the pool is down, then restart it
+	if _, err := lightchain.InsertHeaderChain([]*types.Header{gchain[0].Header()}, 1); err != nil {
+		t.Fatal(err)
+	}
+	pool = NewTxPool(params.TestChainConfig, lightchain, relay)
+	defer pool.Stop()
+	if pending := pool.Stats(); pending != 2 {
+		t.Fatalf("journaled transactions mismatch: have %d, want 2", pending)
+	}
+	if sent := <-relay.send; sent != 2 {
+		t.Fatalf("relayed journaled transactions mismatch: have %d, want 2", sent)
+	}
+	events = make(chan TxStatusEvent, 10)
+	pool.SubscribeTxStatusEvent(events)
+
+	// The next head should detect the transaction mined during the downtime
+	if _, err := lightchain.InsertHeaderChain([]*types.Header{gchain[1].Header()}, 1); err != nil {
+		t.Fatal(err)
+	}
+	ev := expectEvent(events, tx0.Hash(), core.TxStatusIncluded)
+	if ev.Lookup == nil || ev.Lookup.BlockHash != gchain[0].Hash() || ev.Lookup.BlockIndex != 1 || ev.Lookup.Index != 0 {
+		t.Fatalf("included transaction position mismatch: %+v", ev.Lookup)
+	}
+	if pending := pool.Stats(); pending != 1 {
+		t.Fatalf("pending transactions mismatch: have %d, want 1", pending)
+	}
+	status, err := pool.GetStatus(ctx, []common.Hash{tx0.Hash(), tx1.Hash(), {1}})
+	if err != nil {
+		t.Fatalf("failed to retrieve status: %v", err)
+	}
+	if status[0].Status != core.TxStatusIncluded || status[1].Status != core.TxStatusPending || status[2].Status != core.TxStatusUnknown {
+		t.Fatalf("status mismatch: %v", status)
+	}
+}
+
+// Tests that status events are delivered in the order of the status changes.
+func TestTxPoolStatusEventOrder(t *testing.T) {
+	var (
+		sdb   = ircdb.NewMemDatabase()
+		ldb   = ircdb.NewMemDatabase()
+		gspec = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+	)
+	gspec.MustCommit(sdb)
+	gspec.MustCommit(ldb)
+
+	relay := &testTxRelay{
+		send:    make(chan int, 100),
+		discard: make(chan int, 100),
+		mined:   make(chan int, 100),
+	}
+	lightchain, _ := NewLightChain(&testOdr{sdb: sdb, ldb: ldb}, params.TestChainConfig, irchash.NewFullFaker())
+	pool := NewTxPool(params.TestChainConfig, lightchain, relay)
+	defer pool.Stop()
+
+	events := make(chan TxStatusEvent, 100)
+	pool.SubscribeTxStatusEvent(events)
+
+	txs := make([]*types.Transaction, 50)
+	for i := range txs {
+		txs[i], _ = types.SignTx(types.NewTransaction(uint64(i), acc1Addr, big.NewInt(10000), params.TxGas, nil, nil), types.HomesteadSigner{}, testBankKey)
+		if err := pool.Add(context.Background(), txs[i]); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	for i, tx := range txs {
+		select {
+		case ev := <-events:
+			if ev.Hash != tx.Hash() {
+				t.Fatalf("event %d out of order: have %x, want %x", i, ev.Hash, tx.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("missing status event %d", i)
+		}
+	}
+}
@@ -42,6 +42,18 @@ type Backend interface {
 	ChainDb() ircdb.Database
 }
 
+// Config is the configuration parameters of mining.
+type Config struct {
+	TxOrdering       string           `toml:",omitempty"` // Name of the transaction ordering strategy (default = price)
+	MaxTxsPerSender  int              `toml:",omitempty"` // Maximum number of transactions per sender and block (0 = unlimited)
+	PriorityAccounts []common.Address `toml:",omitempty"` // Accounts preferred by the priority ordering
+}
+
+// DefaultConfig contains the default mining settings.
+var DefaultConfig = Config{
+	TxOrdering: PriceOrdering,
+}
+
 // Miner creates blocks and searches for proof-of-work values.
 type Miner struct {
 	mux *event.TypeMux
@@ -57,18 +69,22 @@ type Miner struct {
 	shouldStart int32 // should start indicates whether we should start after sync
 }
 
-func New(irc Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine) *Miner {
+func New(irc Backend, config *Config, chainConfig *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine) (*Miner, error) {
+	ordering, err := newTxOrdering(config)
+	if err != nil {
+		return nil, err
+	}
 	miner := &Miner{
 		irc:      irc,
 		mux:      mux,
 		engine:   engine,
-		worker:   newWorker(config, engine, common.Address{}, irc, mux),
+		worker:   newWorker(chainConfig, ordering, engine, common.Address{}, irc, mux),
 		canStart: 1,
 	}
 	miner.Register(NewCpuAgent(irc.BlockChain(), engine))
 	go miner.update()
 
-	return miner
+	return miner, nil
 }
 
 // update keeps track of the downloader events. Please be aware that this is a one shot type of update loop.
@@ -0,0 +1,394 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/core/types"
+)
+
+// Names of the built in transaction ordering strategies.
+const (
+	PriceOrdering    = "price"    // Highest gas price first, honouring account nonces
+	FifoOrdering     = "fifo"     // Earliest arrival in the local pool first
+	PriorityOrdering = "priority" // Priority accounts first, everything else by price
+)
+
+// TxSet is an ordered set of executable transactions the worker draws from when
+// filling a block. The semantics mirror types.TransactionsByPriceAndNonce.
+type TxSet interface {
+	// Peek returns the next transaction to try, or nil if the set is exhausted.
+	Peek() *types.Transaction
+
+	// Shift replaces the current transaction with the next one from the same
+	// account.
+	Shift()
+
+	// Pop removes the current transaction without replacing it with the next one
+	// from the same account, discarding the remainder of that account's batch.
+	Pop()
+}
+
+// TxOrdering is a transaction selection strategy used during block building.
+type TxOrdering interface {
+	// Order creates a transaction set from the pending transactions grouped by
+	// account and sorted by nonce. The input map is reowned by the ordering.
+	Order(signer types.Signer, txs map[common.Address]types.Transactions) TxSet
+}
+
+// TxTracker is an optional interface a TxOrdering may implement to be notified
+// about transactions entering and leaving the local pool.
+type TxTracker interface {
+	// TrackTxs is called with every batch of transactions entering the pool.
+	TrackTxs(txs []*types.Transaction)
+
+	// PruneTxs is called with the full pending set before a new block is built,
+	// allowing the ordering to drop state held about no longer pending ones.
+	PruneTxs(pending map[common.Address]types.Transactions)
+}
+
+// TxOrderingConstructor creates a transaction ordering from the miner config.
+type TxOrderingConstructor func(config *Config) (TxOrdering, error)
+
+var (
+	orderingsMu sync.RWMutex
+	orderings   = map[string]TxOrderingConstructor{
+		PriceOrdering: func(*Config) (TxOrdering, error) { return priceOrdering{}, nil },
+		FifoOrdering:  func(*Config) (TxOrdering, error) { return newFifoOrdering(), nil },
+		PriorityOrdering: func(config *Config) (TxOrdering, error) {
+			return newPriorityOrdering(config.PriorityAccounts), nil
+		},
+	}
+)
+
+// RegisterTxOrdering makes a transaction ordering strategy available by name to
+// the miner configuration. If a strategy with the same name already exists, it
+// is replaced.
+func RegisterTxOrdering(name string, constructor TxOrderingConstructor) {
+	orderingsMu.Lock()
+	defer orderingsMu.Unlock()
+
+	orderings[name] = constructor
+}
+
+// TxOrderings returns the names of all the registered ordering strategies.
+func TxOrderings() []string {
+	orderingsMu.RLock()
+	defer orderingsMu.RUnlock()
+
+	names := make([]string, 0, len(orderings))
+	for name := range orderings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newTxOrdering creates the ordering strategy requested by the config, wrapping
+// it into a per-sender cap if one is configured.
+func newTxOrdering(config *Config) (TxOrdering, error) {
+	name := config.TxOrdering
+	if name == "" {
+		name = PriceOrdering
+	}
+	orderingsMu.RLock()
+	constructor, ok := orderings[name]
+	orderingsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown transaction ordering %q", name)
+	}
+	ordering, err := constructor(config)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxTxsPerSender > 0 {
+		ordering = &cappedOrdering{ordering: ordering, limit: config.MaxTxsPerSender}
+	}
+	return ordering, nil
+}
+
+// priceOrdering is the default strategy, ordering transactions by gas price.
+type priceOrdering struct{}
+
+func (priceOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxSet {
+	return types.NewTransactionsByPriceAndNonce(signer, txs)
+}
+
+// fifoOrdering orders transactions by the time they were first seen by the
+// local node, falling back to gas price for transactions with equal arrival.
+type fifoOrdering struct {
+	arrivals map[common.Hash]time.Time
+	lock     sync.Mutex
+}
+
+func newFifoOrdering() *fifoOrdering {
+	return &fifoOrdering{arrivals: make(map[common.Hash]time.Time)}
+}
+
+// TrackTxs records the arrival time of newly seen transactions.
+func (o *fifoOrdering) TrackTxs(txs []*types.Transaction) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	now := time.Now()
+	for _, tx := range txs {
+		if _, ok := o.arrivals[tx.Hash()]; !ok {
+			o.arrivals[tx.Hash()] = now
+		}
+	}
+}
+
+func (o *fifoOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxSet {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	// Gather the arrival times of the ordered transactions. Unknown ones (e.g.
+	// pooled before the miner started) are treated as the oldest.
+	arrivals := make(map[common.Hash]time.Time)
+	for _, list := range txs {
+		for _, tx := range list {
+			if arrival, ok := o.arrivals[tx.Hash()]; ok {
+				arrivals[tx.Hash()] = arrival
+			}
+		}
+	}
+	set := &txsByArrival{
+		txs:      make(map[common.Address]types.Transactions),
+		arrivals: arrivals,
+	}
+	for sender, list := range txs {
+		set.heads.txs = append(set.heads.txs, list[0])
+		set.heads.senders = append(set.heads.senders, sender)
+		set.heads.times = append(set.heads.times, arrivals[list[0].Hash()])
+		set.txs[sender] = list[1:]
+	}
+	heap.Init(&set.heads)
+	return set
+}
+
+// PruneTxs drops the arrival times of all transactions not contained in the
+// given pending set, keeping the tracker bounded by the pool size.
+func (o *fifoOrdering) PruneTxs(pending map[common.Address]types.Transactions) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	arrivals := make(map[common.Hash]time.Time)
+	for _, list := range pending {
+		for _, tx := range list {
+			if arrival, ok := o.arrivals[tx.Hash()]; ok {
+				arrivals[tx.Hash()] = arrival
+			}
+		}
+	}
+	o.arrivals = arrivals
+}
+
+// arrivalHeap is a heap of account head transactions sorted by arrival time.
+type arrivalHeap struct {
+	txs     []*types.Transaction
+	senders []common.Address
+	times   []time.Time
+}
+
+func (h arrivalHeap) Len() int { return len(h.txs) }
+func (h arrivalHeap) Less(i, j int) bool {
+	if !h.times[i].Equal(h.times[j]) {
+		return h.times[i].Before(h.times[j])
+	}
+	return h.txs[i].GasPrice().Cmp(h.txs[j].GasPrice()) > 0
+}
+func (h arrivalHeap) Swap(i, j int) {
+	h.txs[i], h.txs[j] = h.txs[j], h.txs[i]
+	h.senders[i], h.senders[j] = h.senders[j], h.senders[i]
+	h.times[i], h.times[j] = h.times[j], h.times[i]
+}
+
+func (h *arrivalHeap) Push(x interface{}) {
+	h.txs = append(h.txs, x.(*types.Transaction))
+	h.senders = append(h.senders, common.Address{})
+	h.times = append(h.times, time.Time{})
+}
+
+func (h *arrivalHeap) Pop() interface{} {
+	n := len(h.txs)
+	tx := h.txs[n-1]
+	h.txs, h.senders, h.times = h.txs[:n-1], h.senders[:n-1], h.times[:n-1]
+	return tx
+}
+
+// txsByArrival is a TxSet returning transactions in arrival order while still
+// honouring account nonces.
+type txsByArrival struct {
+	txs      map[common.Address]types.Transactions
+	heads    arrivalHeap
+	arrivals map[common.Hash]time.Time
+}
+
+func (t *txsByArrival) Peek() *types.Transaction {
+	if len(t.heads.txs) == 0 {
+		return nil
+	}
+	return t.heads.txs[0]
+}
+
+func (t *txsByArrival) Shift() {
+	sender := t.heads.senders[0]
+	if txs, ok := t.txs[sender]; ok && len(txs) > 0 {
+		t.heads.txs[0], t.heads.times[0], t.txs[sender] = txs[0], t.arrivals[txs[0].Hash()], txs[1:]
+		heap.Fix(&t.heads, 0)
+	} else {
+		heap.Pop(&t.heads)
+	}
+}
+
+func (t *txsByArrival) Pop() {
+	heap.Pop(&t.heads)
+}
+
+// priorityOrdering includes all transactions of a set of privileged accounts
+// (e.g. system accounts on a permissioned chain) before any other ones. Both
+// groups are individually ordered by gas price.
+type priorityOrdering struct {
+	accounts map[common.Address]struct{}
+}
+
+func newPriorityOrdering(accounts []common.Address) *priorityOrdering {
+	o := &priorityOrdering{accounts: make(map[common.Address]struct{})}
+	for _, account := range accounts {
+		o.accounts[account] = struct{}{}
+	}
+	return o
+}
+
+func (o *priorityOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxSet {
+	priority := make(map[common.Address]types.Transactions)
+	for addr, list := range txs {
+		if _, ok := o.accounts[addr]; ok {
+			priority[addr] = list
+			delete(txs, addr)
+		}
+	}
+	return &chainedTxSet{sets: []TxSet{
+		types.NewTransactionsByPriceAndNonce(signer, priority),
+		types.NewTransactionsByPriceAndNonce(signer, txs),
+	}}
+}
+
+// chainedTxSet drains multiple transaction sets one after the other.
+type chainedTxSet struct {
+	sets []TxSet
+}
+
+func (c *chainedTxSet) Peek() *types.Transaction {
+	for len(c.sets) > 0 {
+		if tx := c.sets[0].Peek(); tx != nil {
+			return tx
+		}
+		c.sets = c.sets[1:]
+	}
+	return nil
+}
+
+func (c *chainedTxSet) Shift() {
+	if c.Peek() != nil {
+		c.sets[0].Shift()
+	}
+}
+
+func (c *chainedTxSet) Pop() {
+	if c.Peek() != nil {
+		c.sets[0].Pop()
+	}
+}
+
+// cappedOrdering wraps another ordering, limiting the number of transactions
+// any single sender may get included into a block.
+type cappedOrdering struct {
+	ordering TxOrdering
+	limit    int
+}
+
+// TrackTxs forwards the notification to the wrapped ordering if it is
+// interested.
+func (o *cappedOrdering) TrackTxs(txs []*types.Transaction) {
+	if tracker, ok := o.ordering.(TxTracker); ok {
+		tracker.TrackTxs(txs)
+	}
+}
+
+// PruneTxs forwards the notification to the wrapped ordering if it is
+// interested.
+func (o *cappedOrdering) PruneTxs(pending map[common.Address]types.Transactions) {
+	if tracker, ok := o.ordering.(TxTracker); ok {
+		tracker.PruneTxs(pending)
+	}
+}
+
+func (o *cappedOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxSet {
+	return &cappedTxSet{
+		TxSet:  o.ordering.Order(signer, txs),
+		signer: signer,
+		limit:  o.limit,
+		counts: make(map[common.Address]int),
+	}
+}
+
+// txCommitter is an optional interface a TxSet may implement to be told which
+// of its transactions were included into the block, as opposed to skipped.
+type txCommitter interface {
+	// Commit is called with the current transaction included, right before it
+	// is shifted out.
+	Commit()
+}
+
+// cappedTxSet is a TxSet dropping all transactions of an account after a given
+// number of them were committed (i.e. accepted into the block). Skipped ones
+// don't count towards the cap.
+type cappedTxSet struct {
+	TxSet
+	signer types.Signer
+	limit  int
+	counts map[common.Address]int
+}
+
+// Commit counts the current transaction towards the cap of its sender.
+func (t *cappedTxSet) Commit() {
+	if tx := t.TxSet.Peek(); tx != nil {
+		sender, _ := types.Sender(t.signer, tx)
+		t.counts[sender]++
+	}
+}
+
+func (t *cappedTxSet) Shift() {
+	tx := t.TxSet.Peek()
+	if tx == nil {
+		return
+	}
+	sender, _ := types.Sender(t.signer, tx)
+	if t.counts[sender] >= t.limit {
+		t.TxSet.Pop()
+		return
+	}
+	t.TxSet.Shift()
+}
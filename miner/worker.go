@@ -106,10 +106,11 @@ type worker struct {
 	agents map[Agent]struct{}
 	recv   chan *Result
 
-	irc     Backend
-	chain   *core.BlockChain
-	proc    core.Validator
-	chainDb ircdb.Database
+	irc      Backend
+	chain    *core.BlockChain
+	proc     core.Validator
+	chainDb  ircdb.Database
+	ordering TxOrdering // strategy selecting the transactions to include
 
 	coinbase common.Address
 	extra    []byte
@@ -131,7 +132,7 @@ type worker struct {
 	atWork int32
 }
 
-func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, irc Backend, mux *event.TypeMux) *worker {
+func newWorker(config *params.ChainConfig, ordering TxOrdering, engine consensus.Engine, coinbase common.Address, irc Backend, mux *event.TypeMux) *worker {
 	worker := &worker{
 		config:         config,
 		engine:         engine,
@@ -144,6 +145,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		recv:           make(chan *Result, resultQueueSize),
 		chain:          irc.BlockChain(),
 		proc:           irc.BlockChain().Validator(),
+		ordering:       ordering,
 		possibleUncles: make(map[common.Hash]*types.Block),
 		coinbase:       coinbase,
 		agents:         make(map[Agent]struct{}),
@@ -257,6 +259,9 @@ func (self *worker) update() {
 			self.uncleMu.Unlock()
 		case ev := <-self.txsCh:
 			// Handle TxPreEvent
+			if tracker, ok := self.ordering.(TxTracker); ok {
+				tracker.TrackTxs(ev.Txs)
+			}
 			if atomic.LoadInt32(&self.mining) == 0 {
 				// Apply transaction to the pending state if we're not mining
 				self.currentMu.Lock()
@@ -265,7 +270,7 @@ func (self *worker) update() {
 					acc, _ := types.Sender(self.current.signer, tx)
 					txs[acc] = append(txs[acc], tx)
 				}
-				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
+				txset := self.ordering.Order(self.current.signer, txs)
 				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
 				self.updateSnapshot()
 				self.currentMu.Unlock()
@@ -443,7 +448,10 @@ func (self *worker) commitNewWork() {
 		log.Error("Failed to fetch pending transactions", "err", err)
 		return
 	} else {
-		txs := types.NewTransactionsByPriceAndNonce(work.signer, pending)
+		if tracker, ok := self.ordering.(TxTracker); ok {
+			tracker.PruneTxs(pending)
+		}
+		txs := self.ordering.Order(work.signer, pending)
 		work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
 	}
 
@@ -520,7 +528,7 @@ func (self *worker) updateSnapshot() {
 	self.snapshotState = self.current.state.Copy()
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
+func (env *Work) commitTransactions(mux *event.TypeMux, txs TxSet, bc *core.BlockChain, coinbase common.Address) {
 	if env.gasPool == nil {
 		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
 	}
@@ -562,6 +570,9 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 			// Everything ok, collect the logs and shift in the next transaction from the same account
 			coalescedLogs = append(coalescedLogs, logs...)
 			env.tcount++
+			if committer, ok := txs.(txCommitter); ok {
+				committer.Commit()
+			}
 			txs.Shift()
 		default:
 			// Strange error, discard the transaction and get the next in line (note, the
@@ -0,0 +1,291 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/irchain/go-irchain/accounts"
+	"github.com/irchain/go-irchain/common"
+	"github.com/irchain/go-irchain/consensus/irchash"
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/core/types"
+	"github.com/irchain/go-irchain/core/vm"
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/event"
+	"github.com/irchain/go-irchain/ircdb"
+	"github.com/irchain/go-irchain/params"
+)
+
+var (
+	testKeys     []*ecdsa.PrivateKey
+	testAccounts []common.Address
+)
+
+func init() {
+	for i := 0; i < 3; i++ {
+		key, _ := crypto.GenerateKey()
+		testKeys = append(testKeys, key)
+		testAccounts = append(testAccounts, crypto.PubkeyToAddress(key.PublicKey))
+	}
+}
+
+// testWorkerBackend implements Backend with a funded in-memory chain.
+type testWorkerBackend struct {
+	db     ircdb.Database
+	chain  *core.BlockChain
+	txPool *core.TxPool
+}
+
+func newTestWorkerBackend(t *testing.T, config *params.ChainConfig) *testWorkerBackend {
+	alloc := make(core.GenesisAlloc)
+	for _, addr := range testAccounts {
+		alloc[addr] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	var (
+		db    = ircdb.NewMemDatabase()
+		gspec = core.Genesis{Config: config, Alloc: alloc, GasLimit: 10000000}
+	)
+	gspec.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, config, irchash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+
+	return &testWorkerBackend{
+		db:     db,
+		chain:  chain,
+		txPool: core.NewTxPool(poolConfig, config, chain),
+	}
+}
+
+func (b *testWorkerBackend) AccountManager() *accounts.Manager { return accounts.NewManager() }
+func (b *testWorkerBackend) BlockChain() *core.BlockChain      { return b.chain }
+func (b *testWorkerBackend) TxPool() *core.TxPool              { return b.txPool }
+func (b *testWorkerBackend) ChainDb() ircdb.Database           { return b.db }
+
+// newTestWorker creates a worker using the given ordering configuration,
+// seeding the pool with the given transactions.
+func newTestWorker(t *testing.T, config *Config, txs []*types.Transaction) (*worker, *testWorkerBackend) {
+	ordering, err := newTxOrdering(config)
+	if err != nil {
+		t.Fatalf("failed to create ordering: %v", err)
+	}
+	backend := newTestWorkerBackend(t, params.AllIrchashProtocolChanges)
+	for i, err := range backend.txPool.AddLocals(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add to pool: %v", i, err)
+		}
+	}
+	w := newWorker(params.AllIrchashProtocolChanges, ordering, irchash.NewFaker(), common.Address{}, backend, new(event.TypeMux))
+	w.commitNewWork()
+
+	return w, backend
+}
+
+// pricedTx creates a signed value transfer from the given test account.
+func pricedTx(account int, nonce uint64, price int64) *types.Transaction {
+	signer := types.NewEIP155Signer(params.AllIrchashProtocolChanges.ChainID)
+	tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1000000), params.TxGas, big.NewInt(price), nil), signer, testKeys[account])
+	return tx
+}
+
+// includedTxs returns the transactions included into the worker's current block.
+func includedTxs(w *worker) []*types.Transaction {
+	w.currentMu.Lock()
+	defer w.currentMu.Unlock()
+
+	return append([]*types.Transaction{}, w.current.txs...)
+}
+
+func checkTxOrder(t *testing.T, have []*types.Transaction, want []*types.Transaction) {
+	if len(have) != len(want) {
+		t.Fatalf("included transaction count mismatch: have %d, want %d", len(have), len(want))
+	}
+	for i := range want {
+		if have[i].Hash() != want[i].Hash() {
+			t.Errorf("tx %d: hash mismatch: have %x, want %x", i, have[i].Hash(), want[i].Hash())
+		}
+	}
+}
+
+// Tests that the default ordering includes transactions by price while still
+// honouring account nonces.
+func TestWorkerPriceOrdering(t *testing.T) {
+	txs := []*types.Transaction{
+		pricedTx(0, 0, 1), pricedTx(0, 1, 5),
+		pricedTx(1, 0, 3),
+		pricedTx(2, 0, 2),
+	}
+	w, _ := newTestWorker(t, &DefaultConfig, txs)
+	defer w.stop()
+
+	checkTxOrder(t, includedTxs(w), []*types.Transaction{txs[2], txs[3], txs[0], txs[1]})
+}
+
+// Tests that the priority ordering includes the transactions of the configured
+// accounts before any other ones, irrespective of price.
+func TestWorkerPriorityOrdering(t *testing.T) {
+	txs := []*types.Transaction{
+		pricedTx(0, 0, 10),
+		pricedTx(1, 0, 5),
+		pricedTx(2, 0, 1), pricedTx(2, 1, 1),
+	}
+	config := &Config{TxOrdering: PriorityOrdering, PriorityAccounts: []common.Address{testAccounts[2]}}
+
+	w, _ := newTestWorker(t, config, txs)
+	defer w.stop()
+
+	checkTxOrder(t, includedTxs(w), []*types.Transaction{txs[2], txs[3], txs[0], txs[1]})
+}
+
+// Tests that the per-sender cap limits the number of transactions included from
+// a single account, leaving room for others.
+func TestWorkerSenderCap(t *testing.T) {
+	txs := []*types.Transaction{
+		pricedTx(0, 0, 10), pricedTx(0, 1, 10), pricedTx(0, 2, 10),
+		pricedTx(1, 0, 1),
+	}
+	config := &Config{TxOrdering: PriceOrdering, MaxTxsPerSender: 2}
+
+	w, _ := newTestWorker(t, config, txs)
+	defer w.stop()
+
+	checkTxOrder(t, includedTxs(w), []*types.Transaction{txs[0], txs[1], txs[3]})
+}
+
+// Tests that transactions skipped during block building don't count towards the
+// cap of their sender.
+func TestCappedTxSetSkips(t *testing.T) {
+	signer := types.NewEIP155Signer(params.AllIrchashProtocolChanges.ChainID)
+	ordering := &cappedOrdering{ordering: priceOrdering{}, limit: 1}
+
+	txs := []*types.Transaction{pricedTx(0, 0, 10), pricedTx(0, 1, 10), pricedTx(0, 2, 10)}
+	set := ordering.Order(signer, map[common.Address]types.Transactions{testAccounts[0]: txs})
+
+	// Skip the first transaction, the next one should still be available
+	set.Shift()
+	if tx := set.Peek(); tx == nil || tx.Hash() != txs[1].Hash() {
+		t.Fatalf("skipped transaction consumed the sender cap: have %v", tx)
+	}
+	// Commit the second one, which should exhaust the cap
+	set.(txCommitter).Commit()
+	set.Shift()
+	if tx := set.Peek(); tx != nil {
+		t.Fatalf("capped sender not dropped: have nonce %d", tx.Nonce())
+	}
+}
+
+// reverseOrdering is a test strategy including the cheapest transactions first.
+type reverseOrdering struct{}
+
+func (reverseOrdering) Order(signer types.Signer, txs map[common.Address]types.Transactions) TxSet {
+	var flat types.Transactions
+	for _, list := range txs {
+		flat = append(flat, list...)
+	}
+	for i := 0; i < len(flat); i++ {
+		for j := i + 1; j < len(flat); j++ {
+			if flat[j].GasPrice().Cmp(flat[i].GasPrice()) < 0 {
+				flat[i], flat[j] = flat[j], flat[i]
+			}
+		}
+	}
+	return &listTxSet{txs: flat}
+}
+
+// listTxSet is a TxSet over a flat, presorted transaction list.
+type listTxSet struct {
+	txs types.Transactions
+}
+
+func (l *listTxSet) Peek() *types.Transaction {
+	if len(l.txs) == 0 {
+		return nil
+	}
+	return l.txs[0]
+}
+func (l *listTxSet) Shift() { l.txs = l.txs[1:] }
+func (l *listTxSet) Pop()   { l.txs = l.txs[1:] }
+
+// Tests that externally registered ordering strategies can be selected through
+// the miner config.
+func TestWorkerRegisteredOrdering(t *testing.T) {
+	RegisterTxOrdering("reverse", func(*Config) (TxOrdering, error) { return reverseOrdering{}, nil })
+
+	txs := []*types.Transaction{
+		pricedTx(0, 0, 3),
+		pricedTx(1, 0, 1),
+		pricedTx(2, 0, 2),
+	}
+	w, _ := newTestWorker(t, &Config{TxOrdering: "reverse"}, txs)
+	defer w.stop()
+
+	checkTxOrder(t, includedTxs(w), []*types.Transaction{txs[1], txs[2], txs[0]})
+}
+
+// Tests that unknown ordering strategies are rejected.
+func TestUnknownOrdering(t *testing.T) {
+	if _, err := newTxOrdering(&Config{TxOrdering: "nonexistent"}); err == nil {
+		t.Fatalf("unknown ordering accepted")
+	}
+}
+
+// Tests that the FIFO ordering returns transactions in arrival order across
+// accounts, while still honouring nonces within an account.
+func TestFifoOrdering(t *testing.T) {
+	var (
+		signer   = types.NewEIP155Signer(params.AllIrchashProtocolChanges.ChainID)
+		ordering = newFifoOrdering()
+		txs      = []*types.Transaction{
+			pricedTx(1, 0, 1),
+			pricedTx(0, 0, 10),
+			pricedTx(1, 1, 1),
+			pricedTx(2, 0, 5),
+		}
+	)
+	for _, tx := range txs {
+		ordering.TrackTxs([]*types.Transaction{tx})
+		// Ensure distinct timestamps even on coarse clocks
+		ordering.arrivals[tx.Hash()] = ordering.arrivals[tx.Hash()].Add(time.Duration(len(ordering.arrivals)) * time.Millisecond)
+	}
+	pending := map[common.Address]types.Transactions{
+		testAccounts[0]: {txs[1]},
+		testAccounts[1]: {txs[0], txs[2]},
+		testAccounts[2]: {txs[3]},
+	}
+	set := ordering.Order(signer, pending)
+
+	var have []*types.Transaction
+	for tx := set.Peek(); tx != nil; tx = set.Peek() {
+		have = append(have, tx)
+		set.Shift()
+	}
+	checkTxOrder(t, have, txs)
+
+	// Ensure pruning drops everything not pending any more
+	ordering.PruneTxs(map[common.Address]types.Transactions{testAccounts[2]: {txs[3]}})
+	if len(ordering.arrivals) != 1 {
+		t.Errorf("arrival tracker not pruned: have %d entries, want 1", len(ordering.arrivals))
+	}
+}
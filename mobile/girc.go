@@ -71,6 +71,15 @@ type NodeConfig struct {
 	// It has the form "nodename:secret@host:port"
 	IrChainNetStats string
 
+	// IrChainTrustedServers enables the ultra-light client mode if set. Instead
+	// of syncing the header chain, the node accepts heads announced by enough
+	// of these servers.
+	IrChainTrustedServers *Enodes
+
+	// IrChainTrustedFraction is the percentage of trusted servers that need to
+	// announce the same head before it's accepted in ultra-light client mode.
+	IrChainTrustedFraction int
+
 	// WhisperEnabled specifies whether the node should run the Whisper protocol.
 	WhisperEnabled bool
 
@@ -160,6 +169,15 @@ func NewNode(datadir string, config *NodeConfig) (stack *Node, _ error) {
 		ircConf.SyncMode = downloader.LightSync
 		ircConf.NetworkId = uint64(config.IrChainNetworkID)
 		ircConf.DatabaseCache = config.IrChainDatabaseCache
+		if config.IrChainTrustedServers != nil && config.IrChainTrustedServers.Size() > 0 {
+			ircConf.ULC = &irc.ULCConfig{MinTrustedFraction: config.IrChainTrustedFraction}
+			if ircConf.ULC.MinTrustedFraction == 0 {
+				ircConf.ULC.MinTrustedFraction = irc.DefaultULCMinTrustedFraction
+			}
+			for _, node := range config.IrChainTrustedServers.nodes {
+				ircConf.ULC.TrustedServers = append(ircConf.ULC.TrustedServers, node.String())
+			}
+		}
 		if err := rawStack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			return les.New(ctx, &ircConf)
 		}); err != nil {
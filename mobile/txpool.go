@@ -0,0 +1,107 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Contains wrappers for tracking the status of locally sent transactions.
+
+package girc
+
+import (
+	"github.com/irchain/go-irchain/core"
+	"github.com/irchain/go-irchain/les"
+	"github.com/irchain/go-irchain/light"
+)
+
+// Transaction status codes, see TxStatus.GetStatus.
+const (
+	TxStatusUnknown  = int(core.TxStatusUnknown)
+	TxStatusQueued   = int(core.TxStatusQueued)
+	TxStatusPending  = int(core.TxStatusPending)
+	TxStatusIncluded = int(core.TxStatusIncluded)
+)
+
+// TxStatus represents a status change of a locally sent transaction.
+type TxStatus struct {
+	event light.TxStatusEvent
+}
+
+// GetHash returns the hash of the transaction.
+func (s *TxStatus) GetHash() *Hash { return &Hash{s.event.Hash} }
+
+// GetStatus returns the new status of the transaction, one of the TxStatus
+// constants.
+func (s *TxStatus) GetStatus() int { return int(s.event.Status) }
+
+// GetBlockHash returns the hash of the block including the transaction, or nil
+// if it's not included.
+func (s *TxStatus) GetBlockHash() *Hash {
+	if s.event.Lookup == nil {
+		return nil
+	}
+	return &Hash{s.event.Lookup.BlockHash}
+}
+
+// GetBlockNumber returns the number of the block including the transaction, or
+// -1 if it's not included.
+func (s *TxStatus) GetBlockNumber() int64 {
+	if s.event.Lookup == nil {
+		return -1
+	}
+	return int64(s.event.Lookup.BlockIndex)
+}
+
+// GetIndex returns the position of the transaction within its block, or -1 if
+// it's not included.
+func (s *TxStatus) GetIndex() int64 {
+	if s.event.Lookup == nil {
+		return -1
+	}
+	return int64(s.event.Lookup.Index)
+}
+
+// TxStatusHandler is a client-side subscription callback to invoke on status
+// changes of locally sent transactions.
+type TxStatusHandler interface {
+	OnTxStatus(status *TxStatus)
+	OnError(failure string)
+}
+
+// SubscribeTxStatus subscribes to status changes of the transactions sent
+// through the node. Pending transactions are tracked across restarts.
+func (n *Node) SubscribeTxStatus(handler TxStatusHandler, buffer int) (*Subscription, error) {
+	var lirc *les.LightIrChain
+	if err := n.node.Service(&lirc); err != nil {
+		return nil, err
+	}
+	ch := make(chan light.TxStatusEvent, buffer)
+	sub := lirc.TxPool().SubscribeTxStatusEvent(ch)
+
+	// Start up a dispatcher to feed into the callback
+	go func() {
+		for {
+			select {
+			case ev := <-ch:
+				handler.OnTxStatus(&TxStatus{ev})
+
+			case err, ok := <-sub.Err():
+				if ok {
+					handler.OnError(err.Error())
+				}
+				return
+			}
+		}
+	}()
+	return &Subscription{sub}, nil
+}
@@ -75,6 +75,60 @@ func (api *PrivateAdminAPI) RemovePeer(url string) (bool, error) {
 	return true, nil
 }
 
+// BanPeer bans a remote node for the given number of seconds, disconnecting it
+// if connected. A zero duration bans the node persistently. The node may be
+// given either as an hnode URL or as a hex node ID.
+func (api *PrivateAdminAPI) BanPeer(node string, seconds uint64) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	if err := server.BanPeer(id, time.Duration(seconds)*time.Second); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unban lifts the ban of a remote node, returning whether it was banned.
+func (api *PrivateAdminAPI) Unban(node string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	id, err := parseNodeID(node)
+	if err != nil {
+		return false, err
+	}
+	return server.UnbanPeer(id)
+}
+
+// ListBans retrieves all active node bans.
+func (api *PrivateAdminAPI) ListBans() ([]*p2p.BanInfo, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Bans(), nil
+}
+
+// parseNodeID accepts either an hnode URL or a hex encoded node ID.
+func parseNodeID(node string) (discover.NodeID, error) {
+	if id, err := discover.HexID(node); err == nil {
+		return id, nil
+	}
+	n, err := discover.ParseNode(node)
+	if err != nil {
+		return discover.NodeID{}, fmt.Errorf("invalid hnode: %v", err)
+	}
+	return n.ID, nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *PrivateAdminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
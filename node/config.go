@@ -18,6 +18,7 @@ package node
 
 import (
 	"crypto/ecdsa"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -33,8 +34,11 @@ import (
 	"github.com/irchain/go-irchain/log"
 	"github.com/irchain/go-irchain/p2p"
 	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/rpc"
 )
 
+const minJWTSecretLength = 32 // Minimum length in bytes of the RPC authentication secret
+
 const (
 	datadirPrivateKey      = "nodekey"            // Path within the datadir to the node's private key
 	datadirDefaultKeyStore = "keystore"           // Path within the datadir to the keystore
@@ -145,6 +149,28 @@ type Config struct {
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
 
+	// GraphQL enables the GraphQL query service on /graphql of the HTTP RPC
+	// endpoint. It requires the HTTP RPC endpoint to be enabled.
+	GraphQL bool `toml:",omitempty"`
+
+	// GraphQLUI serves the in-browser query editor on /graphql/ui.
+	GraphQLUI bool `toml:",omitempty"`
+
+	// JWTSecret is the path of a file holding the hex encoded secret used to
+	// authenticate HTTP and websocket RPC requests. If set, requests must carry an
+	// HS256 signed bearer token with a recent issuance time, optionally restricting
+	// the API namespaces it may call. Relative paths are resolved in the instance
+	// directory.
+	JWTSecret string `toml:",omitempty"`
+
+	// RPCAccessPolicy restricts the methods callable via the HTTP and websocket
+	// RPC interfaces and throttles them per client.
+	RPCAccessPolicy *rpc.AccessPolicy `toml:",omitempty"`
+
+	// RPCLimits bound the batch length, response size and execution time of the
+	// requests served via the HTTP, websocket and IPC interfaces.
+	RPCLimits rpc.ServerLimits `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -292,6 +318,30 @@ func (c *Config) instanceDir() string {
 	return filepath.Join(c.DataDir, c.name())
 }
 
+// JWTSecretKey loads the secret configured to authenticate RPC requests, returning
+// nil if authentication is disabled.
+func (c *Config) JWTSecretKey() ([]byte, error) {
+	if c.JWTSecret == "" {
+		return nil, nil
+	}
+	path := c.resolvePath(c.JWTSecret)
+	if path == "" {
+		path = c.JWTSecret
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT secret: %v", err)
+	}
+	secret, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT secret: %v", err)
+	}
+	if len(secret) < minJWTSecretLength {
+		return nil, fmt.Errorf("JWT secret too short: have %d bytes, want at least %d", len(secret), minJWTSecretLength)
+	}
+	return secret, nil
+}
+
 // NodeKey retrieves the currently configured private key of the node, checking
 // first any manually set key, falling back to the one found in the configured
 // data folder. If no key can be found, a new one is generated.
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/irchain/go-irchain/crypto"
@@ -148,3 +149,40 @@ func TestNodeKeyPersistency(t *testing.T) {
 		t.Fatalf("ephemeral node key persisted to disk")
 	}
 }
+
+// Tests that the RPC authentication secret is loaded from the configured file
+// and rejected if malformed.
+func TestJWTSecretLoading(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &Config{Name: "unit-test", DataDir: dir}
+	if secret, err := config.JWTSecretKey(); secret != nil || err != nil {
+		t.Fatalf("authentication enabled without secret: %x, %v", secret, err)
+	}
+	tests := []struct {
+		content string
+		valid   bool
+	}{
+		{"0x" + strings.Repeat("ab", 32) + "\n", true},
+		{strings.Repeat("ab", 32), true},
+		{strings.Repeat("ab", 16), false},
+		{strings.Repeat("zz", 32), false},
+	}
+	config.JWTSecret = filepath.Join(dir, "jwtsecret")
+	for i, tt := range tests {
+		if err := ioutil.WriteFile(config.JWTSecret, []byte(tt.content), 0600); err != nil {
+			t.Fatalf("failed to write secret: %v", err)
+		}
+		secret, err := config.JWTSecretKey()
+		if tt.valid && (err != nil || !bytes.Equal(secret, bytes.Repeat([]byte{0xab}, 32))) {
+			t.Errorf("test %d: secret mismatch: %x, %v", i, secret, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("test %d: invalid secret accepted", i)
+		}
+	}
+}
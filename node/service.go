@@ -17,6 +17,7 @@
 package node
 
 import (
+	"net/http"
 	"reflect"
 
 	"github.com/irchain/go-irchain/accounts"
@@ -96,3 +97,11 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPService is an optional interface for services answering plain HTTP requests
+// besides their RPC APIs. The handlers are served on /<namespace> of the HTTP RPC
+// endpoint, subject to its authentication, access policy and resource limits.
+type HTTPService interface {
+	// HTTPHandlers retrieves the handlers to serve, keyed by their namespace.
+	HTTPHandlers() map[string]http.Handler
+}
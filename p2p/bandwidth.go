@@ -0,0 +1,374 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/common/mclock"
+)
+
+// rateMeterWindow is the time constant of the moving average used to estimate
+// the current bandwidth usage.
+const rateMeterWindow = 10 * time.Second
+
+// maxIngressDelay caps the time inbound messages are held back. The remote side
+// can't write while reads are throttled, so the delay must stay well below its
+// frameWriteTimeout.
+const maxIngressDelay = frameWriteTimeout / 4
+
+var errThrottleClosed = errors.New("shutting down")
+
+// BandwidthUsage is a summary of the traffic of a connection or protocol.
+type BandwidthUsage struct {
+	IngressRate uint64 `json:"ingressRate"` // Recent average inbound traffic in bytes per second
+	EgressRate  uint64 `json:"egressRate"`  // Recent average outbound traffic in bytes per second
+	Ingress     uint64 `json:"ingress"`     // Total inbound traffic in bytes
+	Egress      uint64 `json:"egress"`      // Total outbound traffic in bytes
+}
+
+// PeerBandwidth is the bandwidth usage of a peer, also broken down by the
+// running sub-protocols.
+type PeerBandwidth struct {
+	BandwidthUsage
+	Protocols map[string]BandwidthUsage `json:"protocols"`
+}
+
+// limiter is a bandwidth limit. Reservations take bytes from the limit ahead of
+// a transfer, returning the time to wait until the transfer may start.
+type limiter interface {
+	reserve(n uint32) time.Duration
+}
+
+// rateLimiter is a token bucket limiting the throughput of a byte stream. The
+// bucket may go into debt, delaying subsequent reservations until it is paid
+// back, so messages larger than the burst size are still allowed through.
+type rateLimiter struct {
+	rate  float64 // Bytes per second
+	burst float64 // Maximum number of bytes accumulated while idle
+
+	lock   sync.Mutex
+	tokens float64
+	last   mclock.AbsTime
+}
+
+// newRateLimiter creates a limiter for the given rate in bytes per second. It
+// returns nil if the rate is zero, meaning unlimited.
+func newRateLimiter(rate uint64) *rateLimiter {
+	if rate == 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:   float64(rate),
+		burst:  float64(rate),
+		tokens: float64(rate),
+		last:   mclock.Now(),
+	}
+}
+
+// reserve takes n bytes from the bucket and returns the time until they are
+// available.
+func (l *rateLimiter) reserve(n uint32) time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := mclock.Now()
+	l.tokens = math.Min(l.burst, l.tokens+l.rate*time.Duration(now-l.last).Seconds())
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// throttle reserves n bytes from all limiters and waits until they are
+// available or quit is closed. A non-zero max caps the wait, the limiters stay
+// in debt for the remainder and hold back later messages instead.
+func throttle(limiters []limiter, n uint32, max time.Duration, quit <-chan struct{}) error {
+	var delay time.Duration
+	for _, l := range limiters {
+		if d := l.reserve(n); d > delay {
+			delay = d
+		}
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+	if delay == 0 {
+		return nil
+	}
+	throttleTimer.Update(delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-quit:
+		return errThrottleClosed
+	}
+}
+
+// appendLimiter appends l to limiters unless it is nil.
+func appendLimiter(limiters []limiter, l *rateLimiter) []limiter {
+	if l == nil {
+		return limiters
+	}
+	return append(limiters, l)
+}
+
+// shareLimiter divides a bandwidth limit among protocols by weight. As long as
+// the limit isn't exhausted, every protocol may use as much of it as it likes,
+// borrowing the bandwidth left unused by the others. Once it is, each protocol
+// is still granted its weighted share of the rate, while protocols beyond their
+// share wait for the limit to recover.
+type shareLimiter struct {
+	total  *rateLimiter
+	shares map[string]*rateLimiter
+}
+
+// newShareLimiter splits rate among the protocols with the given weights. It
+// returns nil if the rate is zero, meaning unlimited.
+func newShareLimiter(rate uint64, weights map[string]uint) *shareLimiter {
+	if rate == 0 {
+		return nil
+	}
+	var total uint64
+	for _, w := range weights {
+		total += uint64(w)
+	}
+	if total == 0 {
+		total = 1
+	}
+	s := &shareLimiter{
+		total:  newRateLimiter(rate),
+		shares: make(map[string]*rateLimiter, len(weights)),
+	}
+	for name, w := range weights {
+		s.shares[name] = newRateLimiter(rate * uint64(w) / total)
+	}
+	return s
+}
+
+// appendTo appends the limiter of a protocol to limiters. It is a no-op on nil
+// share limiters.
+func (s *shareLimiter) appendTo(limiters []limiter, name string) []limiter {
+	if s == nil {
+		return limiters
+	}
+	return append(limiters, &protoShare{total: s.total, own: s.shares[name]})
+}
+
+// protoShare is the view of a shareLimiter used by a single protocol.
+type protoShare struct {
+	total, own *rateLimiter // own is nil for zero weight protocols
+}
+
+func (p *protoShare) reserve(n uint32) time.Duration {
+	delay := p.total.reserve(n)
+	if delay == 0 || p.own == nil {
+		return delay
+	}
+	// The limit is exhausted, only the protocol's own share is granted. The share
+	// is only charged under contention, so borrowing doesn't eat into it.
+	if own := p.own.reserve(n); own < delay {
+		return own
+	}
+	return delay
+}
+
+// rateMeter measures traffic as an exponentially decaying moving average.
+type rateMeter struct {
+	lock  sync.Mutex
+	total uint64
+	value float64 // Decayed number of bytes
+	last  mclock.AbsTime
+}
+
+// mark records n bytes of traffic. It is a no-op on nil meters.
+func (m *rateMeter) mark(n uint32) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	now := mclock.Now()
+	m.value = m.decayed(now) + float64(n)
+	m.last = now
+	m.total += uint64(n)
+}
+
+// usage returns the current rate in bytes per second and the total traffic.
+func (m *rateMeter) usage() (rate uint64, total uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return uint64(m.decayed(mclock.Now()) / rateMeterWindow.Seconds()), m.total
+}
+
+func (m *rateMeter) decayed(now mclock.AbsTime) float64 {
+	if m.value == 0 {
+		return 0
+	}
+	return m.value * math.Exp(-float64(now-m.last)/float64(rateMeterWindow))
+}
+
+// bandwidthUsage summarizes an ingress and an egress meter.
+func bandwidthUsage(in, out *rateMeter) BandwidthUsage {
+	var u BandwidthUsage
+	u.IngressRate, u.Ingress = in.usage()
+	u.EgressRate, u.Egress = out.usage()
+	return u
+}
+
+// bandwidth holds the server wide bandwidth limits.
+type bandwidth struct {
+	peerIngress, peerEgress uint64 // Per-peer caps
+	ingress, egress         *rateLimiter
+	shares                  map[string]uint
+	protoIngress            *shareLimiter // Global protocol shares
+	protoEgress             *shareLimiter
+}
+
+// newBandwidth creates the bandwidth limits of a server. If protocol shares are
+// configured, the global caps are divided among the given protocols.
+func newBandwidth(cfg *Config) *bandwidth {
+	b := &bandwidth{
+		peerIngress: cfg.MaxPeerIngressRate,
+		peerEgress:  cfg.MaxPeerEgressRate,
+		ingress:     newRateLimiter(cfg.MaxIngressRate),
+		egress:      newRateLimiter(cfg.MaxEgressRate),
+		shares:      cfg.ProtocolShares,
+	}
+	if len(b.shares) == 0 {
+		return b
+	}
+	names := make([]string, len(cfg.Protocols))
+	for i, proto := range cfg.Protocols {
+		names[i] = proto.Name
+	}
+	weights := b.weights(names)
+	b.protoIngress = newShareLimiter(cfg.MaxIngressRate, weights)
+	b.protoEgress = newShareLimiter(cfg.MaxEgressRate, weights)
+	return b
+}
+
+// share returns the bandwidth share of a protocol, defaulting to one.
+func (b *bandwidth) share(name string) uint {
+	if share, ok := b.shares[name]; ok {
+		return share
+	}
+	return 1
+}
+
+// weights returns the bandwidth shares of the given protocols.
+func (b *bandwidth) weights(names []string) map[string]uint {
+	weights := make(map[string]uint, len(names))
+	for _, name := range names {
+		weights[name] = b.share(name)
+	}
+	return weights
+}
+
+// newPeer creates the bandwidth tracker of a new connection.
+func (b *bandwidth) newPeer() *peerBandwidth {
+	return &peerBandwidth{
+		limits:  b,
+		ingress: appendLimiter(appendLimiter(nil, b.ingress), newRateLimiter(b.peerIngress)),
+		egress:  appendLimiter(appendLimiter(nil, b.egress), newRateLimiter(b.peerEgress)),
+	}
+}
+
+// peerBandwidth tracks the bandwidth limits and usage of a connection.
+type peerBandwidth struct {
+	limits          *bandwidth
+	ingress, egress []limiter // Global and per-peer caps
+	in, out         rateMeter
+}
+
+// assign sets up the bandwidth shares of the protocols running on the peer.
+// Without configured shares, protocols are only metered.
+func (pb *peerBandwidth) assign(protocols map[string]*protoRW) {
+	b := pb.limits
+	if len(b.shares) == 0 {
+		return
+	}
+	names := make([]string, 0, len(protocols))
+	for name := range protocols {
+		names = append(names, name)
+	}
+	var (
+		weights = b.weights(names)
+		ingress = newShareLimiter(b.peerIngress, weights)
+		egress  = newShareLimiter(b.peerEgress, weights)
+	)
+	for name, rw := range protocols {
+		rw.ingress = ingress.appendTo(b.protoIngress.appendTo(nil, name), name)
+		rw.egress = egress.appendTo(b.protoEgress.appendTo(nil, name), name)
+	}
+}
+
+// limitedTransport is a transport enforcing bandwidth limits on whole messages.
+// Throttling happens before the underlying transport sets its I/O deadlines.
+//
+// Message sizes are only known once they are read, so inbound messages are
+// throttled after reading them: a message exceeding the ingress limit is taken
+// off the wire in full, delaying the read of the next one. The sender is held
+// back by TCP flow control meanwhile, so the delay is capped at maxIngressDelay
+// to let its pending writes complete before they time out.
+type limitedTransport struct {
+	transport
+	bw        *peerBandwidth
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newLimitedTransport(t transport, bw *peerBandwidth) transport {
+	return &limitedTransport{transport: t, bw: bw, closed: make(chan struct{})}
+}
+
+// ReadMsg reads the next message and waits until the ingress limits allow for
+// its size before returning it.
+func (t *limitedTransport) ReadMsg() (Msg, error) {
+	msg, err := t.transport.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	t.bw.in.mark(msg.Size)
+	return msg, throttle(t.bw.ingress, msg.Size, maxIngressDelay, t.closed)
+}
+
+func (t *limitedTransport) WriteMsg(msg Msg) error {
+	if err := throttle(t.bw.egress, msg.Size, 0, t.closed); err != nil {
+		return err
+	}
+	if err := t.transport.WriteMsg(msg); err != nil {
+		return err
+	}
+	t.bw.out.mark(msg.Size)
+	return nil
+}
+
+func (t *limitedTransport) close(err error) {
+	t.closeOnce.Do(func() { close(t.closed) })
+	t.transport.close(err)
+}
@@ -0,0 +1,150 @@
+// Copyright 2015 The happyuc-go Authors
+// This file is part of the happyuc-go library.
+//
+// The happyuc-go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The happyuc-go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the happyuc-go library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterReserve(t *testing.T) {
+	if l := newRateLimiter(0); l != nil {
+		t.Fatal("zero rate limiter should be nil")
+	}
+	l := newRateLimiter(1000)
+	if d := l.reserve(1000); d != 0 {
+		t.Fatalf("burst reservation delayed by %v", d)
+	}
+	// The bucket is empty now, the next 500 bytes take half a second.
+	d := l.reserve(500)
+	if d < 400*time.Millisecond || d > 500*time.Millisecond {
+		t.Fatalf("wrong delay %v, want ~500ms", d)
+	}
+	// Debt accumulates.
+	d = l.reserve(1000)
+	if d < 1400*time.Millisecond || d > 1500*time.Millisecond {
+		t.Fatalf("wrong delay %v, want ~1.5s", d)
+	}
+}
+
+func TestThrottleQuit(t *testing.T) {
+	l := newRateLimiter(10)
+	quit := make(chan struct{})
+	close(quit)
+	if err := throttle([]limiter{l}, 10, 0, quit); err != nil {
+		t.Fatalf("burst throttled: %v", err)
+	}
+	if err := throttle([]limiter{l}, 1000, 0, quit); err != errThrottleClosed {
+		t.Fatalf("wrong error %v, want %v", err, errThrottleClosed)
+	}
+	if err := throttle(nil, 1000, 0, quit); err != nil {
+		t.Fatalf("unlimited throttle failed: %v", err)
+	}
+}
+
+func TestThrottleMaxDelay(t *testing.T) {
+	l := newRateLimiter(10)
+	start := time.Now()
+	if err := throttle([]limiter{l}, 1000, 50*time.Millisecond, nil); err != nil {
+		t.Fatalf("throttle failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("throttle not capped, waited %v", elapsed)
+	}
+	// The limiter stays in debt for the remainder.
+	if d := l.reserve(0); d < 90*time.Second {
+		t.Fatalf("debt not kept, delay %v", d)
+	}
+}
+
+func TestRateMeter(t *testing.T) {
+	var m rateMeter
+	m.mark(1000)
+	m.mark(500)
+	rate, total := m.usage()
+	if total != 1500 {
+		t.Fatalf("wrong total %d, want 1500", total)
+	}
+	if want := uint64(1500 / rateMeterWindow.Seconds()); rate != want && rate != want-1 {
+		t.Fatalf("wrong rate %d, want %d", rate, want)
+	}
+}
+
+// Tests that protocols may borrow unused bandwidth, but are granted their share
+// once the limit is exhausted.
+func TestShareLimiterBorrowing(t *testing.T) {
+	s := newShareLimiter(1000, map[string]uint{"les": 3, "irc": 1})
+	var les, irc []limiter
+	les = s.appendTo(les, "les")
+	irc = s.appendTo(irc, "irc")
+
+	// Without contention, irc may use the whole limit
+	if d := irc[0].reserve(1000); d != 0 {
+		t.Fatalf("borrowing delayed by %v", d)
+	}
+	// The limit is exhausted, les is still granted its share
+	if d := les[0].reserve(750); d != 0 {
+		t.Fatalf("share delayed by %v", d)
+	}
+	// Beyond its share, les waits for the limit to recover
+	if d := les[0].reserve(750); d < 900*time.Millisecond || d > time.Second {
+		t.Fatalf("excess delayed by %v, want ~1s", d)
+	}
+	// irc only has its own share left, which borrowing didn't use up
+	if d := irc[0].reserve(250); d != 0 {
+		t.Fatalf("share delayed by %v", d)
+	}
+	if d := irc[0].reserve(250); d < 900*time.Millisecond || d > time.Second {
+		t.Fatalf("excess delayed by %v, want ~1s", d)
+	}
+}
+
+func TestBandwidthShares(t *testing.T) {
+	cfg := &Config{
+		MaxEgressRate:     4000,
+		MaxPeerEgressRate: 1000,
+		ProtocolShares:    map[string]uint{"les": 3},
+		Protocols:         []Protocol{{Name: "les", Version: 1}, {Name: "les", Version: 2}, {Name: "irc"}},
+	}
+	b := newBandwidth(cfg)
+	if b.protoEgress.total.rate != 4000 {
+		t.Fatalf("wrong global limit: %v", b.protoEgress.total.rate)
+	}
+	if b.protoEgress.shares["les"].rate != 3000 || b.protoEgress.shares["irc"].rate != 1000 {
+		t.Fatalf("wrong global shares: les %v, irc %v", b.protoEgress.shares["les"].rate, b.protoEgress.shares["irc"].rate)
+	}
+	if b.protoIngress != nil {
+		t.Fatal("ingress share limited without ingress cap")
+	}
+	pb := b.newPeer()
+	if len(pb.egress) != 2 || len(pb.ingress) != 0 {
+		t.Fatalf("wrong peer limiter count: %d egress, %d ingress", len(pb.egress), len(pb.ingress))
+	}
+	// A peer only running les gets the whole peer cap.
+	rw := &protoRW{Protocol: cfg.Protocols[1]}
+	pb.assign(map[string]*protoRW{"les": rw})
+	if len(rw.egress) != 2 || rw.egress[1].(*protoShare).own.rate != 1000 {
+		t.Fatalf("wrong les limiters: %v", rw.egress)
+	}
+	// Without shares, protocols are not limited individually.
+	cfg.ProtocolShares = nil
+	rw = &protoRW{Protocol: cfg.Protocols[1]}
+	newBandwidth(cfg).newPeer().assign(map[string]*protoRW{"les": rw})
+	if len(rw.egress) != 0 {
+		t.Fatal("protocol limited without shares")
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+const (
+	defaultCaptureFileSize = 64 * 1024 * 1024 // Default size at which capture files are rotated
+	captureFiles           = 5                // Number of capture files kept, including the current one
+)
+
+// CaptureRecord is a sub-protocol message recorded by a capturing server.
+// Capture files are streams of RLP encoded records.
+type CaptureRecord struct {
+	Time     uint64          // Unix time of the message in nanoseconds
+	Peer     discover.NodeID // Remote end of the connection
+	Protocol string          // Name of the sub-protocol
+	Version  uint            // Version of the sub-protocol
+	Inbound  bool            // Whether the message was received or sent
+	Code     uint64          // Message code relative to the sub-protocol
+	Payload  []byte          // RLP encoded message content
+}
+
+// CaptureReader decodes capture records from a stream.
+type CaptureReader struct {
+	s *rlp.Stream
+}
+
+// NewCaptureReader creates a reader decoding capture records from r.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{s: rlp.NewStream(r, 0)}
+}
+
+// Next decodes the next record. It returns io.EOF at the end of the stream.
+func (r *CaptureReader) Next() (*CaptureRecord, error) {
+	rec := new(CaptureRecord)
+	if err := r.s.Decode(rec); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// captureWriter writes capture records into a file, rotating it whenever it
+// exceeds the size limit. Rotated files are suffixed with increasing numbers,
+// the oldest being deleted.
+type captureWriter struct {
+	path    string
+	maxSize int64
+
+	lock   sync.Mutex
+	file   *os.File
+	size   int64
+	failed bool // Whether a write error was logged already
+}
+
+// newCaptureWriter opens the capture file at path, appending to it if it exists.
+func newCaptureWriter(path string, maxSize int64) (*captureWriter, error) {
+	if maxSize <= 0 {
+		maxSize = defaultCaptureFileSize
+	}
+	w := &captureWriter{path: path, maxSize: maxSize}
+	if err := w.open(os.O_APPEND); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *captureWriter) open(mode int) error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|mode, 0600)
+	if err != nil {
+		return err
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	w.file, w.size = file, stat.Size()
+	return nil
+}
+
+// write appends a record to the capture, rotating the file if necessary.
+// Errors are logged once and otherwise ignored. Records failing to encode stop
+// the capture altogether.
+func (w *captureWriter) write(rec *CaptureRecord) {
+	blob, err := rlp.EncodeToBytes(rec)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file == nil {
+		return // closed
+	}
+	if err != nil {
+		log.Error("Failed to encode capture record, disabling capture", "file", w.path, "err", err)
+		w.file.Close()
+		w.file = nil
+		return
+	}
+	if w.size > 0 && w.size+int64(len(blob)) > w.maxSize {
+		if err = w.rotate(); err != nil {
+			w.fail(err)
+			return
+		}
+	}
+	n, err := w.file.Write(blob)
+	w.size += int64(n)
+	if err != nil {
+		w.fail(err)
+	}
+}
+
+// rotate shifts the older capture files and starts a new one. The caller must
+// hold w.lock.
+func (w *captureWriter) rotate() error {
+	w.file.Close()
+	w.file = nil
+	for i := captureFiles - 1; i > 0; i-- {
+		from := w.path
+		if i > 1 {
+			from = fmt.Sprintf("%s.%d", w.path, i-1)
+		}
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", w.path, i)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return w.open(os.O_TRUNC)
+}
+
+func (w *captureWriter) fail(err error) {
+	if !w.failed {
+		log.Warn("Failed to write message capture", "file", w.path, "err", err)
+		w.failed = true
+	}
+}
+
+// close closes the capture file, ignoring subsequent writes.
+func (w *captureWriter) close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// msgCapturer wraps a MsgReadWriter and records all messages sent or received.
+type msgCapturer struct {
+	MsgReadWriter
+
+	w       *captureWriter
+	peerID  discover.NodeID
+	proto   string
+	version uint
+}
+
+func newMsgCapturer(rw MsgReadWriter, w *captureWriter, peerID discover.NodeID, proto string, version uint) *msgCapturer {
+	return &msgCapturer{MsgReadWriter: rw, w: w, peerID: peerID, proto: proto, version: version}
+}
+
+// ReadMsg reads a message from the underlying MsgReadWriter and records it.
+func (c *msgCapturer) ReadMsg() (Msg, error) {
+	msg, err := c.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	payload, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(payload)
+
+	at := msg.ReceivedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+	c.record(at, true, msg.Code, payload)
+	return msg, nil
+}
+
+// WriteMsg writes a message to the underlying MsgReadWriter and records it.
+func (c *msgCapturer) WriteMsg(msg Msg) error {
+	payload, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	msg.Payload = bytes.NewReader(payload)
+	if err := c.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	c.record(time.Now(), false, msg.Code, payload)
+	return nil
+}
+
+func (c *msgCapturer) record(at time.Time, inbound bool, code uint64, payload []byte) {
+	c.w.write(&CaptureRecord{
+		Time:     uint64(at.UnixNano()),
+		Peer:     c.peerID,
+		Protocol: c.proto,
+		Version:  c.version,
+		Inbound:  inbound,
+		Code:     code,
+		Payload:  payload,
+	})
+}
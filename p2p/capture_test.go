@@ -0,0 +1,127 @@
+// Copyright 2015 The happyuc-go Authors
+// This file is part of the happyuc-go library.
+//
+// The happyuc-go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The happyuc-go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the happyuc-go library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+func TestMsgCapturer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "p2p-capture-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture")
+
+	w, err := newCaptureWriter(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw1, rw2 := MsgPipe()
+	defer rw1.Close()
+	id := discover.NodeID{1}
+	c := newMsgCapturer(rw1, w, id, "test", 3)
+
+	go func() {
+		Send(rw2, 2, []uint{1, 2, 3})
+		ExpectMsg(rw2, 5, "reply")
+	}()
+	if err := ExpectMsg(c, 2, []uint{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(c, 5, "reply"); err != nil {
+		t.Fatal(err)
+	}
+	w.close()
+
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	r := NewCaptureReader(fd)
+	want := []CaptureRecord{
+		{Peer: id, Protocol: "test", Version: 3, Inbound: true, Code: 2, Payload: []byte{0xc3, 1, 2, 3}},
+		{Peer: id, Protocol: "test", Version: 3, Inbound: false, Code: 5, Payload: []byte{0x85, 'r', 'e', 'p', 'l', 'y'}},
+	}
+	for i, w := range want {
+		rec, err := r.Next()
+		if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if rec.Time == 0 {
+			t.Errorf("record %d: missing time", i)
+		}
+		rec.Time = 0
+		if !reflect.DeepEqual(*rec, w) {
+			t.Errorf("record %d mismatch:\ngot  %+v\nwant %+v", i, *rec, w)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected EOF after last record, got %v", err)
+	}
+}
+
+func TestCaptureRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "p2p-capture-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "capture")
+
+	w, err := newCaptureWriter(path, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 30; i++ {
+		w.write(&CaptureRecord{Code: uint64(i), Payload: make([]byte, 100)})
+	}
+	w.close()
+
+	// Every file holds a single record, only the newest ones are kept.
+	for i := 0; i < captureFiles; i++ {
+		name := path
+		if i > 0 {
+			name = fmt.Sprintf("%s.%d", path, i)
+		}
+		fd, err := os.Open(name)
+		if err != nil {
+			t.Fatalf("capture file %d missing: %v", i, err)
+		}
+		rec, err := NewCaptureReader(fd).Next()
+		fd.Close()
+		if err != nil {
+			t.Fatalf("capture file %d: %v", i, err)
+		}
+		if rec.Code != uint64(29-i) {
+			t.Errorf("capture file %d: got record %d, want %d", i, rec.Code, 29-i)
+		}
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.%d", path, captureFiles)); !os.IsNotExist(err) {
+		t.Errorf("too many capture files kept")
+	}
+}
@@ -71,12 +71,15 @@ func (t TCPDialer) Dial(dest *discover.Node) (net.Conn, error) {
 type dialstate struct {
 	maxDynDials int
 	ntab        discoverTable
+	dns         nodeSource                 // DNS discovery node lists, nil if disabled
+	banned      func(discover.NodeID) bool // reports banned nodes, nil if unused
 	netrestrict *netutil.Netlist
 
 	lookupRunning bool
 	dialing       map[discover.NodeID]connFlag
 	lookupBuf     []*discover.Node // current discovery lookup results
 	randomNodes   []*discover.Node // filled from Table
+	dnsNodes      []*discover.Node // filled from DNS node lists
 	static        map[discover.NodeID]*dialTask
 	hist          *dialHistory
 	start         time.Time        // time when the dialer was first used
@@ -91,6 +94,12 @@ type discoverTable interface {
 	ReadRandomNodes([]*discover.Node) int
 }
 
+// nodeSource is a provider of dial candidates beyond the discovery table, such
+// as DNS node lists.
+type nodeSource interface {
+	ReadRandomNodes([]*discover.Node) int
+}
+
 // the dial history remembers recent dials.
 type dialHistory []pastDial
 
@@ -217,7 +226,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	// Use random nodes from the table for half of the necessary
 	// dynamic dials.
 	randomCandidates := needDynDials / 2
-	if randomCandidates > 0 {
+	if randomCandidates > 0 && s.ntab != nil {
 		n := s.ntab.ReadRandomNodes(s.randomNodes)
 		for i := 0; i < randomCandidates && i < n; i++ {
 			if addDial(dynDialedConn, s.randomNodes[i]) {
@@ -225,6 +234,24 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 			}
 		}
 	}
+	// Use random nodes from the DNS node lists for half of the remaining dynamic
+	// dials, or all of them if the discovery table is disabled.
+	if s.dns != nil && needDynDials > 0 {
+		dnsCandidates := (needDynDials + 1) / 2
+		if s.ntab == nil {
+			dnsCandidates = needDynDials
+		}
+		if len(s.dnsNodes) < s.maxDynDials {
+			s.dnsNodes = make([]*discover.Node, s.maxDynDials)
+		}
+		n := s.dns.ReadRandomNodes(s.dnsNodes)
+		for i := 0; i < n && dnsCandidates > 0; i++ {
+			if addDial(dynDialedConn, s.dnsNodes[i]) {
+				needDynDials--
+				dnsCandidates--
+			}
+		}
+	}
 	// Create dynamic dials from random lookup results, removing tried
 	// items from the result buffer.
 	i := 0
@@ -235,7 +262,7 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	}
 	s.lookupBuf = s.lookupBuf[:copy(s.lookupBuf, s.lookupBuf[i:])]
 	// Launch a discovery lookup if more candidates are needed.
-	if len(s.lookupBuf) < needDynDials && !s.lookupRunning {
+	if len(s.lookupBuf) < needDynDials && !s.lookupRunning && s.ntab != nil {
 		s.lookupRunning = true
 		newtasks = append(newtasks, &discoverTask{})
 	}
@@ -244,9 +271,15 @@ func (s *dialstate) newTasks(nRunning int, peers map[discover.NodeID]*Peer, now
 	// candidates have been tried and no task is currently active.
 	// This should prevent cases where the dialer logic is not ticked
 	// because there are no pending events.
-	if nRunning == 0 && len(newtasks) == 0 && s.hist.Len() > 0 {
-		t := &waitExpireTask{s.hist.min().exp.Sub(now)}
-		newtasks = append(newtasks, t)
+	if nRunning == 0 && len(newtasks) == 0 {
+		switch {
+		case s.hist.Len() > 0:
+			t := &waitExpireTask{s.hist.min().exp.Sub(now)}
+			newtasks = append(newtasks, t)
+		case s.dns != nil && needDynDials > 0:
+			// Nothing to dial yet, recheck the DNS node lists later.
+			newtasks = append(newtasks, &waitExpireTask{lookupInterval})
+		}
 	}
 	return newtasks
 }
@@ -257,6 +290,7 @@ var (
 	errAlreadyConnected = errors.New("already connected")
 	errRecentlyDialed   = errors.New("recently dialed")
 	errNotWhitelisted   = errors.New("not contained in netrestrict whitelist")
+	errBanned           = errors.New("banned")
 )
 
 func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer) error {
@@ -272,6 +306,8 @@ func (s *dialstate) checkDial(n *discover.Node, peers map[discover.NodeID]*Peer)
 		return errNotWhitelisted
 	case s.hist.contains(n.ID):
 		return errRecentlyDialed
+	case s.banned != nil && s.banned(n.ID):
+		return errBanned
 	}
 	return nil
 }
@@ -402,6 +402,45 @@ func TestDialStateDynDialFromTable(t *testing.T) {
 	})
 }
 
+// This test checks that dynamic dials are launched from DNS node lists when the
+// discovery table is disabled.
+func TestDialStateDNS(t *testing.T) {
+	dns := fakeTable{
+		{ID: uintID(1)},
+		{ID: uintID(2)},
+		{ID: uintID(3)},
+	}
+	withDNS := newDialState(nil, nil, nil, 5, nil)
+	withDNS.dns = dns
+	runDialTest(t, dialtest{
+		init: withDNS,
+		rounds: []round{
+			// All DNS nodes are dialed, no lookup is launched.
+			{
+				new: []task{
+					&dialTask{flags: dynDialedConn, dest: dns[0]},
+					&dialTask{flags: dynDialedConn, dest: dns[1]},
+					&dialTask{flags: dynDialedConn, dest: dns[2]},
+				},
+			},
+		},
+	})
+
+	empty := newDialState(nil, nil, nil, 5, nil)
+	empty.dns = fakeTable{}
+	runDialTest(t, dialtest{
+		init: empty,
+		rounds: []round{
+			// Nothing to dial yet, the dialer waits for the node lists.
+			{
+				new: []task{
+					&waitExpireTask{Duration: lookupInterval},
+				},
+			},
+		},
+	})
+}
+
 // This test checks that candidates that do not match the netrestrict list are not dialed.
 func TestDialStateNetRestrict(t *testing.T) {
 	// This table always returns the same random nodes
@@ -29,6 +29,7 @@ import (
 
 	"github.com/irchain/go-irchain/crypto"
 	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/enr"
 	"github.com/irchain/go-irchain/rlp"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
@@ -54,13 +55,16 @@ type nodeDB struct {
 
 // Schema layout for the node database
 var (
-	nodeDBVersionKey = []byte("version") // Version of the database to flush if changes
-	nodeDBItemPrefix = []byte("n:")      // Identifier to prefix node entries with
+	nodeDBVersionKey  = []byte("version")   // Version of the database to flush if changes
+	nodeDBItemPrefix  = []byte("n:")        // Identifier to prefix node entries with
+	nodeDBLocalSeqKey = []byte("local:seq") // Sequence number of the local node record
+	nodeDBBanPrefix   = []byte("ban:")      // Identifier to prefix node bans with
 
 	nodeDBDiscoverRoot      = ":discover"
 	nodeDBDiscoverPing      = nodeDBDiscoverRoot + ":lastping"
 	nodeDBDiscoverPong      = nodeDBDiscoverRoot + ":lastpong"
 	nodeDBDiscoverFindFails = nodeDBDiscoverRoot + ":findfail"
+	nodeDBDiscoverENR       = nodeDBDiscoverRoot + ":enr"
 )
 
 // newNodeDB creates a new node database for storing and retrieving infos about
@@ -303,6 +307,77 @@ func (db *nodeDB) updateFindFails(id NodeID, fails int) error {
 	return db.storeInt64(makeKey(id, nodeDBDiscoverFindFails), int64(fails))
 }
 
+// nodeRecord retrieves the last node record retrieved from a node.
+func (db *nodeDB) nodeRecord(id NodeID) *enr.Record {
+	blob, err := db.lvl.Get(makeKey(id, nodeDBDiscoverENR), nil)
+	if err != nil {
+		return nil
+	}
+	record := new(enr.Record)
+	if err := rlp.DecodeBytes(blob, record); err != nil {
+		log.Error("Failed to decode node record RLP", "err", err)
+		return nil
+	}
+	return record
+}
+
+// updateNodeRecord stores the node record retrieved from a node.
+func (db *nodeDB) updateNodeRecord(id NodeID, record *enr.Record) error {
+	blob, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+	return db.lvl.Put(makeKey(id, nodeDBDiscoverENR), blob, nil)
+}
+
+// localSeq retrieves the sequence number of the last signed local node record.
+func (db *nodeDB) localSeq() int64 {
+	return db.fetchInt64(nodeDBLocalSeqKey)
+}
+
+// storeLocalSeq stores the sequence number of the local node record.
+func (db *nodeDB) storeLocalSeq(seq int64) error {
+	return db.storeInt64(nodeDBLocalSeqKey, seq)
+}
+
+// storeBan stores the ban of a node, expiring at the given time or never if it
+// is the zero time.
+func (db *nodeDB) storeBan(id NodeID, until time.Time) error {
+	var expiry int64
+	if !until.IsZero() {
+		expiry = until.Unix()
+	}
+	return db.storeInt64(append(nodeDBBanPrefix, id[:]...), expiry)
+}
+
+// deleteBan removes the ban of a node.
+func (db *nodeDB) deleteBan(id NodeID) error {
+	return db.lvl.Delete(append(nodeDBBanPrefix, id[:]...), nil)
+}
+
+// bans retrieves all stored node bans, persistent ones having zero expiry.
+func (db *nodeDB) bans() map[NodeID]time.Time {
+	bans := make(map[NodeID]time.Time)
+
+	it := db.lvl.NewIterator(util.BytesPrefix(nodeDBBanPrefix), nil)
+	defer it.Release()
+
+	for it.Next() {
+		var id NodeID
+		if len(it.Key()) != len(nodeDBBanPrefix)+len(id) {
+			continue
+		}
+		copy(id[:], it.Key()[len(nodeDBBanPrefix):])
+
+		var until time.Time
+		if expiry, _ := binary.Varint(it.Value()); expiry != 0 {
+			until = time.Unix(expiry, 0)
+		}
+		bans[id] = until
+	}
+	return bans
+}
+
 // querySeeds retrieves random nodes to be used as potential seed nodes
 // for bootstrapping.
 func (db *nodeDB) querySeeds(n int, maxAge time.Duration) []*Node {
@@ -378,3 +378,31 @@ func TestNodeDBSelfExpiration(t *testing.T) {
 		t.Errorf("self not evacuated")
 	}
 }
+
+func TestNodeDBBans(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	temp, perm := NodeID{1}, NodeID{2}
+	until := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	if err := db.storeBan(temp, until); err != nil {
+		t.Fatalf("failed to store temporary ban: %v", err)
+	}
+	if err := db.storeBan(perm, time.Time{}); err != nil {
+		t.Fatalf("failed to store persistent ban: %v", err)
+	}
+	bans := db.bans()
+	if len(bans) != 2 || !bans[temp].Equal(until) || !bans[perm].IsZero() {
+		t.Fatalf("ban mismatch: %v", bans)
+	}
+	// Bans must survive node expiration.
+	if err := db.expireNodes(); err != nil {
+		t.Fatalf("failed to expire nodes: %v", err)
+	}
+	if err := db.deleteBan(temp); err != nil {
+		t.Fatalf("failed to delete ban: %v", err)
+	}
+	if bans := db.bans(); len(bans) != 1 || !bans[perm].IsZero() {
+		t.Fatalf("ban mismatch after delete: %v", bans)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/enr"
+	"github.com/irchain/go-irchain/rlp"
+)
+
+const (
+	// Endpoint predictions are made from the external endpoints reported in
+	// pong packets. Only the most recent statement of each node is kept.
+	endpointStatements = 20 // Maximum number of tracked endpoint statements
+	endpointMinVotes   = 5  // Minimum number of agreeing statements to change the local endpoint
+)
+
+// localNode maintains the signed node record (EIP-778) of the local node. The
+// record is re-signed with an increased sequence number whenever its content
+// changes, the sequence number being persisted in the node database so it
+// keeps growing across restarts.
+type localNode struct {
+	priv *ecdsa.PrivateKey
+	db   *nodeDB
+
+	mu         sync.Mutex
+	entries    map[string]enr.Entry // all key/value pairs of the record
+	record     *enr.Record          // current signed record
+	statements map[NodeID]string    // external endpoints reported by remote nodes
+	order      []NodeID             // statement insertion order for eviction
+}
+
+// newLocalNode creates the local node with the given endpoint and additional
+// record entries. The record is only signed once the node database is known.
+func newLocalNode(priv *ecdsa.PrivateKey, endpoint rpcEndpoint, entries []enr.Entry) *localNode {
+	ln := &localNode{
+		priv:       priv,
+		entries:    make(map[string]enr.Entry),
+		statements: make(map[NodeID]string),
+	}
+	for _, e := range entries {
+		ln.entries[e.ENRKey()] = e
+	}
+	ln.setEndpointLocked(endpoint)
+	return ln
+}
+
+// init attaches the node database and signs the first version of the record.
+func (ln *localNode) init(db *nodeDB) error {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
+	ln.db = db
+	return ln.sign()
+}
+
+// Record returns the current signed record of the local node.
+func (ln *localNode) Record() *enr.Record {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
+	return ln.record
+}
+
+// Seq returns the sequence number of the current local record, or zero if it
+// isn't signed yet.
+func (ln *localNode) Seq() uint64 {
+	if r := ln.Record(); r != nil {
+		return r.Seq()
+	}
+	return 0
+}
+
+// Set adds or replaces the given entries in the local record, re-signing it if
+// anything changed.
+func (ln *localNode) Set(entries ...enr.Entry) error {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
+	changed := false
+	for _, e := range entries {
+		if old, ok := ln.entries[e.ENRKey()]; !ok || !sameEntry(old, e) {
+			ln.entries[e.ENRKey()] = e
+			changed = true
+		}
+	}
+	if !changed || ln.db == nil {
+		return nil
+	}
+	return ln.sign()
+}
+
+// setEndpointLocked updates the IP and port entries. The caller must hold ln.mu.
+func (ln *localNode) setEndpointLocked(endpoint rpcEndpoint) {
+	if endpoint.IP != nil && !endpoint.IP.IsUnspecified() {
+		ln.entries["ip"] = enr.IP(endpoint.IP)
+	}
+	if endpoint.UDP != 0 {
+		ln.entries["udp"] = enr.UDP(endpoint.UDP)
+	}
+	if endpoint.TCP != 0 {
+		ln.entries["tcp"] = enr.TCP(endpoint.TCP)
+	}
+}
+
+// addStatement records the external UDP endpoint of the local node as reported
+// by a remote node. If enough distinct nodes agree on an endpoint differing from
+// the one in the local record, the IP and UDP port entries are updated.
+func (ln *localNode) addStatement(from NodeID, endpoint rpcEndpoint) {
+	ip := endpoint.IP
+	if ip == nil || ip.IsUnspecified() || ip.IsLoopback() || endpoint.UDP == 0 {
+		return
+	}
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+
+	if _, ok := ln.statements[from]; !ok {
+		if len(ln.order) >= endpointStatements {
+			delete(ln.statements, ln.order[0])
+			ln.order = ln.order[1:]
+		}
+		ln.order = append(ln.order, from)
+	}
+	ln.statements[from] = (&net.UDPAddr{IP: ip, Port: int(endpoint.UDP)}).String()
+
+	// Find the most reported endpoint and check whether it has a majority
+	votes := make(map[string]int)
+	best, bestVotes := "", 0
+	for _, s := range ln.statements {
+		votes[s]++
+		if votes[s] > bestVotes {
+			best, bestVotes = s, votes[s]
+		}
+	}
+	if bestVotes < endpointMinVotes || 2*bestVotes <= len(ln.statements) {
+		return
+	}
+	addr, err := net.ResolveUDPAddr("udp", best)
+	if err != nil {
+		return
+	}
+	curIP, _ := ln.entries["ip"].(enr.IP)
+	curPort, _ := ln.entries["udp"].(enr.UDP)
+	if net.IP(curIP).Equal(addr.IP) && int(curPort) == addr.Port {
+		return
+	}
+	log.Info("Updating local node endpoint", "ip", addr.IP, "udp", addr.Port, "votes", bestVotes)
+	ln.entries["ip"] = enr.IP(addr.IP)
+	ln.entries["udp"] = enr.UDP(addr.Port)
+	if ln.db == nil {
+		return
+	}
+	if err := ln.sign(); err != nil {
+		log.Error("Failed to sign local node record", "err", err)
+	}
+}
+
+// sign assembles and signs a new version of the record with the next sequence
+// number. The caller must hold ln.mu.
+func (ln *localNode) sign() error {
+	var (
+		record = new(enr.Record)
+		seq    = uint64(ln.db.localSeq()) + 1
+	)
+	if ln.record != nil && ln.record.Seq() >= seq {
+		seq = ln.record.Seq() + 1
+	}
+	record.SetSeq(seq)
+	for _, e := range ln.entries {
+		record.Set(e)
+	}
+	if err := enr.SignV4(record, ln.priv); err != nil {
+		return err
+	}
+	ln.record = record
+	return ln.db.storeLocalSeq(int64(seq))
+}
+
+// sameEntry reports whether two entries of the same key have the same value.
+func sameEntry(a, b enr.Entry) bool {
+	enca, erra := rlp.EncodeToBytes(a)
+	encb, errb := rlp.EncodeToBytes(b)
+	return erra == nil && errb == nil && bytes.Equal(enca, encb)
+}
+
+// LocalRecord returns the current signed node record of the local node. It
+// returns nil if the table isn't backed by a UDP transport.
+func (tab *Table) LocalRecord() *enr.Record {
+	if tab.local == nil {
+		return nil
+	}
+	return tab.local.Record()
+}
+
+// SetLocalEntries adds or replaces entries of the local node record. The record
+// is re-signed with an increased sequence number if its content changed.
+func (tab *Table) SetLocalEntries(entries ...enr.Entry) error {
+	if tab.local == nil {
+		return errors.New("no local node record")
+	}
+	return tab.local.Set(entries...)
+}
@@ -0,0 +1,103 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"net"
+	"testing"
+
+	"github.com/irchain/go-irchain/p2p/enr"
+)
+
+func newTestLocalNode(t *testing.T, db *nodeDB) *localNode {
+	ln := newLocalNode(newkey(), rpcEndpoint{IP: net.IP{10, 0, 0, 1}, UDP: 30303, TCP: 30303}, nil)
+	if err := ln.init(db); err != nil {
+		t.Fatal(err)
+	}
+	return ln
+}
+
+// Tests that the local record is only re-signed when its content changes, and
+// that the sequence number survives restarts.
+func TestLocalNodeSeq(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	ln := newTestLocalNode(t, db)
+	if seq := ln.Seq(); seq != 1 {
+		t.Fatalf("wrong initial seq: have %d, want 1", seq)
+	}
+	ln.Set(enr.TCP(30303))
+	if seq := ln.Seq(); seq != 1 {
+		t.Fatalf("seq changed without content change: have %d, want 1", seq)
+	}
+	ln.Set(enr.TCP(30304))
+	if seq := ln.Seq(); seq != 2 {
+		t.Fatalf("seq not increased on content change: have %d, want 2", seq)
+	}
+	var tcp enr.TCP
+	if err := ln.Record().Load(&tcp); err != nil || tcp != 30304 {
+		t.Fatalf("wrong tcp entry: have %d (%v), want 30304", tcp, err)
+	}
+	// Recreating the local node with the same database must continue the sequence
+	if seq := newTestLocalNode(t, db).Seq(); seq != 3 {
+		t.Fatalf("seq not persisted: have %d, want 3", seq)
+	}
+}
+
+// Tests that the local endpoint is updated once enough remote nodes agree on it.
+func TestLocalNodeEndpointStatements(t *testing.T) {
+	db, _ := newNodeDB("", Version, NodeID{})
+	defer db.close()
+
+	ln := newTestLocalNode(t, db)
+	external := rpcEndpoint{IP: net.IP{1, 2, 3, 4}, UDP: 30305}
+
+	for i := 0; i < endpointMinVotes; i++ {
+		var ip enr.IP
+		ln.Record().Load(&ip)
+		if net.IP(ip).Equal(external.IP) {
+			t.Fatalf("IP updated after %d statements, want %d", i, endpointMinVotes)
+		}
+		ln.addStatement(NodeID{byte(i)}, external)
+	}
+	var (
+		ip   enr.IP
+		port enr.UDP
+	)
+	if err := ln.Record().Load(&ip); err != nil || !net.IP(ip).Equal(external.IP) {
+		t.Fatalf("IP not updated: have %v, want %v", net.IP(ip), external.IP)
+	}
+	if err := ln.Record().Load(&port); err != nil || uint16(port) != external.UDP {
+		t.Fatalf("UDP port not updated: have %d, want %d", port, external.UDP)
+	}
+	// Repeated statements of a single node don't count
+	for i := 0; i < 2*endpointMinVotes; i++ {
+		ln.addStatement(NodeID{0xff}, rpcEndpoint{IP: net.IP{5, 6, 7, 8}, UDP: 30303})
+	}
+	if err := ln.Record().Load(&ip); err != nil || !net.IP(ip).Equal(external.IP) {
+		t.Fatalf("IP changed by a single node: have %v, want %v", net.IP(ip), external.IP)
+	}
+	// A port change alone is picked up as well
+	external.UDP = 30306
+	for i := 0; i < endpointMinVotes; i++ {
+		ln.addStatement(NodeID{byte(i)}, external)
+	}
+	if err := ln.Record().Load(&port); err != nil || uint16(port) != external.UDP {
+		t.Fatalf("UDP port not updated: have %d, want %d", port, external.UDP)
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/irchain/go-irchain/common"
 	"github.com/irchain/go-irchain/crypto"
 	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/enr"
 	"github.com/irchain/go-irchain/p2p/netutil"
 )
 
@@ -84,8 +85,9 @@ type Table struct {
 
 	nodeAddedHook func(*Node) // for testing
 
-	net  transport
-	self *Node // metadata of the local node
+	net   transport
+	self  *Node      // metadata of the local node
+	local *localNode // signed record of the local node, nil without UDP transport
 }
 
 type bondproc struct {
@@ -101,6 +103,7 @@ type transport interface {
 	ping(NodeID, *net.UDPAddr) error
 	waitping(NodeID) error
 	findnode(toid NodeID, addr *net.UDPAddr, target NodeID) ([]*Node, error)
+	requestENR(toid NodeID, addr *net.UDPAddr) (*enr.Record, error)
 	close()
 }
 
@@ -270,6 +273,75 @@ func (tab *Table) Resolve(targetID NodeID) *Node {
 	return nil
 }
 
+// RequestENR retrieves the current node record of the given node (EIP-868),
+// bonding with it first if necessary.
+func (tab *Table) RequestENR(n *Node) (*enr.Record, error) {
+	if !tab.db.hasBond(n.ID) {
+		if _, err := tab.bond(false, n.ID, n.addr(), n.TCP); err != nil {
+			return nil, err
+		}
+	}
+	record, err := tab.net.requestENR(n.ID, n.addr())
+	if err != nil {
+		return nil, err
+	}
+	tab.db.updateNodeRecord(n.ID, record)
+	return record, nil
+}
+
+// StoreBan persists the ban of a node in the node database. The zero time
+// denotes a ban that never expires.
+func (tab *Table) StoreBan(id NodeID, until time.Time) error {
+	return tab.db.storeBan(id, until)
+}
+
+// DeleteBan removes the ban of a node from the node database.
+func (tab *Table) DeleteBan(id NodeID) error {
+	return tab.db.deleteBan(id)
+}
+
+// Bans returns all node bans stored in the node database.
+func (tab *Table) Bans() map[NodeID]time.Time {
+	return tab.db.bans()
+}
+
+// BanDB persists node bans in the node database for servers running without
+// a discovery table.
+type BanDB struct {
+	db *nodeDB
+}
+
+// OpenBanDB opens the node database at the given path for storing bans. An
+// empty path opens an in-memory database.
+func OpenBanDB(path string, self NodeID) (*BanDB, error) {
+	db, err := newNodeDB(path, Version, self)
+	if err != nil {
+		return nil, err
+	}
+	return &BanDB{db}, nil
+}
+
+// StoreBan persists the ban of a node. The zero time denotes a ban that never
+// expires.
+func (b *BanDB) StoreBan(id NodeID, until time.Time) error {
+	return b.db.storeBan(id, until)
+}
+
+// DeleteBan removes the ban of a node.
+func (b *BanDB) DeleteBan(id NodeID) error {
+	return b.db.deleteBan(id)
+}
+
+// Bans returns all stored node bans.
+func (b *BanDB) Bans() map[NodeID]time.Time {
+	return b.db.bans()
+}
+
+// Close closes the database.
+func (b *BanDB) Close() {
+	b.db.close()
+}
+
 // Lookup performs a network search for nodes close
 // to the given target. It approaches the target by querying
 // nodes that are closer to it on each iteration.
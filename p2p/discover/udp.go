@@ -23,10 +23,12 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/irchain/go-irchain/crypto"
 	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/enr"
 	"github.com/irchain/go-irchain/p2p/nat"
 	"github.com/irchain/go-irchain/p2p/netutil"
 	"github.com/irchain/go-irchain/rlp"
@@ -44,6 +46,7 @@ var (
 	errTimeout          = errors.New("RPC timeout")
 	errClockWarp        = errors.New("reply deadline too far in the future")
 	errClosed           = errors.New("socket closed")
+	errWrongRecord      = errors.New("record doesn't match node ID")
 )
 
 // Timeouts
@@ -62,6 +65,8 @@ const (
 	pongPacket
 	findnodePacket
 	neighborsPacket
+	enrRequestPacket
+	enrResponsePacket
 )
 
 // RPC request structures
@@ -103,6 +108,21 @@ type (
 		Rest []rlp.RawValue `rlp:"tail"`
 	}
 
+	// enrRequest queries for the remote node's record (EIP-868).
+	enrRequest struct {
+		Expiration uint64
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
+	// enrResponse is the reply to enrRequest.
+	enrResponse struct {
+		ReplyTok []byte // Hash of the enrRequest packet.
+		Record   enr.Record
+		// Ignore additional fields (for forward compatibility).
+		Rest []rlp.RawValue `rlp:"tail"`
+	}
+
 	rpcNode struct {
 		IP  net.IP // len 4 for IPv4 or 16 for IPv6
 		UDP uint16 // for discovery protocol
@@ -117,6 +137,24 @@ type (
 	}
 )
 
+// makeENRSeq encodes the local record sequence number. It is sent as the first
+// additional element of ping and pong packets, which keeps them decodable by
+// nodes not supporting EIP-868.
+func makeENRSeq(seq uint64) []rlp.RawValue {
+	enc, _ := rlp.EncodeToBytes(seq)
+	return []rlp.RawValue{enc}
+}
+
+// decodeENRSeq decodes the record sequence number of a ping or pong packet,
+// returning zero if the sender didn't include one.
+func decodeENRSeq(rest []rlp.RawValue) uint64 {
+	var seq uint64
+	if len(rest) == 0 || rlp.DecodeBytes(rest[0], &seq) != nil {
+		return 0
+	}
+	return seq
+}
+
 func makeEndpoint(addr *net.UDPAddr, tcpPort uint16) rpcEndpoint {
 	ip := addr.IP.To4()
 	if ip == nil {
@@ -162,6 +200,7 @@ type udp struct {
 	netrestrict *netutil.Netlist
 	priv        *ecdsa.PrivateKey
 	ourEndpoint rpcEndpoint
+	local       *localNode
 
 	addpending chan *pending
 	gotreply   chan reply
@@ -169,6 +208,9 @@ type udp struct {
 	closing chan struct{}
 	nat     nat.Interface
 
+	enrMu       sync.Mutex
+	enrFetching map[NodeID]bool // nodes whose record is being retrieved
+
 	*Table
 }
 
@@ -226,6 +268,7 @@ type Config struct {
 	NetRestrict  *netutil.Netlist  // network whitelist
 	Bootnodes    []*Node           // list of bootstrap nodes
 	Unhandled    chan<- ReadPacket // unhandled packets are sent on this channel
+	Entries      []enr.Entry       // additional entries of the local node record
 }
 
 // ListenUDP returns a new table that listens for UDP packets on laddr.
@@ -246,6 +289,7 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 		closing:     make(chan struct{}),
 		gotreply:    make(chan reply),
 		addpending:  make(chan *pending),
+		enrFetching: make(map[NodeID]bool),
 	}
 	realaddr := c.LocalAddr().(*net.UDPAddr)
 	if cfg.AnnounceAddr != nil {
@@ -253,10 +297,16 @@ func newUDP(c conn, cfg Config) (*Table, *udp, error) {
 	}
 	// TODO: separate TCP port
 	udp.ourEndpoint = makeEndpoint(realaddr, uint16(realaddr.Port))
+	udp.local = newLocalNode(cfg.PrivateKey, udp.ourEndpoint, cfg.Entries)
 	tab, err := newTable(udp, PubkeyID(&cfg.PrivateKey.PublicKey), realaddr, cfg.NodeDBPath, cfg.Bootnodes)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := udp.local.init(tab.db); err != nil {
+		tab.Close()
+		return nil, nil, err
+	}
+	tab.local = udp.local
 	udp.Table = tab
 
 	go udp.loop()
@@ -277,6 +327,7 @@ func (t *udp) ping(toid NodeID, toaddr *net.UDPAddr) error {
 		From:       t.ourEndpoint,
 		To:         makeEndpoint(toaddr, 0), // TODO: maybe use known TCP port from DB
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		Rest:       makeENRSeq(t.local.Seq()),
 	}
 	packet, hash, err := encodePacket(t.priv, pingPacket, req)
 	if err != nil {
@@ -319,6 +370,67 @@ func (t *udp) findnode(toid NodeID, toaddr *net.UDPAddr, target NodeID) ([]*Node
 	return nodes, err
 }
 
+// checkENRSeq retrieves the record of a node in the background if the sequence
+// number it announced is newer than the one of the last retrieved record.
+func (t *udp) checkENRSeq(id NodeID, addr *net.UDPAddr, seq uint64) {
+	if seq == 0 {
+		return
+	}
+	if known := t.db.nodeRecord(id); known != nil && known.Seq() >= seq {
+		return
+	}
+	t.enrMu.Lock()
+	defer t.enrMu.Unlock()
+
+	if t.enrFetching[id] {
+		return
+	}
+	t.enrFetching[id] = true
+	go func() {
+		if record, err := t.requestENR(id, addr); err != nil {
+			log.Trace("Failed to retrieve node record", "id", id, "addr", addr, "err", err)
+		} else {
+			t.db.updateNodeRecord(id, record)
+		}
+		t.enrMu.Lock()
+		delete(t.enrFetching, id)
+		t.enrMu.Unlock()
+	}()
+}
+
+// requestENR sends an enrRequest to the given node and waits for the record.
+// The record is verified to belong to the requested node.
+func (t *udp) requestENR(toid NodeID, toaddr *net.UDPAddr) (*enr.Record, error) {
+	req := &enrRequest{
+		Expiration: uint64(time.Now().Add(expiration).Unix()),
+	}
+	packet, hash, err := encodePacket(t.priv, enrRequestPacket, req)
+	if err != nil {
+		return nil, err
+	}
+	var resp *enrResponse
+	errc := t.pending(toid, enrResponsePacket, func(r interface{}) bool {
+		if !bytes.Equal(r.(*enrResponse).ReplyTok, hash) {
+			return false
+		}
+		resp = r.(*enrResponse)
+		return true
+	})
+	t.write(toaddr, req.name(), packet)
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	// The signature was verified during decoding, check the signer
+	var pubkey enr.Secp256k1
+	if err := resp.Record.Load(&pubkey); err != nil {
+		return nil, err
+	}
+	if PubkeyID((*ecdsa.PublicKey)(&pubkey)) != toid {
+		return nil, errWrongRecord
+	}
+	return &resp.Record, nil
+}
+
 // pending adds a reply callback to the pending reply queue.
 // see the documentation of type pending for a detailed explanation.
 func (t *udp) pending(id NodeID, ptype byte, callback func(interface{}) bool) <-chan error {
@@ -570,6 +682,10 @@ func decodePacket(buf []byte) (packet, NodeID, []byte, error) {
 		req = new(findnode)
 	case neighborsPacket:
 		req = new(neighbors)
+	case enrRequestPacket:
+		req = new(enrRequest)
+	case enrResponsePacket:
+		req = new(enrResponse)
 	default:
 		return nil, fromID, hash, fmt.Errorf("unknown type: %d", ptype)
 	}
@@ -586,7 +702,11 @@ func (req *ping) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) er
 		To:         makeEndpoint(from, req.From.TCP),
 		ReplyTok:   mac,
 		Expiration: uint64(time.Now().Add(expiration).Unix()),
+		Rest:       makeENRSeq(t.local.Seq()),
 	})
+	if t.db.hasBond(fromID) {
+		t.checkENRSeq(fromID, from, decodeENRSeq(req.Rest))
+	}
 	if !t.handleReply(fromID, pingPacket, req) {
 		// Note: we're ignoring the provided IP address right now
 		go t.bond(true, fromID, from, req.From.TCP)
@@ -603,6 +723,8 @@ func (req *pong) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) er
 	if !t.handleReply(fromID, pongPacket, req) {
 		return errUnsolicitedReply
 	}
+	t.checkENRSeq(fromID, from, decodeENRSeq(req.Rest))
+	t.local.addStatement(fromID, req.To)
 	return nil
 }
 
@@ -661,6 +783,33 @@ func (req *neighbors) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byt
 
 func (req *neighbors) name() string { return "NEIGHBORS/v4" }
 
+func (req *enrRequest) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if expired(req.Expiration) {
+		return errExpired
+	}
+	if !t.db.hasBond(fromID) {
+		// Like findnode, the record is only sent to bonded nodes to avoid
+		// traffic amplification.
+		return errUnknownNode
+	}
+	t.send(from, enrResponsePacket, &enrResponse{
+		ReplyTok: mac,
+		Record:   *t.local.Record(),
+	})
+	return nil
+}
+
+func (req *enrRequest) name() string { return "ENRREQUEST/v4" }
+
+func (req *enrResponse) handle(t *udp, from *net.UDPAddr, fromID NodeID, mac []byte) error {
+	if !t.handleReply(fromID, enrResponsePacket, req) {
+		return errUnsolicitedReply
+	}
+	return nil
+}
+
+func (req *enrResponse) name() string { return "ENRRESPONSE/v4" }
+
 func expired(ts uint64) bool {
 	return time.Unix(int64(ts), 0).Before(time.Now())
 }
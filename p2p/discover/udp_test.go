@@ -36,6 +36,7 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/irchain/go-irchain/common"
 	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/p2p/enr"
 	"github.com/irchain/go-irchain/rlp"
 )
 
@@ -128,6 +129,8 @@ func TestUDP_packetErrors(t *testing.T) {
 	test.packetIn(errUnsolicitedReply, pongPacket, &pong{ReplyTok: []byte{}, Expiration: futureExp})
 	test.packetIn(errUnknownNode, findnodePacket, &findnode{Expiration: futureExp})
 	test.packetIn(errUnsolicitedReply, neighborsPacket, &neighbors{Expiration: futureExp})
+	test.packetIn(errUnknownNode, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.packetIn(errUnsolicitedReply, enrResponsePacket, &enrResponse{ReplyTok: []byte{}, Record: *test.udp.local.Record()})
 }
 
 func TestUDP_pingTimeout(t *testing.T) {
@@ -345,6 +348,9 @@ func TestUDP_successfulPing(t *testing.T) {
 		if !reflect.DeepEqual(p.To, wantTo) {
 			t.Errorf("got pong.To %v, want %v", p.To, wantTo)
 		}
+		if seq := decodeENRSeq(p.Rest); seq != test.udp.local.Seq() {
+			t.Errorf("got pong ENR seq %d, want %d", seq, test.udp.local.Seq())
+		}
 	})
 
 	// remote is unknown, the table pings back.
@@ -360,6 +366,9 @@ func TestUDP_successfulPing(t *testing.T) {
 		if !reflect.DeepEqual(p.To, wantTo) {
 			t.Errorf("got ping.To %v, want %v", p.To, wantTo)
 		}
+		if seq := decodeENRSeq(p.Rest); seq != test.udp.local.Seq() {
+			t.Errorf("got ping ENR seq %d, want %d", seq, test.udp.local.Seq())
+		}
 		return nil
 	})
 	test.packetIn(nil, pongPacket, &pong{ReplyTok: hash, Expiration: futureExp})
@@ -386,6 +395,114 @@ func TestUDP_successfulPing(t *testing.T) {
 	}
 }
 
+func TestUDP_enrRequest(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	// Requests are only answered for bonded nodes.
+	remoteID := PubkeyID(&test.remotekey.PublicKey)
+	test.table.db.updateBondTime(remoteID, time.Now())
+
+	test.packetIn(nil, enrRequestPacket, &enrRequest{Expiration: futureExp})
+	test.waitPacketOut(func(p *enrResponse) {
+		reqhash := test.sent[0][:macSize]
+		if !bytes.Equal(p.ReplyTok, reqhash) {
+			t.Errorf("got enrResponse.ReplyTok %x, want %x", p.ReplyTok, reqhash)
+		}
+		want := test.udp.local.Record()
+		if p.Record.Seq() != want.Seq() || !bytes.Equal(p.Record.NodeAddr(), want.NodeAddr()) {
+			t.Errorf("wrong record in response: seq %d, want %d", p.Record.Seq(), want.Seq())
+		}
+	})
+}
+
+func TestUDP_requestENR(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	// Create the record of the remote node and a record signed by some other key.
+	var remoteRecord, otherRecord enr.Record
+	remoteRecord.SetSeq(7)
+	remoteRecord.Set(enr.IP(test.remoteaddr.IP))
+	if err := enr.SignV4(&remoteRecord, test.remotekey); err != nil {
+		t.Fatal(err)
+	}
+	if err := enr.SignV4(&otherRecord, newkey()); err != nil {
+		t.Fatal(err)
+	}
+	remoteID := PubkeyID(&test.remotekey.PublicKey)
+
+	for _, tt := range []struct {
+		record  enr.Record
+		wantErr error
+	}{
+		{remoteRecord, nil},
+		{otherRecord, errWrongRecord},
+	} {
+		type result struct {
+			record *enr.Record
+			err    error
+		}
+		done := make(chan result, 1)
+		go func() {
+			record, err := test.udp.requestENR(remoteID, test.remoteaddr)
+			done <- result{record, err}
+		}()
+		hash, _ := test.waitPacketOut(func(p *enrRequest) {})
+		test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: tt.record})
+
+		res := <-done
+		if res.err != tt.wantErr {
+			t.Fatalf("error mismatch: have %v, want %v", res.err, tt.wantErr)
+		}
+		if tt.wantErr == nil && res.record.Seq() != remoteRecord.Seq() {
+			t.Errorf("wrong record seq: have %d, want %d", res.record.Seq(), remoteRecord.Seq())
+		}
+	}
+}
+
+// Tests that the record of a node is retrieved when it announces a newer one
+// than the last known in a ping.
+func TestUDP_pingNewerENRSeq(t *testing.T) {
+	test := newUDPTest(t)
+	defer test.table.Close()
+
+	remoteID := PubkeyID(&test.remotekey.PublicKey)
+	test.table.db.updateBondTime(remoteID, time.Now())
+
+	var known, newer enr.Record
+	known.SetSeq(3)
+	newer.SetSeq(7)
+	for _, r := range []*enr.Record{&known, &newer} {
+		if err := enr.SignV4(r, test.remotekey); err != nil {
+			t.Fatal(err)
+		}
+	}
+	test.table.db.updateNodeRecord(remoteID, &known)
+
+	// A ping announcing a newer record triggers its retrieval
+	test.packetIn(nil, pingPacket, &ping{From: testRemote, To: testLocalAnnounced, Version: Version, Expiration: futureExp, Rest: makeENRSeq(newer.Seq())})
+	test.waitPacketOut(func(p *pong) {})
+	hash, _ := test.waitPacketOut(func(p *enrRequest) {})
+	test.packetIn(nil, enrResponsePacket, &enrResponse{ReplyTok: hash, Record: newer})
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if r := test.table.db.nodeRecord(remoteID); r != nil && r.Seq() == newer.Seq() {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("newer record not stored")
+		}
+	}
+	// Announcing the known record again doesn't
+	test.udp.checkENRSeq(remoteID, test.remoteaddr, newer.Seq())
+	test.udp.enrMu.Lock()
+	defer test.udp.enrMu.Unlock()
+	if test.udp.enrFetching[remoteID] {
+		t.Errorf("known record requested again")
+	}
+}
+
 var testPackets = []struct {
 	input      string
 	wantPacket interface{}
@@ -0,0 +1,215 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package dnsdisc implements node discovery via DNS (EIP-1459).
+//
+// Node lists are published as merkle trees of signed node records in DNS TXT
+// records. A client only needs to know the domain name and the public key of
+// the list publisher to retrieve and authenticate the full list.
+package dnsdisc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru"
+	"github.com/irchain/go-irchain/log"
+)
+
+var (
+	errUnknownEntry  = errors.New("unknown entry type")
+	errNoPubkey      = errors.New("missing public key")
+	errBadPubkey     = errors.New("invalid public key")
+	errInvalidENR    = errors.New("invalid node record")
+	errInvalidChild  = errors.New("invalid child hash")
+	errInvalidSig    = errors.New("invalid signature")
+	errSyntax        = errors.New("invalid syntax")
+	errNoRoot        = errors.New("no valid root found")
+	errHashMismatch  = errors.New("hash mismatch")
+	errENRInLinkTree = errors.New("enr entry in link tree")
+	errLinkInENRTree = errors.New("link entry in ENR tree")
+)
+
+// entryError wraps an entry parsing error with the type of the entry.
+type entryError struct {
+	typ string
+	err error
+}
+
+func (err entryError) Error() string {
+	return fmt.Sprintf("invalid %s entry: %v", err.typ, err.err)
+}
+
+// Resolver is a DNS resolver that can query TXT records.
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+// Config holds configuration options for the DNS discovery client.
+type Config struct {
+	Timeout         time.Duration // timeout used for DNS lookups (default 5s)
+	RecheckInterval time.Duration // time between tree root update checks (default 30min)
+	CacheLimit      int           // maximum number of cached records (default 1000)
+	Resolver        Resolver      // the DNS resolver to use (defaults to system DNS)
+	Logger          log.Logger    // destination of client log messages (defaults to root logger)
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	if cfg.RecheckInterval == 0 {
+		cfg.RecheckInterval = 30 * time.Minute
+	}
+	if cfg.CacheLimit == 0 {
+		cfg.CacheLimit = 1000
+	}
+	if cfg.Resolver == nil {
+		cfg.Resolver = new(net.Resolver)
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Root()
+	}
+	return cfg
+}
+
+// Client discovers nodes by querying DNS servers.
+type Client struct {
+	cfg     Config
+	entries *lru.Cache
+}
+
+// NewClient creates a client.
+func NewClient(cfg Config) (*Client, error) {
+	cfg = cfg.withDefaults()
+	cache, err := lru.New(cfg.CacheLimit)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{cfg: cfg, entries: cache}, nil
+}
+
+// SyncTree downloads the entire node tree at the given URL. This doesn't add
+// the nodes in the tree to a pool. The configured timeout applies to each DNS
+// lookup individually, not to the whole sync.
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	le, err := parseLink(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid enrtree URL: %v", err)
+	}
+	ctx := context.Background()
+
+	root, err := c.resolveRoot(ctx, le)
+	if err != nil {
+		return nil, err
+	}
+	t := &Tree{root: &root, entries: make(map[string]entry)}
+	if err := c.syncTree(ctx, le.domain, root.eroot, false, t.entries); err != nil {
+		return nil, err
+	}
+	if err := c.syncTree(ctx, le.domain, root.lroot, true, t.entries); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// syncTree retrieves all entries of the subtree rooted at hash into entries.
+func (c *Client) syncTree(ctx context.Context, domain, hash string, links bool, entries map[string]entry) error {
+	e, err := c.resolveEntry(ctx, domain, hash)
+	if err != nil {
+		return err
+	}
+	entries[hash] = e
+
+	switch e := e.(type) {
+	case *branchEntry:
+		for _, child := range e.children {
+			if err := c.syncTree(ctx, domain, child, links, entries); err != nil {
+				return err
+			}
+		}
+	case *enrEntry:
+		if links {
+			return errENRInLinkTree
+		}
+	case *linkEntry:
+		if !links {
+			return errLinkInENRTree
+		}
+	}
+	return nil
+}
+
+// resolveRoot retrieves a root entry via DNS and verifies its signature.
+func (c *Client) resolveRoot(ctx context.Context, loc *linkEntry) (rootEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, loc.domain)
+	c.cfg.Logger.Trace("Updating DNS discovery root", "tree", loc.domain, "err", err)
+	if err != nil {
+		return rootEntry{}, err
+	}
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			root, err := parseRoot(txt)
+			if err != nil {
+				return rootEntry{}, err
+			}
+			if !root.verifySignature(loc.pubkey) {
+				return rootEntry{}, entryError{typ: "root", err: errInvalidSig}
+			}
+			return root, nil
+		}
+	}
+	return rootEntry{}, errNoRoot
+}
+
+// resolveEntry retrieves an entry from the cache or fetches it from the network
+// if it isn't cached.
+func (c *Client) resolveEntry(ctx context.Context, domain, hash string) (entry, error) {
+	cacheKey := hash + "." + domain
+	if e, ok := c.entries.Get(cacheKey); ok {
+		return e.(entry), nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	txts, err := c.cfg.Resolver.LookupTXT(ctx, cacheKey)
+	c.cfg.Logger.Trace("DNS discovery lookup", "name", cacheKey, "err", err)
+	if err != nil {
+		return nil, err
+	}
+	for _, txt := range txts {
+		e, err := parseEntry(txt)
+		if err == errUnknownEntry {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if subdomain(e) != hash {
+			return nil, errHashMismatch
+		}
+		c.entries.Add(cacheKey, e)
+		return e, nil
+	}
+	return nil, fmt.Errorf("no valid entry at %s", cacheKey)
+}
@@ -0,0 +1,246 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/p2p/enr"
+)
+
+// mapResolver is an in-process DNS resolver serving TXT records from a map.
+type mapResolver map[string]string
+
+func (mr mapResolver) add(m map[string]string) {
+	for k, v := range m {
+		mr[k] = v
+	}
+}
+
+func (mr mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if record, ok := mr[name]; ok {
+		return []string{record}, nil
+	}
+	return nil, fmt.Errorf("%s: no such host", name)
+}
+
+// testNodes creates a batch of signed node records.
+func testNodes(t *testing.T, n int) []*enr.Record {
+	records := make([]*enr.Record, n)
+	for i := range records {
+		key, _ := crypto.GenerateKey()
+
+		var r enr.Record
+		r.SetSeq(uint64(i))
+		r.Set(enr.IP(net.IP{127, 0, 0, byte(i + 1)}))
+		r.Set(enr.TCP(30303 + i))
+		if err := enr.SignV4(&r, key); err != nil {
+			t.Fatalf("failed to sign record %d: %v", i, err)
+		}
+		records[i] = &r
+	}
+	return records
+}
+
+// makeTestTree creates and signs a tree, returning it along with its URL.
+func makeTestTree(t *testing.T, key *ecdsa.PrivateKey, domain string, nodes []*enr.Record, links []string) (*Tree, string) {
+	tree, err := MakeTree(1, nodes, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url, err := tree.Sign(key, domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree, url
+}
+
+func newTestClient(t *testing.T, r Resolver) *Client {
+	c, err := NewClient(Config{Resolver: r, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// Tests that a published tree can be retrieved in full.
+func TestClientSyncTree(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	nodes := testNodes(t, 40)
+	tree, url := makeTestTree(t, key, "n", nodes, nil)
+
+	resolver := make(mapResolver)
+	resolver.add(tree.ToTXT("n"))
+
+	synced, err := newTestClient(t, resolver).SyncTree(url)
+	if err != nil {
+		t.Fatal("sync error:", err)
+	}
+	if !reflect.DeepEqual(synced.Nodes(), tree.Nodes()) {
+		t.Errorf("wrong nodes in synced tree")
+	}
+	if len(synced.Nodes()) != len(nodes) {
+		t.Errorf("synced node count mismatch: have %d, want %d", len(synced.Nodes()), len(nodes))
+	}
+	if synced.Seq() != tree.Seq() || synced.Signature() != tree.Signature() {
+		t.Errorf("synced root mismatch")
+	}
+}
+
+// slowResolver is a mapResolver taking a while to answer each lookup, failing
+// if the lookup's context expires first.
+type slowResolver struct {
+	mapResolver
+	delay time.Duration
+}
+
+func (sr slowResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	select {
+	case <-time.After(sr.delay):
+		return sr.mapResolver.LookupTXT(ctx, name)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Tests that the timeout applies to individual lookups, allowing the sync of a
+// tree to take longer than that in total.
+func TestClientSyncTreeLookupTimeout(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, url := makeTestTree(t, key, "n", testNodes(t, 10), nil)
+
+	resolver := slowResolver{mapResolver: make(mapResolver), delay: 10 * time.Millisecond}
+	resolver.add(tree.ToTXT("n"))
+
+	c, err := NewClient(Config{Resolver: resolver, Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	synced, err := c.SyncTree(url)
+	if err != nil {
+		t.Fatal("sync error:", err)
+	}
+	if len(synced.Nodes()) != 10 {
+		t.Errorf("synced node count mismatch: have %d, want 10", len(synced.Nodes()))
+	}
+}
+
+// Tests that a root signed by a different key is rejected.
+func TestClientSyncTreeBadSignature(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+	tree, _ := makeTestTree(t, key, "n", testNodes(t, 3), nil)
+
+	resolver := make(mapResolver)
+	resolver.add(tree.ToTXT("n"))
+
+	url := newLinkEntry("n", &other.PublicKey).String()
+	if _, err := newTestClient(t, resolver).SyncTree(url); err == nil {
+		t.Fatal("expected error for root with wrong signature")
+	}
+}
+
+// Tests that tampered entries are detected through their hash.
+func TestClientSyncTreeHashMismatch(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, url := makeTestTree(t, key, "n", testNodes(t, 3), nil)
+
+	resolver := make(mapResolver)
+	resolver.add(tree.ToTXT("n"))
+
+	replacement := FormatENR(testNodes(t, 1)[0])
+	for name, txt := range resolver {
+		if strings.HasPrefix(txt, enrPrefix) {
+			resolver[name] = replacement
+			break
+		}
+	}
+	if _, err := newTestClient(t, resolver).SyncTree(url); err != errHashMismatch {
+		t.Fatalf("wrong error: have %v, want %v", err, errHashMismatch)
+	}
+}
+
+// Tests that links aren't accepted in the node record subtree.
+func TestClientSyncTreeLinkInENRTree(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, url := makeTestTree(t, key, "n", testNodes(t, 3), []string{newLinkEntry("other", &key.PublicKey).String()})
+
+	// Swap the subtree roots, placing the link into the node record subtree
+	root := *tree.root
+	root.eroot, root.lroot = root.lroot, root.eroot
+	tree.root = &root
+	if _, err := tree.Sign(key, "n"); err != nil {
+		t.Fatal(err)
+	}
+	resolver := make(mapResolver)
+	resolver.add(tree.ToTXT("n"))
+
+	if _, err := newTestClient(t, resolver).SyncTree(url); err != errLinkInENRTree {
+		t.Fatalf("wrong error: have %v, want %v", err, errLinkInENRTree)
+	}
+}
+
+// Tests that a pool collects the nodes of all linked trees.
+func TestPoolLinks(t *testing.T) {
+	var (
+		keyA, _ = crypto.GenerateKey()
+		keyB, _ = crypto.GenerateKey()
+		nodesA  = testNodes(t, 5)
+		nodesB  = testNodes(t, 20)
+	)
+	treeB, urlB := makeTestTree(t, keyB, "b", nodesB, nil)
+	treeA, urlA := makeTestTree(t, keyA, "a", nodesA, []string{urlB})
+
+	resolver := make(mapResolver)
+	resolver.add(treeA.ToTXT("a"))
+	resolver.add(treeB.ToTXT("b"))
+
+	pool, err := newTestClient(t, resolver).NewPool(urlA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	select {
+	case <-pool.ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("pool sync timeout")
+	}
+	want := make(map[discover.NodeID]bool)
+	for _, r := range append(nodesA, nodesB...) {
+		want[nodeFromRecord(r).ID] = true
+	}
+	buf := make([]*discover.Node, 100)
+	n := pool.ReadRandomNodes(buf)
+	if n != len(want) {
+		t.Fatalf("node count mismatch: have %d, want %d", n, len(want))
+	}
+	for _, node := range buf[:n] {
+		if !want[node.ID] {
+			t.Errorf("unexpected node %x", node.ID[:8])
+		}
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"crypto/ecdsa"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/p2p/enr"
+)
+
+// maxLinkDepth is the maximum number of link hops followed from the initial
+// tree URLs, preventing resolution loops between misconfigured trees.
+const maxLinkDepth = 4
+
+// Pool keeps a set of node lists up to date and serves random nodes from them
+// to the dialer.
+type Pool struct {
+	client *Client
+	urls   []string
+
+	lock  sync.RWMutex
+	trees map[string][]*discover.Node // nodes of the last successful sync per tree
+	nodes []*discover.Node            // nodes of all synced trees
+	ready chan struct{}               // closed when the first sync finished
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPool creates a pool syncing the trees at the given URLs periodically. The
+// URLs are validated upfront, the first sync happens in the background.
+func (c *Client) NewPool(urls ...string) (*Pool, error) {
+	for _, url := range urls {
+		if _, err := parseLink(url); err != nil {
+			return nil, err
+		}
+	}
+	p := &Pool{
+		client: c,
+		urls:   urls,
+		trees:  make(map[string][]*discover.Node),
+		ready:  make(chan struct{}),
+		quit:   make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.loop()
+	return p, nil
+}
+
+// Close stops the background syncing of the pool.
+func (p *Pool) Close() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+// ReadRandomNodes fills the given slice with random nodes from the pool. It
+// returns the number of nodes written.
+func (p *Pool) ReadRandomNodes(buf []*discover.Node) int {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	n := 0
+	for _, i := range rand.Perm(len(p.nodes)) {
+		if n == len(buf) {
+			break
+		}
+		buf[n] = p.nodes[i]
+		n++
+	}
+	return n
+}
+
+// Nodes returns all nodes currently known to the pool.
+func (p *Pool) Nodes() []*discover.Node {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return append([]*discover.Node(nil), p.nodes...)
+}
+
+// loop syncs all trees on startup and whenever the recheck interval passes.
+func (p *Pool) loop() {
+	defer p.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for first := true; ; first = false {
+		select {
+		case <-timer.C:
+			p.sync()
+			if first {
+				close(p.ready)
+			}
+			timer.Reset(p.client.cfg.RecheckInterval)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// sync downloads all trees reachable from the pool URLs and replaces the node
+// set. Trees failing to sync retain their previously known nodes.
+func (p *Pool) sync() {
+	var (
+		seen  = make(map[string]bool)
+		trees = make(map[string][]*discover.Node)
+		queue = p.urls
+	)
+	for depth := 0; len(queue) > 0 && depth <= maxLinkDepth; depth++ {
+		var next []string
+		for _, url := range queue {
+			if seen[url] {
+				continue
+			}
+			seen[url] = true
+
+			t, err := p.client.SyncTree(url)
+			if err != nil {
+				p.client.cfg.Logger.Debug("DNS discovery tree sync failed", "url", url, "err", err)
+				p.lock.RLock()
+				trees[url] = p.trees[url]
+				p.lock.RUnlock()
+				continue
+			}
+			for _, r := range t.Nodes() {
+				if n := nodeFromRecord(r); n != nil {
+					trees[url] = append(trees[url], n)
+				}
+			}
+			next = append(next, t.Links()...)
+		}
+		queue = next
+	}
+	// Deduplicate the nodes of all trees and swap in the new set
+	var (
+		list  []*discover.Node
+		known = make(map[discover.NodeID]bool)
+	)
+	for _, nodes := range trees {
+		for _, n := range nodes {
+			if !known[n.ID] {
+				known[n.ID] = true
+				list = append(list, n)
+			}
+		}
+	}
+	p.lock.Lock()
+	p.trees, p.nodes = trees, list
+	p.lock.Unlock()
+
+	p.client.cfg.Logger.Debug("Synced DNS discovery trees", "trees", len(seen), "nodes", len(list))
+}
+
+// nodeFromRecord converts a signed node record into a dialable node. It
+// returns nil if the record doesn't contain a public key, IP or TCP port.
+func nodeFromRecord(r *enr.Record) *discover.Node {
+	var (
+		pubkey enr.Secp256k1
+		ip     enr.IP
+		tcp    enr.TCP
+		udp    enr.UDP
+	)
+	if r.Load(&pubkey) != nil || r.Load(&ip) != nil || r.Load(&tcp) != nil {
+		return nil
+	}
+	r.Load(&udp)
+	if udp == 0 {
+		udp = enr.UDP(tcp)
+	}
+	id := discover.PubkeyID((*ecdsa.PublicKey)(&pubkey))
+	return discover.NewNode(id, net.IP(ip), uint16(udp), uint16(tcp))
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package dnsdisc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/irchain/go-irchain/crypto"
+	"github.com/irchain/go-irchain/p2p/enr"
+)
+
+// Tests that all entry types survive an encoding round trip.
+func TestParseEntry(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	tree, url := makeTestTree(t, key, "n", testNodes(t, 20), []string{newLinkEntry("other", &key.PublicKey).String()})
+
+	for name, txt := range tree.ToTXT("n") {
+		if name == "n" {
+			root, err := parseRoot(txt)
+			if err != nil {
+				t.Fatalf("root parse error: %v", err)
+			}
+			if root.String() != txt {
+				t.Errorf("root round trip mismatch: have %q, want %q", root.String(), txt)
+			}
+			if !root.verifySignature(&key.PublicKey) {
+				t.Errorf("root signature invalid")
+			}
+			continue
+		}
+		e, err := parseEntry(txt)
+		if err != nil {
+			t.Fatalf("entry %s parse error: %v", name, err)
+		}
+		if e.String() != txt {
+			t.Errorf("entry %s round trip mismatch: have %q, want %q", name, e.String(), txt)
+		}
+		if subdomain(e)+".n" != name {
+			t.Errorf("entry %s published under wrong name", name)
+		}
+	}
+	domain, pubkey, err := ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain != "n" || !reflect.DeepEqual(pubkey, &key.PublicKey) {
+		t.Errorf("URL round trip mismatch")
+	}
+}
+
+// Tests that invalid entries are rejected.
+func TestParseEntryErrors(t *testing.T) {
+	tests := []string{
+		"unknown:foo",
+		"enrtree-branch:1,2",
+		"enr:-----",
+		"enrtree://nokey",
+		"enrtree://AAAA@foo",
+	}
+	for _, input := range tests {
+		if _, err := parseEntry(input); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+// Tests that the tree structure is deterministic regardless of input order.
+func TestMakeTreeCanonical(t *testing.T) {
+	nodes := testNodes(t, 30)
+	reversed := make([]*enr.Record, len(nodes))
+	for i := range nodes {
+		reversed[i] = nodes[len(nodes)-1-i]
+	}
+	a, err := MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MakeTree(1, reversed, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.root.eroot != b.root.eroot {
+		t.Errorf("tree root depends on input order")
+	}
+}
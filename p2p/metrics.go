@@ -29,6 +29,7 @@ var (
 	ingressTrafficMeter = metrics.NewRegisteredMeter("p2p/InboundTraffic", nil)
 	egressConnectMeter  = metrics.NewRegisteredMeter("p2p/OutboundConnects", nil)
 	egressTrafficMeter  = metrics.NewRegisteredMeter("p2p/OutboundTraffic", nil)
+	throttleTimer       = metrics.NewRegisteredTimer("p2p/Throttle", nil)
 )
 
 // meteredConn is a wrapper around a network TCP connection that meters both the
@@ -107,6 +107,12 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// reputation receives misbehaviour reports if set
+	reputation *reputation
+
+	// capture records all sub-protocol messages if set
+	capture *captureWriter
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -165,6 +171,9 @@ func (p *Peer) Inbound() bool {
 
 func newPeer(conn *conn, protocols []Protocol) *Peer {
 	protomap := matchProtocols(protocols, conn.caps, conn)
+	if conn.bw != nil {
+		conn.bw.assign(protomap)
+	}
 	p := &Peer{
 		rw:       conn,
 		running:  protomap,
@@ -177,6 +186,14 @@ func newPeer(conn *conn, protocols []Protocol) *Peer {
 	return p
 }
 
+// Report records a misbehaviour of the peer. Peers accumulating too many faults
+// are banned temporarily and disconnected.
+func (p *Peer) Report(fault PeerFault) {
+	if p.reputation.report(p.ID(), fault) {
+		p.Disconnect(DiscUselessPeer)
+	}
+}
+
 func (p *Peer) Log() log.Logger {
 	return p.log
 }
@@ -322,7 +339,14 @@ outer:
 					offset -= old.Length
 				}
 				// Assign the new match
-				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw}
+				result[cap.Name] = &protoRW{
+					Protocol: proto,
+					offset:   offset,
+					in:       make(chan Msg),
+					w:        rw,
+					inRate:   new(rateMeter),
+					outRate:  new(rateMeter),
+				}
 				offset += proto.Length
 
 				continue outer
@@ -343,6 +367,9 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name)
 		}
+		if p.capture != nil {
+			rw = newMsgCapturer(rw, p.capture, p.ID(), proto.Name, proto.Version)
+		}
 		p.log.Trace(fmt.Sprintf("Starting protocol %s/%d", proto.Name, proto.Version))
 		go func() {
 			err := proto.Run(p, rw)
@@ -377,16 +404,26 @@ type protoRW struct {
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
+
+	ingress, egress []limiter // bandwidth shares, if configured
+	inRate, outRate *rateMeter
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	if msg.Code >= rw.Length {
 		return newPeerError(errInvalidMsgCode, "not handled")
 	}
+	if err := throttle(rw.egress, msg.Size, 0, rw.closed); err != nil {
+		return err
+	}
 	msg.Code += rw.offset
+	size := msg.Size
 	select {
 	case <-rw.wstart:
 		err = rw.w.WriteMsg(msg)
+		if err == nil {
+			rw.outRate.mark(size)
+		}
 		// Report write status back to Peer.run. It will initiate
 		// shutdown if the error is non-nil and unblock the next write
 		// otherwise. The calling protocol code should exit for errors
@@ -398,10 +435,16 @@ func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	return err
 }
 
+// ReadMsg returns the next message of the protocol. Messages are throttled
+// according to the ingress shares once they have been read from the connection.
 func (rw *protoRW) ReadMsg() (Msg, error) {
 	select {
 	case msg := <-rw.in:
 		msg.Code -= rw.offset
+		rw.inRate.mark(msg.Size)
+		if err := throttle(rw.ingress, msg.Size, maxIngressDelay, rw.closed); err != nil {
+			return Msg{}, err
+		}
 		return msg, nil
 	case <-rw.closed:
 		return Msg{}, io.EOF
@@ -422,7 +465,8 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Protocols map[string]interface{} `json:"protocols"`           // Sub-protocol specific metadata fields
+	Bandwidth *PeerBandwidth         `json:"bandwidth,omitempty"` // Traffic of the connection and its sub-protocols
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -457,5 +501,15 @@ func (p *Peer) Info() *PeerInfo {
 		}
 		info.Protocols[proto.Name] = protoInfo
 	}
+	// Gather the bandwidth usage
+	if bw := p.rw.bw; bw != nil {
+		info.Bandwidth = &PeerBandwidth{
+			BandwidthUsage: bandwidthUsage(&bw.in, &bw.out),
+			Protocols:      make(map[string]BandwidthUsage),
+		}
+		for _, proto := range p.running {
+			info.Bandwidth.Protocols[proto.Name] = bandwidthUsage(proto.inRate, proto.outRate)
+		}
+	}
 	return info
 }
@@ -0,0 +1,253 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/log"
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+const (
+	banThreshold    = 100              // Accumulated penalty at which a peer is banned temporarily
+	banDuration     = time.Hour        // Duration of automatic temporary bans
+	penaltyHalfLife = 30 * time.Minute // Time in which an accumulated penalty is halved
+	penaltyMinimum  = 1                // Penalty below which a peer is forgotten
+)
+
+// PeerFault is a kind of misbehaviour protocols can report about a peer.
+type PeerFault int
+
+const (
+	FaultUseless PeerFault = iota // Peer is of no use to the protocol (e.g. failed to serve a sync)
+	FaultInvalid                  // Peer sent invalid data (e.g. a bad block)
+	FaultSlow                     // Peer failed to answer a request in time
+)
+
+// faultPenalties is the penalty applied for each kind of fault.
+var faultPenalties = [...]float64{
+	FaultUseless: 20,
+	FaultInvalid: 50,
+	FaultSlow:    10,
+}
+
+var faultToString = [...]string{
+	FaultUseless: "useless",
+	FaultInvalid: "invalid",
+	FaultSlow:    "slow",
+}
+
+func (f PeerFault) String() string {
+	if f < 0 || int(f) >= len(faultToString) {
+		return fmt.Sprintf("unknown fault %d", int(f))
+	}
+	return faultToString[f]
+}
+
+// BanInfo describes a banned node.
+type BanInfo struct {
+	ID         discover.NodeID `json:"id"`
+	Persistent bool            `json:"persistent"`      // Whether the ban never expires
+	Until      *time.Time      `json:"until,omitempty"` // Expiration of temporary bans
+}
+
+// banStore is implemented by discovery tables able to persist bans in the
+// node database. The zero time denotes a persistent ban.
+type banStore interface {
+	StoreBan(id discover.NodeID, until time.Time) error
+	DeleteBan(id discover.NodeID) error
+	Bans() map[discover.NodeID]time.Time
+}
+
+// penalty is the decaying accumulated penalty of a peer.
+type penalty struct {
+	value   float64
+	updated time.Time
+}
+
+// reputation tracks the penalties of misbehaving peers and bans them if their
+// accumulated penalty reaches the threshold. Bans are persisted if a store is
+// available, otherwise they only live in memory.
+type reputation struct {
+	store banStore
+	now   func() time.Time
+
+	lock      sync.Mutex
+	penalties map[discover.NodeID]*penalty
+	bans      map[discover.NodeID]time.Time // zero time for persistent bans
+}
+
+// newReputation creates the reputation tracker, loading the stored bans.
+func newReputation(store banStore) *reputation {
+	r := &reputation{
+		store:     store,
+		now:       time.Now,
+		penalties: make(map[discover.NodeID]*penalty),
+		bans:      make(map[discover.NodeID]time.Time),
+	}
+	if store != nil {
+		for id, until := range store.Bans() {
+			r.bans[id] = until
+		}
+	}
+	return r
+}
+
+// report applies the penalty of the given fault to a peer, banning it if the
+// accumulated penalty reaches the threshold. It returns whether the peer was
+// banned.
+func (r *reputation) report(id discover.NodeID, fault PeerFault) bool {
+	if r == nil || fault < 0 || int(fault) >= len(faultPenalties) {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.now()
+	p := r.penalties[id]
+	if p == nil {
+		p = &penalty{updated: now}
+		r.penalties[id] = p
+	}
+	p.value = decay(p.value, now.Sub(p.updated)) + faultPenalties[fault]
+	p.updated = now
+	log.Trace("Peer misbehaviour reported", "id", id, "fault", fault, "penalty", p.value)
+
+	if p.value < banThreshold {
+		r.expire(now)
+		return false
+	}
+	delete(r.penalties, id)
+	if until, ok := r.bans[id]; ok && until.IsZero() {
+		return true // already banned persistently
+	}
+	log.Debug("Banning misbehaving peer", "id", id, "duration", banDuration)
+	r.setBan(id, now.Add(banDuration))
+	return true
+}
+
+// ban bans a node for the given duration, or persistently if it is zero.
+func (r *reputation) ban(id discover.NodeID, duration time.Duration) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	var until time.Time
+	if duration > 0 {
+		until = r.now().Add(duration)
+	}
+	return r.setBan(id, until)
+}
+
+// setBan stores a ban. The caller must hold r.lock.
+func (r *reputation) setBan(id discover.NodeID, until time.Time) error {
+	r.bans[id] = until
+	if r.store != nil {
+		return r.store.StoreBan(id, until)
+	}
+	return nil
+}
+
+// unban lifts the ban of a node and forgets its penalty. It returns whether
+// the node was banned.
+func (r *reputation) unban(id discover.NodeID) (bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	_, banned := r.bans[id]
+	delete(r.bans, id)
+	delete(r.penalties, id)
+	if r.store != nil {
+		return banned, r.store.DeleteBan(id)
+	}
+	return banned, nil
+}
+
+// isBanned reports whether a node is currently banned.
+func (r *reputation) isBanned(id discover.NodeID) bool {
+	if r == nil {
+		return false
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	until, ok := r.bans[id]
+	if !ok {
+		return false
+	}
+	if !until.IsZero() && !r.now().Before(until) {
+		r.liftBan(id)
+		return false
+	}
+	return true
+}
+
+// list returns all active bans, sorted by node ID.
+func (r *reputation) list() []*BanInfo {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.expire(r.now())
+	infos := make([]*BanInfo, 0, len(r.bans))
+	for id, until := range r.bans {
+		info := &BanInfo{ID: id, Persistent: until.IsZero()}
+		if !info.Persistent {
+			until := until
+			info.Until = &until
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ID.String() < infos[j].ID.String()
+	})
+	return infos
+}
+
+// expire drops all expired bans and forgotten penalties. The caller must hold
+// r.lock.
+func (r *reputation) expire(now time.Time) {
+	for id, until := range r.bans {
+		if !until.IsZero() && !now.Before(until) {
+			r.liftBan(id)
+		}
+	}
+	for id, p := range r.penalties {
+		if decay(p.value, now.Sub(p.updated)) < penaltyMinimum {
+			delete(r.penalties, id)
+		}
+	}
+}
+
+// liftBan removes an expired ban. The caller must hold r.lock.
+func (r *reputation) liftBan(id discover.NodeID) {
+	delete(r.bans, id)
+	if r.store != nil {
+		r.store.DeleteBan(id)
+	}
+}
+
+// decay returns the remaining part of a penalty after the given time.
+func decay(value float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return value
+	}
+	return value * math.Pow(0.5, float64(elapsed)/float64(penaltyHalfLife))
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 The happyuc-go Authors
+// This file is part of the happyuc-go library.
+//
+// The happyuc-go library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The happyuc-go library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the happyuc-go library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+// memBanStore is an in-memory ban store.
+type memBanStore map[discover.NodeID]time.Time
+
+func (s memBanStore) StoreBan(id discover.NodeID, until time.Time) error {
+	s[id] = until
+	return nil
+}
+
+func (s memBanStore) DeleteBan(id discover.NodeID) error {
+	delete(s, id)
+	return nil
+}
+
+func (s memBanStore) Bans() map[discover.NodeID]time.Time {
+	return s
+}
+
+func newTestReputation(store banStore) (*reputation, *time.Time) {
+	now := time.Unix(1000000, 0)
+	r := newReputation(store)
+	r.now = func() time.Time { return now }
+	return r, &now
+}
+
+func TestReputationThresholdBan(t *testing.T) {
+	store := make(memBanStore)
+	r, now := newTestReputation(store)
+	id := discover.NodeID{1}
+
+	if r.report(id, FaultInvalid) {
+		t.Fatal("peer banned after a single fault")
+	}
+	if !r.report(id, FaultInvalid) {
+		t.Fatal("peer not banned after reaching the threshold")
+	}
+	if !r.isBanned(id) {
+		t.Fatal("isBanned false after ban")
+	}
+	if until, ok := store[id]; !ok || !until.Equal(now.Add(banDuration)) {
+		t.Fatalf("wrong stored ban: %v %v", until, ok)
+	}
+	// The ban expires after banDuration.
+	*now = now.Add(banDuration)
+	if r.isBanned(id) {
+		t.Fatal("ban did not expire")
+	}
+	if _, ok := store[id]; ok {
+		t.Fatal("expired ban not removed from store")
+	}
+}
+
+func TestReputationDecay(t *testing.T) {
+	r, now := newTestReputation(nil)
+	id := discover.NodeID{1}
+
+	r.report(id, FaultInvalid)
+	*now = now.Add(2 * penaltyHalfLife)
+	if r.report(id, FaultInvalid) {
+		t.Fatal("peer banned although its penalty decayed")
+	}
+	if r.isBanned(id) {
+		t.Fatal("isBanned true without ban")
+	}
+	// Penalties below the minimum are forgotten.
+	*now = now.Add(20 * penaltyHalfLife)
+	r.list()
+	if len(r.penalties) != 0 {
+		t.Fatalf("decayed penalty not forgotten: %d left", len(r.penalties))
+	}
+}
+
+func TestReputationPersistentBan(t *testing.T) {
+	store := make(memBanStore)
+	r, now := newTestReputation(store)
+	id := discover.NodeID{2}
+
+	if err := r.ban(id, 0); err != nil {
+		t.Fatal(err)
+	}
+	*now = now.Add(100 * banDuration)
+	if !r.isBanned(id) {
+		t.Fatal("persistent ban expired")
+	}
+	bans := r.list()
+	if len(bans) != 1 || bans[0].ID != id || !bans[0].Persistent || bans[0].Until != nil {
+		t.Fatalf("wrong ban list: %+v", bans)
+	}
+	// Bans are loaded from the store on startup.
+	r2, _ := newTestReputation(store)
+	if !r2.isBanned(id) {
+		t.Fatal("stored ban not loaded")
+	}
+	if banned, err := r2.unban(id); !banned || err != nil {
+		t.Fatalf("unban returned %v, %v", banned, err)
+	}
+	if len(store) != 0 {
+		t.Fatal("unban did not remove stored ban")
+	}
+	if banned, _ := r2.unban(id); banned {
+		t.Fatal("unban of unknown node returned true")
+	}
+}
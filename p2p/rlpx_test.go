@@ -19,6 +19,7 @@ package p2p
 import (
 	"bytes"
 	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -223,6 +224,85 @@ func TestProtocolHandshake(t *testing.T) {
 	wg.Wait()
 }
 
+func TestProtocolHandshakeSnappy(t *testing.T) {
+	testProtocolHandshakeSnappy(t, []snappyHandshakeTest{
+		{snappyProtocolVersion, snappyProtocolVersion, true},
+		{snappyProtocolVersion - 1, snappyProtocolVersion - 1, false},
+	})
+}
+
+// Tests that a v5 node talking to a v4 node falls back to uncompressed messages
+// on both sides, regardless of which one dialed.
+func TestProtocolHandshakeSnappyMismatch(t *testing.T) {
+	testProtocolHandshakeSnappy(t, []snappyHandshakeTest{
+		{snappyProtocolVersion - 1, snappyProtocolVersion, false},
+		{snappyProtocolVersion, snappyProtocolVersion - 1, false},
+	})
+}
+
+type snappyHandshakeTest struct {
+	dialVersion, listenVersion uint64
+	snappy                     bool
+}
+
+func testProtocolHandshakeSnappy(t *testing.T, tests []snappyHandshakeTest) {
+	for i, test := range tests {
+		var (
+			prv0, _ = crypto.GenerateKey()
+			prv1, _ = crypto.GenerateKey()
+			node1   = &discover.Node{ID: discover.PubkeyID(&prv1.PublicKey)}
+			hs0     = &protoHandshake{Version: test.dialVersion, ID: discover.PubkeyID(&prv0.PublicKey)}
+			hs1     = &protoHandshake{Version: test.listenVersion, ID: node1.ID}
+			wmsg    = []interface{}{strings.Repeat("test", 1000)}
+			wg      sync.WaitGroup
+		)
+		fd0, fd1, err := tcpPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			defer fd0.Close()
+			rlpx := newRLPX(fd0).(*rlpx)
+			if _, err := rlpx.doEncHandshake(prv0, node1); err != nil {
+				t.Errorf("test %d: dial side enc handshake failed: %v", i, err)
+				return
+			}
+			if _, err := rlpx.doProtoHandshake(hs0); err != nil {
+				t.Errorf("test %d: dial side proto handshake error: %v", i, err)
+				return
+			}
+			if rlpx.rw.snappy != test.snappy {
+				t.Errorf("test %d: dial side snappy mismatch: got %v, want %v", i, rlpx.rw.snappy, test.snappy)
+			}
+			if err := Send(rlpx, 16, wmsg); err != nil {
+				t.Errorf("test %d: send error: %v", i, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			defer fd1.Close()
+			rlpx := newRLPX(fd1).(*rlpx)
+			if _, err := rlpx.doEncHandshake(prv1, nil); err != nil {
+				t.Errorf("test %d: listen side enc handshake failed: %v", i, err)
+				return
+			}
+			if _, err := rlpx.doProtoHandshake(hs1); err != nil {
+				t.Errorf("test %d: listen side proto handshake error: %v", i, err)
+				return
+			}
+			if rlpx.rw.snappy != test.snappy {
+				t.Errorf("test %d: listen side snappy mismatch: got %v, want %v", i, rlpx.rw.snappy, test.snappy)
+			}
+			if err := ExpectMsg(rlpx, 16, wmsg); err != nil {
+				t.Errorf("test %d: receive error: %v", i, err)
+			}
+		}()
+		wg.Wait()
+	}
+}
+
 func TestProtocolHandshakeErrors(t *testing.T) {
 	our := &protoHandshake{Version: 3, Caps: []Cap{{"foo", 2}, {"bar", 3}}, Name: "quux"}
 	tests := []struct {
@@ -378,6 +458,80 @@ func TestRLPXFrameRW(t *testing.T) {
 	}
 }
 
+func TestRLPXFrameRWSnappy(t *testing.T) {
+	conn := new(bytes.Buffer)
+	rw1, rw2 := newTestFrameRWs(conn)
+	rw1.snappy, rw2.snappy = true, true
+
+	// Compressible messages shrink on the wire and arrive intact.
+	wmsg := []interface{}{strings.Repeat("test", 10000)}
+	wantPayload, _ := rlp.EncodeToBytes(wmsg)
+	if err := Send(rw1, 8, wmsg); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if conn.Len() >= len(wantPayload) {
+		t.Errorf("message not compressed: %d bytes on the wire, payload %d bytes", conn.Len(), len(wantPayload))
+	}
+	msg, err := rw2.ReadMsg()
+	if err != nil {
+		t.Fatalf("ReadMsg error: %v", err)
+	}
+	if msg.Size != uint32(len(wantPayload)) {
+		t.Errorf("msg size mismatch: got %d, want %d", msg.Size, len(wantPayload))
+	}
+	payload, _ := ioutil.ReadAll(msg.Payload)
+	if msg.Code != 8 || !bytes.Equal(payload, wantPayload) {
+		t.Fatalf("msg mismatch: code %d, payload %x", msg.Code, payload)
+	}
+
+	// Messages claiming an oversized decompressed length are rejected.
+	var header [binary.MaxVarintLen32]byte
+	n := binary.PutUvarint(header[:], uint64(maxUint24)+1)
+	rw1.snappy = false
+	fake := append(header[:n], make([]byte, 16)...)
+	if err := rw1.WriteMsg(Msg{Code: 8, Size: uint32(len(fake)), Payload: bytes.NewReader(fake)}); err != nil {
+		t.Fatalf("WriteMsg error: %v", err)
+	}
+	if _, err := rw2.ReadMsg(); err != errPlainMessageTooLarge {
+		t.Fatalf("wrong error for oversized message: got %v, want %v", err, errPlainMessageTooLarge)
+	}
+}
+
+// newTestFrameRWs creates two frame readers/writers with matching secrets
+// communicating through conn.
+func newTestFrameRWs(conn io.ReadWriter) (*rlpxFrameRW, *rlpxFrameRW) {
+	var (
+		aesSecret      = make([]byte, 16)
+		macSecret      = make([]byte, 16)
+		egressMACinit  = make([]byte, 32)
+		ingressMACinit = make([]byte, 32)
+	)
+	for _, s := range [][]byte{aesSecret, macSecret, egressMACinit, ingressMACinit} {
+		rand.Read(s)
+	}
+	s1 := secrets{
+		AES:        aesSecret,
+		MAC:        macSecret,
+		EgressMAC:  sha3.NewKeccak256(),
+		IngressMAC: sha3.NewKeccak256(),
+	}
+	s1.EgressMAC.Write(egressMACinit)
+	s1.IngressMAC.Write(ingressMACinit)
+	rw1 := newRLPXFrameRW(conn, s1)
+
+	s2 := secrets{
+		AES:        aesSecret,
+		MAC:        macSecret,
+		EgressMAC:  sha3.NewKeccak256(),
+		IngressMAC: sha3.NewKeccak256(),
+	}
+	s2.EgressMAC.Write(ingressMACinit)
+	s2.IngressMAC.Write(egressMACinit)
+	rw2 := newRLPXFrameRW(conn, s2)
+
+	return rw1, rw2
+}
+
 type handshakeAuthTest struct {
 	input       string
 	isPlain     bool
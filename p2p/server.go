@@ -18,7 +18,9 @@
 package p2p
 
 import (
+	"bytes"
 	"crypto/ecdsa"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"net"
@@ -31,8 +33,11 @@ import (
 	"github.com/irchain/go-irchain/log"
 	"github.com/irchain/go-irchain/p2p/discover"
 	"github.com/irchain/go-irchain/p2p/discv5"
+	"github.com/irchain/go-irchain/p2p/dnsdisc"
+	"github.com/irchain/go-irchain/p2p/enr"
 	"github.com/irchain/go-irchain/p2p/nat"
 	"github.com/irchain/go-irchain/p2p/netutil"
+	"github.com/irchain/go-irchain/rlp"
 )
 
 const (
@@ -80,6 +85,10 @@ type Config struct {
 	// protocol should be started or not.
 	DiscoveryV5 bool `toml:",omitempty"`
 
+	// DNSDiscovery is a list of enrtree:// URLs of DNS node lists (EIP-1459)
+	// used as an additional source of dial candidates.
+	DNSDiscovery []string `toml:",omitempty"`
+
 	// Name sets the node name of this server.
 	// Use common.MakeName to create a name that follows existing conventions.
 	Name string `toml:"-"`
@@ -135,10 +144,36 @@ type Config struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
+	// MaxIngressRate and MaxEgressRate limit the total bandwidth of all peer
+	// connections in bytes per second. Zero means unlimited.
+	MaxIngressRate uint64 `toml:",omitempty"`
+	MaxEgressRate  uint64 `toml:",omitempty"`
+
+	// MaxPeerIngressRate and MaxPeerEgressRate limit the bandwidth of each peer
+	// connection in bytes per second. Zero means unlimited.
+	MaxPeerIngressRate uint64 `toml:",omitempty"`
+	MaxPeerEgressRate  uint64 `toml:",omitempty"`
+
+	// ProtocolShares assigns relative bandwidth shares to sub-protocols by name,
+	// protocols without an entry having a share of one. If set, each protocol is
+	// guaranteed its share of the bandwidth caps while they are exhausted, and
+	// may use the bandwidth left unused by the others. Otherwise all protocols
+	// compete for the whole bandwidth.
+	//
+	// Ingress limits apply to messages after they have been read, throttling the
+	// reads of subsequent messages.
+	ProtocolShares map[string]uint `toml:",omitempty"`
+
 	// If EnableMsgEvents is set then the server will emit PeerEvents
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
 
+	// CaptureFile is the path of a file into which all sub-protocol messages
+	// sent or received are written for debugging. Capturing is disabled if
+	// empty. The file is rotated when it grows beyond CaptureFileSize bytes.
+	CaptureFile     string `toml:",omitempty"`
+	CaptureFileSize int64  `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 }
@@ -159,8 +194,15 @@ type Server struct {
 	ntab         discoverTable
 	listener     net.Listener
 	ourHandshake *protoHandshake
+	localRecord  *enr.Record
+	localEntries map[string]enr.Entry // entries set after startup, overriding protocol attributes
 	lastLookup   time.Time
 	DiscV5       *discv5.Network
+	dnsPool      *dnsdisc.Pool
+	reputation   *reputation
+	banDB        *discover.BanDB // Ban storage when running without discovery
+	bandwidth    *bandwidth
+	capture      *captureWriter
 
 	// These are for Peers, PeerCount (and nothing else).
 	peerOp     chan peerOpFunc
@@ -201,6 +243,7 @@ type conn struct {
 	transport
 	flags connFlag
 	cont  chan error      // The run loop uses cont to signal errors to SetupConn.
+	bw    *peerBandwidth  // bandwidth limits and usage, nil if untracked
 	id    discover.NodeID // valid after the encryption handshake
 	caps  []Cap           // valid after the protocol handshake
 	name  string          // valid after the protocol handshake
@@ -300,6 +343,55 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// BanPeer bans the given node for the given duration, or persistently if the
+// duration is zero, disconnecting it if connected. Bans are stored in the node
+// database.
+func (srv *Server) BanPeer(id discover.NodeID, duration time.Duration) error {
+	rep := srv.peerReputation()
+	if rep == nil {
+		return errServerStopped
+	}
+	if err := rep.ban(id, duration); err != nil {
+		return err
+	}
+	for _, p := range srv.Peers() {
+		if p.ID() == id {
+			p.Disconnect(DiscUselessPeer)
+		}
+	}
+	return nil
+}
+
+// UnbanPeer lifts the ban of the given node. It returns whether the node was
+// banned.
+func (srv *Server) UnbanPeer(id discover.NodeID) (bool, error) {
+	rep := srv.peerReputation()
+	if rep == nil {
+		return false, errServerStopped
+	}
+	return rep.unban(id)
+}
+
+// Bans returns all active node bans.
+func (srv *Server) Bans() []*BanInfo {
+	rep := srv.peerReputation()
+	if rep == nil {
+		return nil
+	}
+	return rep.list()
+}
+
+// peerReputation returns the reputation tracker if the server is running.
+func (srv *Server) peerReputation() *reputation {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return nil
+	}
+	return srv.reputation
+}
+
 // SubscribePeers subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -336,6 +428,113 @@ func (srv *Server) makeSelf(listener net.Listener, ntab discoverTable) *discover
 	return ntab.Self()
 }
 
+// LocalRecord returns the signed node record of the local node, advertising its
+// endpoint along with the attributes of the running protocols. It returns nil if
+// the server is not running.
+func (srv *Server) LocalRecord() *enr.Record {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return nil
+	}
+	if tab, ok := srv.ntab.(recordTable); ok {
+		return tab.LocalRecord()
+	}
+	return srv.localRecord
+}
+
+// recordTable is implemented by discovery tables maintaining the local node
+// record themselves, refreshing it when the external endpoint changes.
+type recordTable interface {
+	LocalRecord() *enr.Record
+	SetLocalEntries(entries ...enr.Entry) error
+}
+
+// SetLocalEntries adds or replaces entries of the local node record, allowing
+// protocols to update attributes which change over time. The record is re-signed
+// with an increased sequence number if anything changed.
+func (srv *Server) SetLocalEntries(entries ...enr.Entry) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	if !srv.running {
+		return errServerStopped
+	}
+	if tab, ok := srv.ntab.(recordTable); ok {
+		return tab.SetLocalEntries(entries...)
+	}
+	if srv.localEntries == nil {
+		srv.localEntries = make(map[string]enr.Entry)
+	}
+	changed := false
+	for _, e := range entries {
+		if old, ok := srv.localEntries[e.ENRKey()]; !ok || !sameEntry(old, e) {
+			srv.localEntries[e.ENRKey()] = e
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return srv.setupLocalRecord()
+}
+
+// sameEntry reports whether two entries of the same key have the same value.
+func sameEntry(a, b enr.Entry) bool {
+	enca, erra := rlp.EncodeToBytes(a)
+	encb, errb := rlp.EncodeToBytes(b)
+	return erra == nil && errb == nil && bytes.Equal(enca, encb)
+}
+
+// protocolAttributes gathers the node record entries of all running protocols.
+func (srv *Server) protocolAttributes() []enr.Entry {
+	var entries []enr.Entry
+	for _, proto := range srv.Protocols {
+		entries = append(entries, proto.Attributes...)
+	}
+	return entries
+}
+
+// setupLocalRecord assembles and signs the local node record. If the discovery
+// table maintains the record, only the listener's TCP port is added to it.
+func (srv *Server) setupLocalRecord() error {
+	if tab, ok := srv.ntab.(recordTable); ok {
+		if srv.listener == nil {
+			return nil
+		}
+		port := srv.listener.Addr().(*net.TCPAddr).Port
+		return tab.SetLocalEntries(enr.TCP(port))
+	}
+	var (
+		self   = srv.makeSelf(srv.listener, srv.ntab)
+		record = new(enr.Record)
+	)
+	if self.IP != nil && !self.IP.IsUnspecified() {
+		record.Set(enr.IP(self.IP))
+	}
+	if self.TCP != 0 {
+		record.Set(enr.TCP(self.TCP))
+	}
+	if self.UDP != 0 {
+		record.Set(enr.UDP(self.UDP))
+	}
+	for _, attr := range srv.protocolAttributes() {
+		record.Set(attr)
+	}
+	for _, e := range srv.localEntries {
+		record.Set(e)
+	}
+	if srv.localRecord != nil {
+		record.SetSeq(srv.localRecord.Seq() + 1)
+	}
+	if err := enr.SignV4(record, srv.PrivateKey); err != nil {
+		return fmt.Errorf("failed to sign node record: %v", err)
+	}
+	srv.localRecord = record
+	return nil
+}
+
 // Stop terminates the server and all active peer connections.
 // It blocks until all active connections have been closed.
 func (srv *Server) Stop() {
@@ -404,6 +603,13 @@ func (srv *Server) Start() (err error) {
 	if srv.Dialer == nil {
 		srv.Dialer = TCPDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	srv.bandwidth = newBandwidth(&srv.Config)
+	if srv.CaptureFile != "" {
+		if srv.capture, err = newCaptureWriter(srv.CaptureFile, srv.CaptureFileSize); err != nil {
+			return err
+		}
+		srv.log.Warn("Capturing protocol messages", "file", srv.CaptureFile)
+	}
 	srv.quit = make(chan struct{})
 	srv.addpeer = make(chan *conn)
 	srv.delpeer = make(chan peerDrop)
@@ -457,6 +663,7 @@ func (srv *Server) Start() (err error) {
 			NetRestrict:  srv.NetRestrict,
 			Bootnodes:    srv.BootstrapNodes,
 			Unhandled:    unhandled,
+			Entries:      srv.protocolAttributes(),
 		}
 		ntab, err := discover.ListenUDP(conn, cfg)
 		if err != nil {
@@ -484,8 +691,34 @@ func (srv *Server) Start() (err error) {
 		srv.DiscV5 = ntab
 	}
 
+	// peer reputation, with bans persisted in the node database. Without a
+	// discovery table owning the database, open it just for the bans.
+	store, _ := srv.ntab.(banStore)
+	if store == nil {
+		if srv.banDB, err = discover.OpenBanDB(srv.NodeDatabase, discover.PubkeyID(&srv.PrivateKey.PublicKey)); err != nil {
+			return err
+		}
+		store = srv.banDB
+	}
+	srv.reputation = newReputation(store)
+
+	// DNS node lists
+	if len(srv.DNSDiscovery) > 0 {
+		client, err := dnsdisc.NewClient(dnsdisc.Config{Logger: srv.log})
+		if err != nil {
+			return err
+		}
+		if srv.dnsPool, err = client.NewPool(srv.DNSDiscovery...); err != nil {
+			return err
+		}
+	}
+
 	dynPeers := srv.maxDialedConns()
 	dialer := newDialState(srv.StaticNodes, srv.BootstrapNodes, srv.ntab, dynPeers, srv.NetRestrict)
+	if srv.dnsPool != nil {
+		dialer.dns = srv.dnsPool
+	}
+	dialer.banned = srv.reputation.isBanned
 
 	// handshake
 	srv.ourHandshake = &protoHandshake{Version: baseProtocolVersion, Name: srv.Name, ID: discover.PubkeyID(&srv.PrivateKey.PublicKey)}
@@ -501,6 +734,10 @@ func (srv *Server) Start() (err error) {
 	if srv.NoDial && srv.ListenAddr == "" {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
+	// node record
+	if err := srv.setupLocalRecord(); err != nil {
+		return err
+	}
 
 	srv.loopWG.Add(1)
 	go srv.run(dialer)
@@ -638,6 +875,8 @@ running:
 			if err == nil {
 				// The handshakes are done and it passed all checks.
 				p := newPeer(c, srv.Protocols)
+				p.reputation = srv.reputation
+				p.capture = srv.capture
 				// If message events are enabled, pass the peerFeed
 				// to the peer
 				if srv.EnableMsgEvents {
@@ -679,6 +918,9 @@ running:
 	if srv.DiscV5 != nil {
 		srv.DiscV5.Close()
 	}
+	if srv.dnsPool != nil {
+		srv.dnsPool.Close()
+	}
 	// Disconnect all peers.
 	for _, p := range peers {
 		p.Disconnect(DiscQuitting)
@@ -691,6 +933,12 @@ running:
 		p.log.Trace("<-delpeer (spindown)", "remainingTasks", len(runningTasks))
 		delete(peers, p.ID())
 	}
+	if srv.capture != nil {
+		srv.capture.close()
+	}
+	if srv.banDB != nil {
+		srv.banDB.Close()
+	}
 }
 
 func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCount int, c *conn) error {
@@ -713,6 +961,9 @@ func (srv *Server) encHandshakeChecks(peers map[discover.NodeID]*Peer, inboundCo
 		return DiscAlreadyConnected
 	case c.id == srv.Self().ID:
 		return DiscSelf
+	case srv.reputation.isBanned(c.id):
+		srv.log.Trace("Rejecting banned peer", "id", c.id)
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -723,7 +974,7 @@ func (srv *Server) maxInboundConns() int {
 }
 
 func (srv *Server) maxDialedConns() int {
-	if srv.NoDiscovery || srv.NoDial {
+	if (srv.NoDiscovery && len(srv.DNSDiscovery) == 0) || srv.NoDial {
 		return 0
 	}
 	r := srv.DialRatio
@@ -800,6 +1051,10 @@ func (srv *Server) SetupConn(fd net.Conn, flags connFlag, dialDest *discover.Nod
 		return errors.New("shutdown")
 	}
 	c := &conn{fd: fd, transport: srv.newTransport(fd), flags: flags, cont: make(chan error)}
+	if srv.bandwidth != nil {
+		c.bw = srv.bandwidth.newPeer()
+		c.transport = newLimitedTransport(c.transport, c.bw)
+	}
 	err := srv.setupConn(c, flags, dialDest)
 	if err != nil {
 		c.close(err)
@@ -917,6 +1172,7 @@ type NodeInfo struct {
 		Discovery int `json:"discovery"` // UDP listening port for discovery protocol
 		Listener  int `json:"listener"`  // TCP listening port for RLPx
 	} `json:"ports"`
+	ENR        string                 `json:"enr"` // Node record in its textual "enr:" representation
 	ListenAddr string                 `json:"listenAddr"`
 	Protocols  map[string]interface{} `json:"protocols"`
 }
@@ -937,6 +1193,12 @@ func (srv *Server) NodeInfo() *NodeInfo {
 	info.Ports.Discovery = int(node.UDP)
 	info.Ports.Listener = int(node.TCP)
 
+	if record := srv.LocalRecord(); record != nil {
+		if blob, err := rlp.EncodeToBytes(record); err == nil {
+			info.ENR = "enr:" + base64.RawURLEncoding.EncodeToString(blob)
+		}
+	}
+
 	// Gather all the running protocol infos (only once per protocol type)
 	for _, proto := range srv.Protocols {
 		if _, ok := info.Protocols[proto.Name]; !ok {
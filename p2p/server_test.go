@@ -19,9 +19,13 @@ package p2p
 import (
 	"crypto/ecdsa"
 	"errors"
+	"io/ioutil"
 	"math/rand"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,6 +33,7 @@ import (
 	"github.com/irchain/go-irchain/crypto/sha3"
 	"github.com/irchain/go-irchain/log"
 	"github.com/irchain/go-irchain/p2p/discover"
+	"github.com/irchain/go-irchain/p2p/enr"
 )
 
 func init() {
@@ -122,6 +127,76 @@ func TestServerListen(t *testing.T) {
 	}
 }
 
+// Tests that the local node record carries the listener endpoint along with the
+// attributes of the running protocols, and is properly signed.
+func TestServerLocalRecord(t *testing.T)          { testServerLocalRecord(t, true) }
+func TestServerLocalRecordDiscovery(t *testing.T) { testServerLocalRecord(t, false) }
+
+func testServerLocalRecord(t *testing.T, nodiscover bool) {
+	srv := &Server{
+		Config: Config{
+			Name:        "test",
+			MaxPeers:    10,
+			ListenAddr:  "127.0.0.1:0",
+			PrivateKey:  newkey(),
+			NoDiscovery: nodiscover,
+			Protocols: []Protocol{{
+				Name:       "test",
+				Version:    1,
+				Attributes: []enr.Entry{enr.WithEntry("test", uint(42))},
+			}},
+		},
+	}
+	if srv.LocalRecord() != nil {
+		t.Fatalf("node record available before start")
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	defer srv.Stop()
+
+	record := srv.LocalRecord()
+	if !record.Signed() {
+		t.Fatalf("node record not signed")
+	}
+	var (
+		tcp  enr.TCP
+		attr uint
+	)
+	if err := record.Load(&tcp); err != nil {
+		t.Fatalf("failed to load TCP port: %v", err)
+	}
+	if int(tcp) != srv.listener.Addr().(*net.TCPAddr).Port {
+		t.Errorf("TCP port mismatch: have %d, want %d", tcp, srv.listener.Addr().(*net.TCPAddr).Port)
+	}
+	if err := record.Load(enr.WithEntry("test", &attr)); err != nil {
+		t.Fatalf("failed to load protocol attribute: %v", err)
+	}
+	if attr != 42 {
+		t.Errorf("protocol attribute mismatch: have %d, want 42", attr)
+	}
+	if enc := srv.NodeInfo().ENR; !strings.HasPrefix(enc, "enr:") {
+		t.Errorf("node info lacks the node record: %q", enc)
+	}
+	// Update the protocol attribute and check that the record is re-signed
+	if err := srv.SetLocalEntries(enr.WithEntry("test", uint(43))); err != nil {
+		t.Fatalf("failed to update local record: %v", err)
+	}
+	updated := srv.LocalRecord()
+	if updated.Seq() <= record.Seq() {
+		t.Errorf("record sequence not increased: have %d, previous %d", updated.Seq(), record.Seq())
+	}
+	if err := updated.Load(enr.WithEntry("test", &attr)); err != nil {
+		t.Fatalf("failed to load updated protocol attribute: %v", err)
+	}
+	if attr != 43 {
+		t.Errorf("updated protocol attribute mismatch: have %d, want 43", attr)
+	}
+	if !updated.Signed() {
+		t.Errorf("updated node record not signed")
+	}
+}
+
 func TestServerDial(t *testing.T) {
 	// run a one-shot TCP server to handle the connection.
 	listener, err := net.Listen("tcp", "127.0.0.1:0")
@@ -502,3 +577,41 @@ func randomID() (id discover.NodeID) {
 	}
 	return id
 }
+
+// Tests that bans are persisted in the node database even if discovery is off.
+func TestServerBansWithoutDiscovery(t *testing.T) {
+	root, err := ioutil.TempDir("", "p2p-bans-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	config := Config{
+		MaxPeers:     10,
+		ListenAddr:   "127.0.0.1:0",
+		PrivateKey:   newkey(),
+		NoDiscovery:  true,
+		NodeDatabase: filepath.Join(root, "nodes"),
+	}
+	id := randomID()
+
+	srv := &Server{Config: config}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not start server: %v", err)
+	}
+	if err := srv.BanPeer(id, 0); err != nil {
+		t.Fatalf("failed to ban peer: %v", err)
+	}
+	srv.Stop()
+
+	srv = &Server{Config: config}
+	if err := srv.Start(); err != nil {
+		t.Fatalf("could not restart server: %v", err)
+	}
+	defer srv.Stop()
+
+	bans := srv.Bans()
+	if len(bans) != 1 || bans[0].ID != id || !bans[0].Persistent {
+		t.Fatalf("ban not persisted: %+v", bans)
+	}
+}
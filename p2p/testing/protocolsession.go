@@ -26,6 +26,7 @@ import (
 	"github.com/irchain/go-irchain/p2p"
 	"github.com/irchain/go-irchain/p2p/discover"
 	"github.com/irchain/go-irchain/p2p/simulations/adapters"
+	"github.com/irchain/go-irchain/rlp"
 )
 
 var errTimedOut = errors.New("timed out")
@@ -37,6 +38,7 @@ type ProtocolSession struct {
 	Server  *p2p.Server
 	IDs     []discover.NodeID
 	adapter *adapters.SimAdapter
+	mocks   map[discover.NodeID]*mockNode // dummy peers not run by the adapter
 	events  chan *p2p.PeerEvent
 }
 
@@ -77,15 +79,30 @@ type Disconnect struct {
 	Error error           // disconnect reason
 }
 
-// trigger sends messages from peers
-func (s *ProtocolSession) trigger(trig Trigger) error {
-	simNode, ok := s.adapter.GetNode(trig.Peer)
+// mockNode retrieves the mock running the dummy peer with the given id
+func (s *ProtocolSession) mockNode(id discover.NodeID) (*mockNode, error) {
+	if mock, ok := s.mocks[id]; ok {
+		return mock, nil
+	}
+	if s.adapter == nil {
+		return nil, fmt.Errorf("peer %v does not exist (1- %v)", id, len(s.IDs))
+	}
+	simNode, ok := s.adapter.GetNode(id)
 	if !ok {
-		return fmt.Errorf("trigger: peer %v does not exist (1- %v)", trig.Peer, len(s.IDs))
+		return nil, fmt.Errorf("peer %v does not exist (1- %v)", id, len(s.IDs))
 	}
 	mockNode, ok := simNode.Services()[0].(*mockNode)
 	if !ok {
-		return fmt.Errorf("trigger: peer %v is not a mock", trig.Peer)
+		return nil, fmt.Errorf("peer %v is not a mock", id)
+	}
+	return mockNode, nil
+}
+
+// trigger sends messages from peers
+func (s *ProtocolSession) trigger(trig Trigger) error {
+	mockNode, err := s.mockNode(trig.Peer)
+	if err != nil {
+		return fmt.Errorf("trigger: %v", err)
 	}
 
 	errc := make(chan error)
@@ -120,13 +137,9 @@ func (s *ProtocolSession) expect(exps []Expect) error {
 	// construct a map of mockNodes for each node
 	mockNodes := make(map[discover.NodeID]*mockNode)
 	for nodeID := range peerExpects {
-		simNode, ok := s.adapter.GetNode(nodeID)
-		if !ok {
-			return fmt.Errorf("trigger: peer %v does not exist (1- %v)", nodeID, len(s.IDs))
-		}
-		mockNode, ok := simNode.Services()[0].(*mockNode)
-		if !ok {
-			return fmt.Errorf("trigger: peer %v is not a mock", nodeID)
+		mockNode, err := s.mockNode(nodeID)
+		if err != nil {
+			return fmt.Errorf("trigger: %v", err)
 		}
 		mockNodes[nodeID] = mockNode
 	}
@@ -278,3 +291,34 @@ func (s *ProtocolSession) TestDisconnected(disconnects ...*Disconnect) error {
 	}
 	return nil
 }
+
+// Replay replays captured messages of a single connection against the pivot
+// node, which takes the role of the capturing node. Messages the capturing node
+// received are sent by the given dummy peer, messages it sent are expected to
+// be sent by the pivot node in the same order of exchanges. Callers should
+// filter out the records irrelevant for the replay, e.g. those of other peers.
+func (s *ProtocolSession) Replay(peer discover.NodeID, records []*p2p.CaptureRecord) error {
+	return s.TestExchanges(CaptureExchanges(peer, records)...)
+}
+
+// CaptureExchanges converts captured messages into exchanges with the given
+// dummy peer. Each exchange consists of consecutive received messages as
+// triggers, followed by the consecutive sent messages as expects.
+func CaptureExchanges(peer discover.NodeID, records []*p2p.CaptureRecord) []Exchange {
+	var (
+		exchanges []Exchange
+		current   *Exchange
+	)
+	for i, rec := range records {
+		if current == nil || (rec.Inbound && len(current.Expects) > 0) {
+			exchanges = append(exchanges, Exchange{Label: fmt.Sprintf("capture record %d", i)})
+			current = &exchanges[len(exchanges)-1]
+		}
+		if rec.Inbound {
+			current.Triggers = append(current.Triggers, Trigger{Msg: rlp.RawValue(rec.Payload), Code: rec.Code, Peer: peer})
+		} else {
+			current.Expects = append(current.Expects, Expect{Msg: rlp.RawValue(rec.Payload), Code: rec.Code, Peer: peer})
+		}
+	}
+	return exchanges
+}
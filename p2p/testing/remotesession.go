@@ -0,0 +1,91 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package testing
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"time"
+
+	"github.com/irchain/go-irchain/event"
+	"github.com/irchain/go-irchain/p2p"
+	"github.com/irchain/go-irchain/p2p/discover"
+)
+
+// RemoteSession is a protocol session with a running node as the pivot node,
+// reached over the network by a single dummy peer.
+type RemoteSession struct {
+	*ProtocolSession
+	mock *mockNode
+	sub  event.Subscription
+}
+
+// NewRemoteSession starts a dummy peer with the given key and connects it to
+// the remote node, speaking the given protocol. The run function of the
+// protocol is replaced by the mock. The dummy peer is the only entry of IDs.
+func NewRemoteSession(key *ecdsa.PrivateKey, remote *discover.Node, proto p2p.Protocol, timeout time.Duration) (*RemoteSession, error) {
+	mock := newMockNode()
+	proto.Run = mock.Run
+
+	srv := &p2p.Server{Config: p2p.Config{
+		PrivateKey:  key,
+		MaxPeers:    1,
+		NoDiscovery: true,
+		Protocols:   []p2p.Protocol{proto},
+	}}
+	events := make(chan *p2p.PeerEvent, 1000)
+	sub := srv.SubscribeEvents(events)
+
+	if err := srv.Start(); err != nil {
+		sub.Unsubscribe()
+		return nil, err
+	}
+	id := discover.PubkeyID(&key.PublicKey)
+	s := &RemoteSession{
+		ProtocolSession: &ProtocolSession{
+			Server: srv,
+			IDs:    []discover.NodeID{id},
+			mocks:  map[discover.NodeID]*mockNode{id: mock},
+			events: events,
+		},
+		mock: mock,
+		sub:  sub,
+	}
+	srv.AddPeer(remote)
+
+	alarm := time.NewTimer(timeout)
+	defer alarm.Stop()
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == p2p.PeerEventTypeAdd && ev.Peer == remote.ID {
+				return s, nil
+			}
+		case <-alarm.C:
+			s.Stop()
+			return nil, fmt.Errorf("can't connect to %s/%d peer %v", proto.Name, proto.Version, remote.ID)
+		}
+	}
+}
+
+// Stop shuts down the dummy peer.
+func (s *RemoteSession) Stop() error {
+	s.sub.Unsubscribe()
+	s.mock.Stop()
+	s.Server.Stop()
+	return nil
+}
@@ -130,6 +130,15 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// CheckpointOracleConfig is the configuration of a checkpoint oracle contract,
+// which light clients use to learn about new checkpoints signed by a quorum of
+// trusted signers.
+type CheckpointOracleConfig struct {
+	Address   common.Address   `json:"address"`   // Address of the oracle contract
+	Signers   []common.Address `json:"signers"`   // Signers trusted to vote on checkpoints
+	Threshold uint64           `json:"threshold"` // Number of signatures required to accept a checkpoint
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -0,0 +1,144 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// jwtIssuedAtWindow is the maximum allowed difference between the issuance time
+// of a token and the local time. Tokens are expected to be generated fresh for
+// every request (or connection), which limits the usefulness of replaying them.
+const jwtIssuedAtWindow = 60 * time.Second
+
+var (
+	errMissingToken = errors.New("missing bearer token")
+	errStaleToken   = errors.New("stale token")
+)
+
+// TokenProvider is invoked by the client every time it needs to authenticate
+// against the server, returning the bearer token to send along. HTTP clients
+// request a token for every call, websocket clients for every connection.
+type TokenProvider func() (string, error)
+
+// JWTClaims are the claims of the tokens accepted by authenticated endpoints.
+// If Namespaces is empty, all namespaces registered on the endpoint may be used.
+// If the subject is set, rate limits are applied to it instead of the client IP.
+type JWTClaims struct {
+	jwt.StandardClaims
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// NewJWTTokenProvider returns a token provider generating HS256 tokens signed
+// with the given secret, optionally restricted to the given namespaces.
+func NewJWTTokenProvider(secret []byte, namespaces []string) TokenProvider {
+	return func() (string, error) {
+		claims := &JWTClaims{
+			StandardClaims: jwt.StandardClaims{IssuedAt: time.Now().Unix()},
+			Namespaces:     namespaces,
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	}
+}
+
+// authNamespacesKey is the context key under which the namespaces permitted by
+// the token of an authenticated request are stored.
+type authNamespacesKey struct{}
+
+// jwtHandler is an http.Handler which rejects requests without a valid bearer
+// token, and passes the namespace restrictions of accepted ones to the server.
+type jwtHandler struct {
+	secret []byte
+	next   http.Handler
+}
+
+// newJWTHandler wraps the given handler with token authentication. A nil secret
+// disables authentication.
+func newJWTHandler(secret []byte, next http.Handler) http.Handler {
+	if len(secret) == 0 {
+		return next
+	}
+	return &jwtHandler{secret: secret, next: next}
+}
+
+// ServeHTTP implements http.Handler
+func (h *jwtHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if claims.Subject != "" {
+		r = r.WithContext(context.WithValue(r.Context(), authClientKey{}, claims.Subject))
+	}
+	if len(claims.Namespaces) > 0 {
+		allowed := make(map[string]bool)
+		for _, namespace := range claims.Namespaces {
+			allowed[namespace] = true
+		}
+		r = r.WithContext(context.WithValue(r.Context(), authNamespacesKey{}, allowed))
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// authenticate validates the bearer token of the request, returning its claims.
+func (h *jwtHandler) authenticate(r *http.Request) (*JWTClaims, error) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil, errMissingToken
+	}
+	claims := new(JWTClaims)
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(auth, "Bearer "), claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return h.secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	issued := time.Unix(claims.IssuedAt, 0)
+	if diff := time.Since(issued); diff > jwtIssuedAtWindow || diff < -jwtIssuedAtWindow {
+		return nil, errStaleToken
+	}
+	return claims, nil
+}
+
+// restrictNamespaces fails the requests calling into namespaces not permitted
+// by the token the request or connection was authenticated with. The metadata
+// namespace is always available.
+func restrictNamespaces(ctx context.Context, reqs []*serverRequest) {
+	allowed, ok := ctx.Value(authNamespacesKey{}).(map[string]bool)
+	if !ok {
+		return
+	}
+	for _, req := range reqs {
+		if req.err != nil || req.callb == nil || req.svcname == MetadataApi {
+			continue
+		}
+		if !allowed[req.svcname] {
+			req.err = &unauthorizedError{req.svcname}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var testJWTSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// authTestClient starts an authenticated endpoint serving the test service and
+// dials it with the given token provider.
+func authTestClient(t *testing.T, transport string, auth TokenProvider) (*Client, *httptest.Server, error) {
+	srv := newTestServer("service", new(Service))
+
+	var hs *httptest.Server
+	switch transport {
+	case "http":
+		hs = httptest.NewServer(newJWTHandler(testJWTSecret, srv))
+	case "ws":
+		hs = httptest.NewServer(newJWTHandler(testJWTSecret, srv.WebsocketHandler([]string{"*"})))
+	}
+	var (
+		client *Client
+		err    error
+	)
+	switch transport {
+	case "http":
+		client, err = DialHTTPWithAuth(hs.URL, new(http.Client), auth)
+	case "ws":
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		client, err = DialWebsocketWithAuth(ctx, strings.Replace(hs.URL, "http:", "ws:", 1), "", auth)
+	}
+	if err != nil {
+		hs.Close()
+		return nil, nil, err
+	}
+	return client, hs, nil
+}
+
+func TestJWTAuthHTTP(t *testing.T)      { testJWTAuth(t, "http") }
+func TestJWTAuthWebsocket(t *testing.T) { testJWTAuth(t, "ws") }
+
+func testJWTAuth(t *testing.T, transport string) {
+	var result Result
+
+	// Requests signed with the right secret are accepted
+	client, hs, err := authTestClient(t, transport, NewJWTTokenProvider(testJWTSecret, nil))
+	if err != nil {
+		t.Fatalf("failed to dial with valid token: %v", err)
+	}
+	if err := client.Call(&result, "service_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Errorf("call with valid token failed: %v", err)
+	}
+	client.Close()
+	hs.Close()
+
+	// Requests signed with another secret, stale and missing tokens are rejected
+	invalid := map[string]TokenProvider{
+		"wrong secret": NewJWTTokenProvider([]byte("fedcba9876543210fedcba9876543210"), nil),
+		"stale": func() (string, error) {
+			claims := &JWTClaims{StandardClaims: jwt.StandardClaims{IssuedAt: time.Now().Add(-2 * jwtIssuedAtWindow).Unix()}}
+			return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testJWTSecret)
+		},
+		"missing": nil,
+	}
+	for name, auth := range invalid {
+		client, hs, err := authTestClient(t, transport, auth)
+		if err == nil {
+			err = client.Call(&result, "service_echo", "hello", 10, &Args{"world"})
+			client.Close()
+			hs.Close()
+		}
+		if err == nil {
+			t.Errorf("%s token: call succeeded", name)
+		}
+	}
+}
+
+func TestJWTAuthNamespaces(t *testing.T) {
+	for _, transport := range []string{"http", "ws"} {
+		client, hs, err := authTestClient(t, transport, NewJWTTokenProvider(testJWTSecret, []string{"other"}))
+		if err != nil {
+			t.Fatalf("%s: failed to dial: %v", transport, err)
+		}
+		var result Result
+		err = client.Call(&result, "service_echo", "hello", 10, &Args{"world"})
+		if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != (&unauthorizedError{}).ErrorCode() {
+			t.Errorf("%s: restricted namespace error mismatch: %v", transport, err)
+		}
+		var modules map[string]string
+		if err := client.Call(&modules, "rpc_modules"); err != nil {
+			t.Errorf("%s: metadata call failed: %v", transport, err)
+		}
+		client.Close()
+		hs.Close()
+	}
+}
+
+func TestJWTAuthHTTPService(t *testing.T) {
+	service := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	hs := httptest.NewServer(newJWTHandler(testJWTSecret, httpServiceMux(NewServer(), map[string]http.Handler{"query": service})))
+	defer hs.Close()
+
+	tests := []struct {
+		namespaces []string
+		want       int
+	}{
+		{nil, http.StatusOK},
+		{[]string{"query"}, http.StatusOK},
+		{[]string{"other"}, http.StatusForbidden},
+	}
+	for i, tt := range tests {
+		token, _ := NewJWTTokenProvider(testJWTSecret, tt.namespaces)()
+		req, _ := http.NewRequest(http.MethodGet, hs.URL+"/query", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("test %d: request failed: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, resp.StatusCode, tt.want)
+		}
+	}
+	resp, err := http.Get(hs.URL + "/query")
+	if err != nil {
+		t.Fatalf("unauthenticated request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("unauthenticated status mismatch: have %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
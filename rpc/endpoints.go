@@ -18,12 +18,15 @@ package rpc
 
 import (
 	"net"
+	"net/http"
 
 	"github.com/irchain/go-irchain/log"
 )
 
-// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string) (net.Listener, *Server, error) {
+// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules,
+// optional token authentication, an optional access policy and resource limits.
+// Additional HTTP services are served on /<namespace> behind the same checks.
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, jwtSecret []byte, policy *AccessPolicy, limits ServerLimits, services map[string]http.Handler) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -31,6 +34,10 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	}
 	// Register all the APIs exposed by the services
 	handler := NewServer()
+	if err := handler.SetAccessPolicy(policy); err != nil {
+		return nil, nil, err
+	}
+	handler.SetLimits(limits)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -47,12 +54,30 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewHTTPServer(cors, vhosts, handler).Serve(listener)
+	go NewHTTPServer(cors, vhosts, jwtSecret, httpServiceMux(handler, services)).Serve(listener)
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts a websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// httpServiceMux serves the given HTTP services on /<namespace> next to the API
+// of the server, subject to its restrictions.
+func httpServiceMux(srv *Server, services map[string]http.Handler) http.Handler {
+	if len(services) == 0 {
+		return srv
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", srv)
+	for namespace, service := range services {
+		handler := srv.HTTPHandler(namespace, service)
+		mux.Handle("/"+namespace, handler)
+		mux.Handle("/"+namespace+"/", handler)
+		log.Debug("HTTP service mounted", "path", "/"+namespace)
+	}
+	return mux
+}
+
+// StartWSEndpoint starts a websocket endpoint, with optional token authentication,
+// an optional access policy and resource limits.
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, jwtSecret []byte, policy *AccessPolicy, limits ServerLimits) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -61,6 +86,10 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	}
 	// Register all the APIs exposed by the services
 	handler := NewServer()
+	if err := handler.SetAccessPolicy(policy); err != nil {
+		return nil, nil, err
+	}
+	handler.SetLimits(limits)
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -77,15 +106,16 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return nil, nil, err
 	}
-	go NewWSServer(wsOrigins, handler).Serve(listener)
+	go NewWSServer(wsOrigins, jwtSecret, handler).Serve(listener)
 	return listener, handler, err
 
 }
 
-// StartIPCEndpoint starts an IPC endpoint.
-func StartIPCEndpoint(ipcEndpoint string, apis []API) (net.Listener, *Server, error) {
+// StartIPCEndpoint starts an IPC endpoint with the given resource limits.
+func StartIPCEndpoint(ipcEndpoint string, apis []API, limits ServerLimits) (net.Listener, *Server, error) {
 	// Register all the APIs exposed by the services.
 	handler := NewServer()
+	handler.SetLimits(limits)
 	for _, api := range apis {
 		if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
 			return nil, nil, err
@@ -66,3 +66,51 @@ type shutdownError struct{}
 func (e *shutdownError) ErrorCode() int { return -32000 }
 
 func (e *shutdownError) Error() string { return "server is shutting down" }
+
+// issued when the authentication token of a request doesn't permit the namespace
+type unauthorizedError struct{ service string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32001 }
+
+func (e *unauthorizedError) Error() string {
+	return fmt.Sprintf("access to the %s namespace is not permitted", e.service)
+}
+
+// issued when the access policy of the server refuses to execute a method
+type methodDeniedError struct{ method string }
+
+func (e *methodDeniedError) ErrorCode() int { return -32003 }
+
+func (e *methodDeniedError) Error() string {
+	return fmt.Sprintf("the method %s is not permitted", e.method)
+}
+
+// issued when a request exceeds the rate or concurrency limits of its method
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
+
+// issued when a batch contains more requests than the configured maximum
+type batchTooLargeError struct{ size, limit int }
+
+func (e *batchTooLargeError) ErrorCode() int { return -32006 }
+
+func (e *batchTooLargeError) Error() string {
+	return fmt.Sprintf("batch too large (%d>%d)", e.size, e.limit)
+}
+
+// issued when the reply to a request exceeds the configured response size
+type responseTooLargeError struct{}
+
+func (e *responseTooLargeError) ErrorCode() int { return -32004 }
+
+func (e *responseTooLargeError) Error() string { return "response too large" }
+
+// issued when a call doesn't complete within the configured execution time
+type requestTimeoutError struct{}
+
+func (e *requestTimeoutError) ErrorCode() int { return -32002 }
+
+func (e *requestTimeoutError) Error() string { return "request timed out" }
@@ -44,6 +44,7 @@ var nullAddr, _ = net.ResolveTCPAddr("tcp", "127.0.0.1:0")
 type httpConn struct {
 	client    *http.Client
 	req       *http.Request
+	auth      TokenProvider
 	closeOnce sync.Once
 	closed    chan struct{}
 }
@@ -69,6 +70,13 @@ func (hc *httpConn) Close() error {
 // DialHTTPWithClient creates a new RPC client that connects to an RPC server over HTTP
 // using the provided HTTP Client.
 func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
+	return DialHTTPWithAuth(endpoint, client, nil)
+}
+
+// DialHTTPWithAuth creates a new RPC client that connects to an RPC server over HTTP
+// using the provided HTTP Client, authenticating every request with a bearer token
+// obtained from auth.
+func DialHTTPWithAuth(endpoint string, client *http.Client, auth TokenProvider) (*Client, error) {
 	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -78,7 +86,7 @@ func DialHTTPWithClient(endpoint string, client *http.Client) (*Client, error) {
 
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (net.Conn, error) {
-		return &httpConn{client: client, req: req, closed: make(chan struct{})}, nil
+		return &httpConn{client: client, req: req, auth: auth, closed: make(chan struct{})}, nil
 	})
 }
 
@@ -137,6 +145,18 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
 
+	if hc.auth != nil {
+		token, err := hc.auth()
+		if err != nil {
+			return nil, err
+		}
+		// The request template is shared between calls, copy the headers
+		req.Header = make(http.Header, len(hc.req.Header)+1)
+		for key, values := range hc.req.Header {
+			req.Header[key] = values
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 	resp, err := hc.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -158,12 +178,15 @@ func (t *httpReadWriteNopCloser) Close() error {
 	return nil
 }
 
-// NewHTTPServer creates a new HTTP RPC server around an API provider.
+// NewHTTPServer creates a new HTTP RPC server around an API provider. If jwtSecret
+// is set, requests need to be authenticated with an HS256 bearer token signed
+// with it.
 //
 // Deprecated: Server implements http.Handler
-func NewHTTPServer(cors []string, vhosts []string, srv *Server) *http.Server {
-	// Wrap the CORS-handler within a host-handler
-	handler := newCorsHandler(srv, cors)
+func NewHTTPServer(cors []string, vhosts []string, jwtSecret []byte, srv http.Handler) *http.Server {
+	// Wrap the authentication-handler within a CORS-handler within a host-handler
+	handler := newJWTHandler(jwtSecret, srv)
+	handler = newCorsHandler(handler, cors)
 	handler = newVHostHandler(vhosts, handler)
 	return &http.Server{
 		Handler:      handler,
@@ -199,6 +222,90 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	srv.ServeSingleRequest(ctx, codec, OptionMethodInvocation)
 }
 
+// HTTPHandler wraps an HTTP service mounted next to the JSON-RPC API of the server
+// into the same restrictions as the API itself. Requests count as calls to the
+// method <namespace>_query: they are subject to the namespace restrictions of
+// the authentication token and the access policy of the server, they are aborted
+// after the request timeout, and their responses are limited in size.
+func (srv *Server) HTTPHandler(namespace string, next http.Handler) http.Handler {
+	return &serviceHandler{namespace: namespace, server: srv, next: next}
+}
+
+// serviceHandler is an http.Handler enforcing the restrictions of a server on an
+// HTTP service.
+type serviceHandler struct {
+	namespace string
+	server    *Server
+	next      http.Handler
+}
+
+// ServeHTTP implements http.Handler
+func (h *serviceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := context.WithValue(r.Context(), "remote", r.RemoteAddr)
+	method := h.namespace + serviceMethodSeparator + "query"
+	start := time.Now()
+
+	if allowed, ok := ctx.Value(authNamespacesKey{}).(map[string]bool); ok && !allowed[h.namespace] {
+		http.Error(w, (&unauthorizedError{h.namespace}).Error(), http.StatusForbidden)
+		return
+	}
+	if h.server.policy != nil {
+		release, err := h.server.policy.admit(ctx, method)
+		if err != nil {
+			methodMetrics(method, start, err)
+			code := http.StatusForbidden
+			if _, ok := err.(*limitExceededError); ok {
+				code = http.StatusTooManyRequests
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		defer release()
+	}
+	if h.server.limits.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.server.limits.RequestTimeout)
+		defer cancel()
+	}
+	if h.server.limits.ResponseSize == 0 {
+		h.next.ServeHTTP(w, r.WithContext(ctx))
+		methodMetrics(method, start, nil)
+		return
+	}
+	// Buffer the response to replace it with an error if it grows too large
+	lw := &limitedResponseWriter{ResponseWriter: w, code: http.StatusOK, body: limitWriter{limit: h.server.limits.ResponseSize}}
+	h.next.ServeHTTP(lw, r.WithContext(ctx))
+	if lw.err != nil {
+		methodMetrics(method, start, lw.err)
+		http.Error(w, lw.err.Error(), http.StatusInternalServerError)
+		return
+	}
+	methodMetrics(method, start, nil)
+	w.WriteHeader(lw.code)
+	w.Write(lw.body.buf)
+}
+
+// limitedResponseWriter buffers a response, failing once it exceeds the limit.
+type limitedResponseWriter struct {
+	http.ResponseWriter
+	code int
+	body limitWriter
+	err  error
+}
+
+func (w *limitedResponseWriter) WriteHeader(code int) {
+	w.code = code
+}
+
+func (w *limitedResponseWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n, err := w.body.Write(p)
+	w.err = err
+	return n, err
+}
+
 // validateRequest returns a non-zero response code and error message if the
 // request is invalid.
 func validateRequest(r *http.Request) (int, error) {
@@ -217,7 +324,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
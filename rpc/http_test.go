@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -52,3 +53,42 @@ func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expec
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+func TestHTTPServiceRestrictions(t *testing.T) {
+	service := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Query().Get("reply")))
+	})
+	srv := NewServer()
+	if err := srv.SetAccessPolicy(&AccessPolicy{Limits: map[string]MethodLimit{"query_*": {Rate: 0.001, Burst: 2}}}); err != nil {
+		t.Fatalf("failed to set access policy: %v", err)
+	}
+	srv.SetLimits(ServerLimits{ResponseSize: 8})
+	hs := httptest.NewServer(httpServiceMux(srv, map[string]http.Handler{"query": service}))
+	defer hs.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(hs.URL + path)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if code, body := get("/query?reply=short"); code != http.StatusOK || body != "short" {
+		t.Fatalf("short reply mismatch: have %d %q", code, body)
+	}
+	if code, _ := get("/query?reply=" + strings.Repeat("x", 9)); code != http.StatusInternalServerError {
+		t.Fatalf("oversized reply status mismatch: have %d, want %d", code, http.StatusInternalServerError)
+	}
+	if code, _ := get("/query?reply=short"); code != http.StatusTooManyRequests {
+		t.Fatalf("throttled request status mismatch: have %d, want %d", code, http.StatusTooManyRequests)
+	}
+	// The access policy applies to the service namespace as well
+	if err := srv.SetAccessPolicy(&AccessPolicy{Deny: []string{"query_*"}}); err != nil {
+		t.Fatalf("failed to set access policy: %v", err)
+	}
+	if code, _ := get("/query/sub"); code != http.StatusForbidden {
+		t.Fatalf("denied request status mismatch: have %d, want %d", code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+var errResponseTooLarge = &responseTooLargeError{}
+
+// ServerLimits bound the resources a single request may consume. The zero value
+// imposes no limits.
+type ServerLimits struct {
+	BatchItems     int           `toml:",omitempty"` // Maximum number of calls in a batch (0 = unlimited)
+	ResponseSize   int           `toml:",omitempty"` // Maximum size in bytes of a (batch) response (0 = unlimited)
+	RequestTimeout time.Duration `toml:",omitempty"` // Maximum execution time of a call (0 = unlimited)
+}
+
+// SetLimits configures the resource limits of the server. It must be called
+// before the server starts serving requests.
+func (s *Server) SetLimits(limits ServerLimits) {
+	s.limits = limits
+}
+
+// checkBatch returns an error if the batch exceeds the configured size.
+func (s *Server) checkBatch(reqs []*serverRequest) Error {
+	if s.limits.BatchItems > 0 && len(reqs) > s.limits.BatchItems {
+		return &batchTooLargeError{len(reqs), s.limits.BatchItems}
+	}
+	return nil
+}
+
+// encodeResponse serializes a response, replacing it with an error if it would
+// push the total size of the reply past the configured limit. The size of the
+// returned encoding is added to size, and whether the limit was hit is reported.
+func (s *Server) encodeResponse(codec ServerCodec, id interface{}, response interface{}, size *int) (json.RawMessage, bool) {
+	w := &limitWriter{limit: s.limits.ResponseSize - *size}
+	err := encodeLimited(w, response)
+	switch {
+	case err == errResponseTooLarge:
+		enc, _ := json.Marshal(codec.CreateErrorResponse(id, errResponseTooLarge))
+		*size += len(enc)
+		return enc, true
+	case err != nil:
+		enc, _ := json.Marshal(codec.CreateErrorResponse(id, &callbackError{err.Error()}))
+		*size += len(enc)
+		return enc, false
+	}
+	*size += len(w.buf)
+	return w.buf, false
+}
+
+// limitWriter is an in-memory buffer that refuses to grow past its limit.
+type limitWriter struct {
+	buf   []byte
+	limit int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(w.buf)+len(p) > w.limit {
+		return 0, errResponseTooLarge
+	}
+	w.buf = append(w.buf, p...)
+	return len(p), nil
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// encodeLimited writes the JSON encoding of v into w. The result of a success
+// response and any list within it is encoded element by element, so encoding
+// aborts as soon as the limit is reached instead of after serializing the whole
+// value.
+func encodeLimited(w *limitWriter, v interface{}) error {
+	if resp, ok := v.(*jsonSuccessResponse); ok {
+		// Encode the envelope around a null result and splice the real one in
+		envelope := *resp
+		envelope.Result = nil
+		enc, err := json.Marshal(&envelope)
+		if err != nil {
+			return err
+		}
+		head := bytes.TrimSuffix(enc, []byte("null}"))
+		if _, err := w.Write(head); err != nil {
+			return err
+		}
+		if err := encodeLimited(w, resp.Result); err != nil {
+			return err
+		}
+		_, err = w.Write([]byte("}"))
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() && !rv.Type().Implements(jsonMarshalerType) {
+		rv = rv.Elem()
+	}
+	switch {
+	case rv.Kind() == reflect.Slice && rv.IsNil():
+	case rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array:
+	case rv.Type().Elem().Kind() == reflect.Uint8:
+	case rv.Type().Implements(jsonMarshalerType):
+	default:
+		if _, err := w.Write([]byte("[")); err != nil {
+			return err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				if _, err := w.Write([]byte(",")); err != nil {
+					return err
+				}
+			}
+			// Encode addressable elements through a pointer, so methods with
+			// pointer receivers are used just like json.Marshal would
+			elem := rv.Index(i)
+			if elem.CanAddr() {
+				elem = elem.Addr()
+			}
+			if err := encodeLimited(w, elem.Interface()); err != nil {
+				return err
+			}
+		}
+		_, err := w.Write([]byte("]"))
+		return err
+	}
+	enc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(enc)
+	return err
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func limitsTestClient(limits ServerLimits) *Client {
+	server := newTestServer("service", new(Service))
+	server.SetLimits(limits)
+	return DialInProc(server)
+}
+
+func TestServerBatchLimit(t *testing.T) {
+	client := limitsTestClient(ServerLimits{BatchItems: 2})
+	defer client.Close()
+
+	batch := []BatchElem{
+		{Method: "service_echo", Args: []interface{}{"a", 1, &Args{"x"}}, Result: new(Result)},
+		{Method: "service_echo", Args: []interface{}{"b", 2, &Args{"y"}}, Result: new(Result)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch within limit failed: %v", err)
+	}
+	for i, elem := range batch {
+		if elem.Error != nil {
+			t.Errorf("batch element %d failed: %v", i, elem.Error)
+		}
+	}
+	batch = append(batch, BatchElem{Method: "service_echo", Args: []interface{}{"c", 3, &Args{"z"}}, Result: new(Result)})
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("oversized batch failed: %v", err)
+	}
+	for i, elem := range batch {
+		if rpcErr, ok := elem.Error.(Error); !ok || rpcErr.ErrorCode() != (&batchTooLargeError{}).ErrorCode() {
+			t.Errorf("oversized batch element %d error mismatch: %v", i, elem.Error)
+		}
+	}
+}
+
+func TestServerResponseLimit(t *testing.T) {
+	client := limitsTestClient(ServerLimits{ResponseSize: 200})
+	defer client.Close()
+
+	var result Result
+	if err := client.Call(&result, "service_echo", "short", 1, &Args{"x"}); err != nil {
+		t.Fatalf("small response failed: %v", err)
+	}
+	err := client.Call(&result, "service_echo", strings.Repeat("x", 200), 1, &Args{"x"})
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != errResponseTooLarge.ErrorCode() {
+		t.Fatalf("large response error mismatch: %v", err)
+	}
+	// Once a batch exceeds the limit, the remaining calls are not executed
+	batch := []BatchElem{
+		{Method: "service_echo", Args: []interface{}{"short", 1, &Args{"x"}}, Result: new(Result)},
+		{Method: "service_echo", Args: []interface{}{strings.Repeat("x", 100), 2, &Args{"y"}}, Result: new(Result)},
+		{Method: "service_echo", Args: []interface{}{"short", 3, &Args{"z"}}, Result: new(Result)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if batch[0].Error != nil {
+		t.Errorf("first batch element failed: %v", batch[0].Error)
+	}
+	for i := 1; i < len(batch); i++ {
+		if batch[i].Error == nil {
+			t.Errorf("batch element %d served past the limit", i)
+		}
+	}
+}
+
+func TestEncodeLimited(t *testing.T) {
+	values := []interface{}{
+		nil,
+		"string",
+		[]int(nil),
+		[]int{1, 2, 3},
+		[]byte{1, 2, 3},
+		[2][]string{{"a"}, {"b", "c"}},
+		[]*big.Int{big.NewInt(1), nil},
+		[]Result{{"x", 1, &Args{"y"}}},
+		&jsonSuccessResponse{Version: jsonrpcVersion, Id: 1, Result: [][]int{{1}, {2, 3}}},
+	}
+	for i, v := range values {
+		want, _ := json.Marshal(v)
+		w := &limitWriter{limit: 1024}
+		if err := encodeLimited(w, v); err != nil {
+			t.Errorf("value %d: encoding failed: %v", i, err)
+		} else if string(w.buf) != string(want) {
+			t.Errorf("value %d: encoding mismatch: have %s, want %s", i, w.buf, want)
+		}
+	}
+	// Encoding stops at the first element crossing the limit
+	w := &limitWriter{limit: 10}
+	if err := encodeLimited(w, []string{"aaaa", "bbbb", "cccc"}); err != errResponseTooLarge {
+		t.Fatalf("oversized encoding error mismatch: have %v, want %v", err, errResponseTooLarge)
+	}
+	if string(w.buf) != `["aaaa",` {
+		t.Fatalf("oversized encoding not aborted: %s", w.buf)
+	}
+}
+
+func TestServerRequestTimeout(t *testing.T) {
+	client := limitsTestClient(ServerLimits{RequestTimeout: 50 * time.Millisecond})
+	defer client.Close()
+
+	if err := client.Call(nil, "service_sleep", 10*time.Millisecond); err != nil {
+		t.Fatalf("quick call failed: %v", err)
+	}
+	start := time.Now()
+	err := client.Call(nil, "service_sleep", time.Second)
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != (&requestTimeoutError{}).ErrorCode() {
+		t.Fatalf("timeout error mismatch: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("call not aborted, took %v", elapsed)
+	}
+}
@@ -0,0 +1,335 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/common/hexutil"
+)
+
+const openRPCVersion = "1.2.6"
+
+// JSON schema patterns of the hex encodings defined in package hexutil.
+const (
+	hexQuantityPattern = "^0x(0|[1-9a-f][0-9a-f]*)$"
+	hexBytesPattern    = "^0x([0-9a-fA-F]{2})*$"
+)
+
+var (
+	bigIntType      = reflect.TypeOf(big.Int{})
+	timeType        = reflect.TypeOf(time.Time{})
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+	blockNumberType = reflect.TypeOf(BlockNumber(0))
+	textMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+	// hexQuantityTypes are encoded as 0x-prefixed quantities without leading zeroes.
+	hexQuantityTypes = map[reflect.Type]bool{
+		reflect.TypeOf(hexutil.Big{}):     true,
+		reflect.TypeOf(hexutil.Uint64(0)): true,
+		reflect.TypeOf(hexutil.Uint(0)):   true,
+	}
+
+	schemaOverrideLock sync.RWMutex
+	schemaOverrides    = make(map[reflect.Type]reflect.Type)
+)
+
+// RegisterSchemaOverride sets the type whose JSON schema describes the values of
+// the type of value in service descriptions. It is meant for types with custom
+// JSON encodings, which can't be derived by reflection: encoded should be a struct
+// mirroring that encoding. Types with custom encodings and no override are
+// described by an empty schema, accepting any value.
+func RegisterSchemaOverride(value, encoded interface{}) {
+	typ, enc := reflect.TypeOf(value), reflect.TypeOf(encoded)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for enc.Kind() == reflect.Ptr {
+		enc = enc.Elem()
+	}
+	if enc.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("schema override of %v is not a struct: %v", typ, enc))
+	}
+	schemaOverrideLock.Lock()
+	defer schemaOverrideLock.Unlock()
+
+	schemaOverrides[typ] = enc
+}
+
+// schemaOverride returns the type describing the encoding of typ, if any.
+func schemaOverride(typ reflect.Type) reflect.Type {
+	schemaOverrideLock.RLock()
+	defer schemaOverrideLock.RUnlock()
+
+	return schemaOverrides[typ]
+}
+
+// OpenRPCDocument is an OpenRPC service description, see https://spec.open-rpc.org.
+type OpenRPCDocument struct {
+	OpenRPC    string            `json:"openrpc"`
+	Info       OpenRPCInfo       `json:"info"`
+	Methods    []*OpenRPCMethod  `json:"methods"`
+	Components OpenRPCComponents `json:"components"`
+}
+
+// OpenRPCInfo holds the metadata of the described API.
+type OpenRPCInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenRPCMethod describes a single callable method. Subscriptions are flagged
+// with the x-subscription extension: they are created by calling the
+// <namespace>_subscribe method with the subscription name as first parameter,
+// followed by the listed parameters, and return the subscription identifier.
+type OpenRPCMethod struct {
+	Name         string                      `json:"name"`
+	Params       []*OpenRPCContentDescriptor `json:"params"`
+	Result       *OpenRPCContentDescriptor   `json:"result"`
+	Subscription bool                        `json:"x-subscription,omitempty"`
+}
+
+// OpenRPCContentDescriptor describes a method parameter or result.
+type OpenRPCContentDescriptor struct {
+	Name     string      `json:"name"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *JSONSchema `json:"schema"`
+}
+
+// OpenRPCComponents holds the named schemas referenced by the methods.
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas"`
+}
+
+// JSONSchema is the subset of JSON schema used to describe Go types.
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+// Discover returns an OpenRPC document describing all methods and subscriptions
+// the server exposes.
+func (s *RPCService) Discover() *OpenRPCDocument {
+	return s.server.openRPCDocument()
+}
+
+// openRPCDocument builds the OpenRPC description of the registered services by
+// reflecting over their callbacks.
+func (s *Server) openRPCDocument() *OpenRPCDocument {
+	gen := &schemaGenerator{schemas: make(map[string]*JSONSchema)}
+	doc := &OpenRPCDocument{
+		OpenRPC: openRPCVersion,
+		Info:    OpenRPCInfo{Title: "IrChain JSON-RPC", Version: "1.0"},
+	}
+	for name, svc := range s.services {
+		for cbname, cb := range svc.callbacks {
+			doc.Methods = append(doc.Methods, gen.method(name+serviceMethodSeparator+cbname, cb))
+		}
+		for subname, cb := range svc.subscriptions {
+			doc.Methods = append(doc.Methods, gen.method(name+serviceMethodSeparator+subname, cb))
+		}
+	}
+	sort.Slice(doc.Methods, func(i, j int) bool { return doc.Methods[i].Name < doc.Methods[j].Name })
+	doc.Components.Schemas = gen.schemas
+	return doc
+}
+
+// schemaGenerator derives JSON schemas from Go types, collecting the named struct
+// types as reusable components.
+type schemaGenerator struct {
+	schemas map[string]*JSONSchema
+}
+
+// method describes a single callback.
+func (g *schemaGenerator) method(name string, cb *callback) *OpenRPCMethod {
+	m := &OpenRPCMethod{
+		Name:         name,
+		Params:       make([]*OpenRPCContentDescriptor, len(cb.argTypes)),
+		Subscription: cb.isSubscribe,
+	}
+	// Trailing pointer arguments may be omitted by the caller
+	optional := len(cb.argTypes)
+	for optional > 0 && cb.argTypes[optional-1].Kind() == reflect.Ptr {
+		optional--
+	}
+	for i, typ := range cb.argTypes {
+		m.Params[i] = &OpenRPCContentDescriptor{
+			Name:     fmt.Sprintf("arg%d", i),
+			Required: i < optional,
+			Schema:   g.schema(typ),
+		}
+	}
+	if cb.isSubscribe {
+		m.Result = &OpenRPCContentDescriptor{Name: "subscription", Schema: &JSONSchema{Title: "rpc.ID", Type: "string"}}
+		return m
+	}
+	m.Result = &OpenRPCContentDescriptor{Name: "result", Schema: &JSONSchema{Type: "null"}}
+	for i, mtype := 0, cb.method.Type; i < mtype.NumOut(); i++ {
+		if i != cb.errPos {
+			m.Result.Schema = g.schema(mtype.Out(i))
+			break
+		}
+	}
+	return m
+}
+
+// schema returns the JSON schema of the values of the given type, as encoded by
+// package encoding/json.
+func (g *schemaGenerator) schema(typ reflect.Type) *JSONSchema {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch {
+	case hexQuantityTypes[typ]:
+		return &JSONSchema{Title: typ.String(), Type: "string", Pattern: hexQuantityPattern}
+	case typ == blockNumberType:
+		return &JSONSchema{Title: typ.String(), OneOf: []*JSONSchema{
+			{Type: "string", Pattern: hexQuantityPattern},
+			{Type: "string", Enum: []string{"earliest", "latest", "pending"}},
+		}}
+	case typ == bigIntType:
+		return &JSONSchema{Title: typ.String(), Type: "integer"}
+	case typ == timeType:
+		return &JSONSchema{Title: typ.String(), Type: "string", Format: "date-time"}
+	case typ == rawMessageType:
+		return &JSONSchema{}
+	case reflect.PtrTo(typ).Implements(textMarshaler):
+		schema := &JSONSchema{Title: typ.String(), Type: "string"}
+		// Byte arrays and slices with a text encoding are hex encoded
+		switch {
+		case typ.Kind() == reflect.Array && typ.Elem().Kind() == reflect.Uint8:
+			schema.Pattern = fmt.Sprintf("^0x[0-9a-fA-F]{%d}$", 2*typ.Len())
+		case typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+			schema.Pattern = hexBytesPattern
+		}
+		return schema
+	case schemaOverride(typ) != nil:
+		return g.component(typ.String(), schemaOverride(typ))
+	case typ.Implements(jsonMarshalerType) || reflect.PtrTo(typ).Implements(jsonMarshalerType) || reflect.PtrTo(typ).Implements(jsonUnmarshaler):
+		// Custom JSON encodings can't be derived by reflection, only assume byte
+		// arrays and slices to be hex encoded
+		if typ.Kind() == reflect.Array && typ.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Title: typ.String(), Type: "string", Pattern: fmt.Sprintf("^0x[0-9a-fA-F]{%d}$", 2*typ.Len())}
+		}
+		if typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Title: typ.String(), Type: "string", Pattern: hexBytesPattern}
+		}
+		return &JSONSchema{Title: typ.String()}
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 && typ.Kind() == reflect.Slice {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: g.schema(typ.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.schema(typ.Elem())}
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return g.structSchema(typ)
+		}
+		return g.component(typ.String(), typ)
+	}
+	// Interfaces and anything else can't be described statically
+	return &JSONSchema{}
+}
+
+// component returns a reference to the named schema of the given struct type,
+// generating it on first use.
+func (g *schemaGenerator) component(name string, typ reflect.Type) *JSONSchema {
+	if _, ok := g.schemas[name]; !ok {
+		g.schemas[name] = nil // break recursion on self referencing types
+		g.schemas[name] = g.structSchema(typ)
+		g.schemas[name].Title = name
+	}
+	return &JSONSchema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema describes the JSON object encoding of a struct type.
+func (g *schemaGenerator) structSchema(typ reflect.Type) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	g.addFields(schema, typ)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// addFields adds the encoded fields of a struct type to the object schema,
+// flattening embedded structs like encoding/json does.
+func (g *schemaGenerator) addFields(schema *JSONSchema, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := tag, ""
+		if idx := strings.Index(tag, ","); idx >= 0 {
+			name, opts = tag[:idx], tag[idx+1:]
+		}
+		ftype := field.Type
+		if field.Anonymous && name == "" {
+			if ftype.Kind() == reflect.Ptr {
+				ftype = ftype.Elem()
+			}
+			if ftype.Kind() == reflect.Struct {
+				g.addFields(schema, ftype)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fschema := g.schema(ftype)
+		if strings.Contains(opts, "string") {
+			fschema = &JSONSchema{Type: "string"}
+		}
+		schema.Properties[name] = fschema
+		if !strings.Contains(opts, "omitempty") && ftype.Kind() != reflect.Ptr {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/irchain/go-irchain/common/hexutil"
+)
+
+type HexArgs struct {
+	Data   hexutil.Bytes   `json:"data"`
+	Value  *hexutil.Big    `json:"value"`
+	Gas    *hexutil.Uint64 `json:"gas,omitempty"`
+	Nested *HexArgs        `json:"nested,omitempty"`
+}
+
+type HexService struct{}
+
+func (s *HexService) Call(args HexArgs, block BlockNumber) (hexutil.Bytes, error) {
+	return args.Data, nil
+}
+
+func TestDiscover(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	if err := server.RegisterName("hex", new(HexService)); err != nil {
+		t.Fatal(err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	var doc OpenRPCDocument
+	if err := client.Call(&doc, "rpc_discover"); err != nil {
+		t.Fatalf("discover failed: %v", err)
+	}
+	if doc.OpenRPC != openRPCVersion {
+		t.Errorf("version mismatch: have %s, want %s", doc.OpenRPC, openRPCVersion)
+	}
+	methods := make(map[string]*OpenRPCMethod)
+	for _, m := range doc.Methods {
+		methods[m.Name] = m
+	}
+	for _, name := range []string{"rpc_modules", "rpc_discover", "service_echo", "service_subscription", "hex_call"} {
+		if methods[name] == nil {
+			t.Errorf("method %s not described", name)
+		}
+	}
+	// Check the parameters and results derived from Go types
+	echo := methods["service_echo"]
+	if len(echo.Params) != 3 {
+		t.Fatalf("service_echo: have %d params, want 3", len(echo.Params))
+	}
+	if echo.Params[0].Schema.Type != "string" || echo.Params[1].Schema.Type != "integer" {
+		t.Errorf("service_echo: param schema mismatch: %+v %+v", echo.Params[0].Schema, echo.Params[1].Schema)
+	}
+	if !echo.Params[1].Required || echo.Params[2].Required {
+		t.Errorf("service_echo: optional trailing pointer argument mismatch")
+	}
+	if echo.Result.Schema.Ref != "#/components/schemas/rpc.Result" {
+		t.Errorf("service_echo: result schema mismatch: %+v", echo.Result.Schema)
+	}
+	if sub := methods["service_subscription"]; !sub.Subscription || methods["service_echo"].Subscription {
+		t.Errorf("subscription flag mismatch")
+	}
+	if noret := methods["service_noArgsRets"]; noret.Result.Schema.Type != "null" {
+		t.Errorf("service_noArgsRets: result schema mismatch: %+v", noret.Result.Schema)
+	}
+	// Check the hex encodings
+	call := methods["hex_call"]
+	if call.Result.Schema.Pattern != hexBytesPattern {
+		t.Errorf("hex_call: result pattern mismatch: %+v", call.Result.Schema)
+	}
+	if len(call.Params[1].Schema.OneOf) != 2 {
+		t.Errorf("hex_call: block number schema mismatch: %+v", call.Params[1].Schema)
+	}
+	args := doc.Components.Schemas["rpc.HexArgs"]
+	if args == nil {
+		t.Fatalf("HexArgs schema missing")
+	}
+	if args.Properties["value"].Pattern != hexQuantityPattern || args.Properties["gas"].Pattern != hexQuantityPattern {
+		t.Errorf("hex quantity schema mismatch: %+v %+v", args.Properties["value"], args.Properties["gas"])
+	}
+	if args.Properties["nested"].Ref != "#/components/schemas/rpc.HexArgs" {
+		t.Errorf("recursive schema mismatch: %+v", args.Properties["nested"])
+	}
+	if want := []string{"data"}; !reflect.DeepEqual(args.Required, want) {
+		t.Errorf("required fields mismatch: have %v, want %v", args.Required, want)
+	}
+}
+
+// customJSON has a custom JSON encoding unrelated to its fields.
+type customJSON struct{ Field int }
+
+func (c customJSON) MarshalJSON() ([]byte, error) { return []byte(`"custom"`), nil }
+
+// customBytes is a byte array with a custom JSON encoding.
+type customBytes [4]byte
+
+func (c *customBytes) UnmarshalJSON(input []byte) error { return nil }
+
+// overriddenJSON has a custom JSON encoding described by overriddenJSONEncoding.
+type overriddenJSON struct{ field int }
+
+func (o overriddenJSON) MarshalJSON() ([]byte, error) { return []byte(`{"value":"0x0"}`), nil }
+
+type overriddenJSONEncoding struct {
+	Value hexutil.Uint64 `json:"value"`
+}
+
+func TestSchemaCustomEncodings(t *testing.T) {
+	RegisterSchemaOverride(overriddenJSON{}, overriddenJSONEncoding{})
+	gen := &schemaGenerator{schemas: make(map[string]*JSONSchema)}
+
+	if schema := gen.schema(reflect.TypeOf(customJSON{})); schema.Type != "" || schema.Ref != "" || len(schema.Properties) != 0 {
+		t.Errorf("custom encoding schema mismatch: %+v", schema)
+	}
+	if schema := gen.schema(reflect.TypeOf(&customBytes{})); schema.Type != "string" || schema.Pattern != "^0x[0-9a-fA-F]{8}$" {
+		t.Errorf("custom byte array schema mismatch: %+v", schema)
+	}
+	if schema := gen.schema(reflect.TypeOf([]overriddenJSON{})); schema.Items.Ref != "#/components/schemas/rpc.overriddenJSON" {
+		t.Errorf("overridden schema reference mismatch: %+v", schema.Items)
+	}
+	override := gen.schemas["rpc.overriddenJSON"]
+	if override == nil || override.Properties["value"] == nil || override.Properties["value"].Pattern != hexQuantityPattern {
+		t.Errorf("overridden schema mismatch: %+v", override)
+	}
+}
@@ -0,0 +1,246 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/irchain/go-irchain/metrics"
+)
+
+// maxTrackedClients is the number of clients a method limiter keeps rate limiting
+// state for before it starts dropping the state of idle ones.
+const maxTrackedClients = 4096
+
+// MethodLimit configures the throttling of the methods matching a policy entry.
+// Limits configured for a wildcard pattern are shared by all matching methods.
+type MethodLimit struct {
+	Rate          float64 // Sustained calls per second allowed per client (0 = unlimited)
+	Burst         int     // Calls a client may make at once, in excess of the rate (minimum 1)
+	MaxConcurrent int     // Calls allowed to execute concurrently across all clients (0 = unlimited)
+}
+
+// AccessPolicy restricts which methods a server executes and how often. Methods
+// are matched by their full name (irc_getLogs), by namespace (debug_*) or by
+// the catch-all pattern (*).
+//
+// Clients are told apart by the subject of their authentication token if any,
+// otherwise by their IP address.
+type AccessPolicy struct {
+	Allow  []string               `toml:",omitempty"` // Methods to execute, all if empty
+	Deny   []string               `toml:",omitempty"` // Methods to refuse, takes precedence over Allow
+	Limits map[string]MethodLimit `toml:",omitempty"` // Throttling of methods, the most specific pattern applies
+}
+
+// methodPatterns is a set of method name patterns.
+type methodPatterns map[string]bool
+
+// match returns the most specific pattern matching the method, if any.
+func (p methodPatterns) match(method string) (string, bool) {
+	if p[method] {
+		return method, true
+	}
+	if i := strings.Index(method, serviceMethodSeparator); i >= 0 {
+		if pattern := method[:i] + serviceMethodSeparator + "*"; p[pattern] {
+			return pattern, true
+		}
+	}
+	if p["*"] {
+		return "*", true
+	}
+	return "", false
+}
+
+// accessPolicy is the compiled form of an AccessPolicy.
+type accessPolicy struct {
+	allow    methodPatterns
+	deny     methodPatterns
+	patterns methodPatterns // patterns with limits configured
+	limiters map[string]*methodLimiter
+}
+
+func newAccessPolicy(config *AccessPolicy) (*accessPolicy, error) {
+	p := &accessPolicy{
+		allow:    make(methodPatterns),
+		deny:     make(methodPatterns),
+		patterns: make(methodPatterns),
+		limiters: make(map[string]*methodLimiter),
+	}
+	for _, method := range config.Allow {
+		if method == "" {
+			return nil, fmt.Errorf("empty method in allow list")
+		}
+		p.allow[method] = true
+	}
+	for _, method := range config.Deny {
+		if method == "" {
+			return nil, fmt.Errorf("empty method in deny list")
+		}
+		p.deny[method] = true
+	}
+	for pattern, limit := range config.Limits {
+		if pattern == "" {
+			return nil, fmt.Errorf("empty method in limits")
+		}
+		if limit.Rate < 0 || limit.Burst < 0 || limit.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("negative limit for %s", pattern)
+		}
+		if limit.Burst == 0 {
+			limit.Burst = 1
+		}
+		p.patterns[pattern] = true
+		p.limiters[pattern] = &methodLimiter{limit: limit, clients: make(map[string]*tokenBucket)}
+	}
+	return p, nil
+}
+
+// admit checks whether the given client may call the method, returning a function
+// to invoke once the call finished if so.
+func (p *accessPolicy) admit(ctx context.Context, method string) (func(), Error) {
+	if _, denied := p.deny.match(method); denied {
+		return nil, &methodDeniedError{method}
+	}
+	if _, allowed := p.allow.match(method); len(p.allow) > 0 && !allowed {
+		return nil, &methodDeniedError{method}
+	}
+	pattern, limited := p.patterns.match(method)
+	if !limited {
+		return func() {}, nil
+	}
+	return p.limiters[pattern].acquire(clientID(ctx), time.Now())
+}
+
+// SetAccessPolicy restricts the methods the server executes and throttles them as
+// configured. It must be called before the server starts serving requests.
+func (s *Server) SetAccessPolicy(config *AccessPolicy) error {
+	if config == nil {
+		s.policy = nil
+		return nil
+	}
+	policy, err := newAccessPolicy(config)
+	if err != nil {
+		return err
+	}
+	s.policy = policy
+	return nil
+}
+
+// tokenBucket tracks the call rate of a single client.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// methodLimiter enforces the limits of a single policy entry.
+type methodLimiter struct {
+	limit MethodLimit
+
+	lock    sync.Mutex
+	active  int
+	clients map[string]*tokenBucket
+}
+
+// acquire reserves a call of the given client, failing if it exceeds the limits.
+func (l *methodLimiter) acquire(client string, now time.Time) (func(), Error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.limit.MaxConcurrent > 0 && l.active >= l.limit.MaxConcurrent {
+		return nil, &limitExceededError{"too many concurrent requests"}
+	}
+	if l.limit.Rate > 0 {
+		bucket := l.clients[client]
+		if bucket == nil {
+			if len(l.clients) >= maxTrackedClients {
+				l.prune(now)
+			}
+			bucket = &tokenBucket{tokens: float64(l.limit.Burst), updated: now}
+			l.clients[client] = bucket
+		}
+		bucket.tokens += now.Sub(bucket.updated).Seconds() * l.limit.Rate
+		if max := float64(l.limit.Burst); bucket.tokens > max {
+			bucket.tokens = max
+		}
+		bucket.updated = now
+		if bucket.tokens < 1 {
+			return nil, &limitExceededError{"request rate limit exceeded"}
+		}
+		bucket.tokens--
+	}
+	l.active++
+	return l.release, nil
+}
+
+func (l *methodLimiter) release() {
+	l.lock.Lock()
+	l.active--
+	l.lock.Unlock()
+}
+
+// prune drops the buckets of clients that would be refilled completely by now, as
+// they are equivalent to fresh ones. The caller must hold l.lock.
+func (l *methodLimiter) prune(now time.Time) {
+	for client, bucket := range l.clients {
+		if bucket.tokens+now.Sub(bucket.updated).Seconds()*l.limit.Rate >= float64(l.limit.Burst) {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// authClientKey is the context key under which the subject of the token of an
+// authenticated request is stored.
+type authClientKey struct{}
+
+// clientID identifies the client of a request for rate limiting purposes.
+func clientID(ctx context.Context) string {
+	if subject, ok := ctx.Value(authClientKey{}).(string); ok && subject != "" {
+		return "token:" + subject
+	}
+	if remote, ok := ctx.Value("remote").(string); ok && remote != "" {
+		if host, _, err := net.SplitHostPort(remote); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + remote
+	}
+	return "local"
+}
+
+// methodMetrics marks the outcome of an RPC call in the per-method metrics. The
+// error is the one the call was refused or failed with, nil on success.
+func methodMetrics(method string, start time.Time, err error) {
+	if !metrics.Enabled {
+		return
+	}
+	switch err.(type) {
+	case *limitExceededError:
+		metrics.GetOrRegisterMeter("rpc/throttled/"+method, nil).Mark(1)
+		return
+	case *methodDeniedError:
+		metrics.GetOrRegisterMeter("rpc/denied/"+method, nil).Mark(1)
+		return
+	}
+	metrics.GetOrRegisterMeter("rpc/requests/"+method, nil).Mark(1)
+	metrics.GetOrRegisterTimer("rpc/duration/"+method, nil).UpdateSince(start)
+	if err != nil {
+		metrics.GetOrRegisterMeter("rpc/failures/"+method, nil).Mark(1)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 The go-irchain Authors
+// This file is part of the go-irchain library.
+//
+// The go-irchain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-irchain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-irchain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/irchain/go-irchain/metrics"
+)
+
+func TestMethodPatternMatching(t *testing.T) {
+	patterns := methodPatterns{"debug_*": true, "irc_getLogs": true}
+	tests := []struct {
+		method  string
+		pattern string
+	}{
+		{"irc_getLogs", "irc_getLogs"},
+		{"irc_call", ""},
+		{"debug_traceTransaction", "debug_*"},
+		{"admin_peers", ""},
+	}
+	for _, tt := range tests {
+		if pattern, _ := patterns.match(tt.method); pattern != tt.pattern {
+			t.Errorf("%s: pattern mismatch: have %q, want %q", tt.method, pattern, tt.pattern)
+		}
+	}
+	patterns["*"] = true
+	if pattern, _ := patterns.match("admin_peers"); pattern != "*" {
+		t.Errorf("catch-all not matched, have %q", pattern)
+	}
+}
+
+func TestMethodLimiterRate(t *testing.T) {
+	l := &methodLimiter{limit: MethodLimit{Rate: 2, Burst: 2}, clients: make(map[string]*tokenBucket)}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		release, err := l.acquire("a", now)
+		if err != nil {
+			t.Fatalf("call %d within burst throttled: %v", i, err)
+		}
+		release()
+	}
+	if _, err := l.acquire("a", now); err == nil {
+		t.Fatal("call exceeding burst admitted")
+	}
+	if _, err := l.acquire("b", now); err != nil {
+		t.Fatalf("other client throttled: %v", err)
+	}
+	if _, err := l.acquire("a", now.Add(500*time.Millisecond)); err != nil {
+		t.Fatalf("call after refill throttled: %v", err)
+	}
+}
+
+func TestMethodLimiterConcurrency(t *testing.T) {
+	l := &methodLimiter{limit: MethodLimit{MaxConcurrent: 1, Burst: 1}, clients: make(map[string]*tokenBucket)}
+	now := time.Now()
+
+	release, err := l.acquire("a", now)
+	if err != nil {
+		t.Fatalf("first call throttled: %v", err)
+	}
+	if _, err := l.acquire("b", now); err == nil {
+		t.Fatal("concurrent call admitted")
+	}
+	release()
+	if _, err := l.acquire("b", now); err != nil {
+		t.Fatalf("call after release throttled: %v", err)
+	}
+}
+
+func TestServerAccessPolicy(t *testing.T) {
+	server := newTestServer("service", new(Service))
+	err := server.SetAccessPolicy(&AccessPolicy{
+		Deny:   []string{"service_noArgsRets"},
+		Limits: map[string]MethodLimit{"service_*": {Rate: 0.001, Burst: 1}},
+	})
+	if err != nil {
+		t.Fatalf("failed to set policy: %v", err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	err = client.Call(nil, "service_noArgsRets")
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != (&methodDeniedError{}).ErrorCode() {
+		t.Errorf("denied method error mismatch: %v", err)
+	}
+	var result Result
+	if err := client.Call(&result, "service_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	err = client.Call(&result, "service_echo", "hello", 10, &Args{"world"})
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != (&limitExceededError{}).ErrorCode() {
+		t.Errorf("throttled method error mismatch: %v", err)
+	}
+	var modules map[string]string
+	if err := client.Call(&modules, "rpc_modules"); err != nil {
+		t.Errorf("unlimited method failed: %v", err)
+	}
+}
+
+type FailingService struct{}
+
+func (s *FailingService) Fail() error { return errors.New("failed") }
+
+// Tests that failing calls are recorded in the per-method metrics.
+func TestMethodMetricsFailures(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	client := DialInProc(newTestServer("failing", new(FailingService)))
+	defer client.Close()
+
+	if err := client.Call(nil, "failing_fail"); err == nil {
+		t.Fatal("failing call succeeded")
+	}
+	if n := metrics.GetOrRegisterMeter("rpc/requests/failing_fail", nil).Count(); n != 1 {
+		t.Errorf("request count mismatch: have %d, want 1", n)
+	}
+	if n := metrics.GetOrRegisterMeter("rpc/failures/failing_fail", nil).Count(); n != 1 {
+		t.Errorf("failure count mismatch: have %d, want 1", n)
+	}
+}
+
+func TestAccessPolicyValidation(t *testing.T) {
+	invalid := []*AccessPolicy{
+		{Allow: []string{""}},
+		{Deny: []string{""}},
+		{Limits: map[string]MethodLimit{"irc_call": {Rate: -1}}},
+	}
+	for i, policy := range invalid {
+		if err := NewServer().SetAccessPolicy(policy); err == nil {
+			t.Errorf("policy %d: expected error", i)
+		}
+	}
+}
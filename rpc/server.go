@@ -24,6 +24,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/irchain/go-irchain/log"
 	"gopkg.in/fatih/set.v0"
@@ -187,6 +188,23 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 			}
 			return nil
 		}
+		// Refuse oversized batches and fail any requests the connection isn't
+		// authorized to make
+		if batch {
+			if err := s.checkBatch(reqs); err != nil {
+				resps := make([]interface{}, len(reqs))
+				for i, r := range reqs {
+					resps[i] = codec.CreateErrorResponse(&r.id, err)
+				}
+				codec.Write(resps)
+				if singleShot {
+					return nil
+				}
+				continue
+			}
+		}
+		restrictNamespaces(ctx, reqs)
+
 		// If a single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -277,9 +295,23 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, &invalidParamsError{"Expected subscription id as first argument"}), nil
 	}
 
+	// enforce the access policy before dispatching the call
+	start := time.Now()
+	if s.policy != nil {
+		release, err := s.policy.admit(ctx, req.method)
+		if err != nil {
+			methodMetrics(req.method, start, err)
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+		defer release()
+	}
+	var callErr error
+	defer func() { methodMetrics(req.method, start, callErr) }()
+
 	if req.callb.isSubscribe {
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
+			callErr = err
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
 		}
 
@@ -292,11 +324,18 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateResponse(req.id, subid), activateSub
 	}
 
-	// regular RPC call, prepare arguments
+	// regular RPC call, bound its execution time if requested
+	if s.limits.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.limits.RequestTimeout)
+		defer cancel()
+	}
+	// prepare arguments
 	if len(req.args) != len(req.callb.argTypes) {
 		rpcErr := &invalidParamsError{fmt.Sprintf("%s%s%s expects %d parameters, got %d",
 			req.svcname, serviceMethodSeparator, req.callb.method.Name,
 			len(req.callb.argTypes), len(req.args))}
+		callErr = rpcErr
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
 
@@ -310,19 +349,35 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 
 	// execute RPC method and return result
 	reply := req.callb.method.Func.Call(arguments)
+	if ctx.Err() == context.DeadlineExceeded {
+		callErr = &requestTimeoutError{}
+		return codec.CreateErrorResponse(&req.id, &requestTimeoutError{}), nil
+	}
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
-			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
-			return res, nil
+			callErr = reply[req.callb.errPos].Interface().(error)
+			return createCallbackErrorResponse(codec, &req.id, callErr), nil
 		}
 	}
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
 }
 
+// createCallbackErrorResponse creates the response for an error returned by a
+// callback. Errors carrying their own code and data are passed on as such.
+func createCallbackErrorResponse(codec ServerCodec, id interface{}, err error) interface{} {
+	rpcErr, ok := err.(Error)
+	if !ok {
+		rpcErr = &callbackError{err.Error()}
+	}
+	if de, ok := err.(DataError); ok {
+		return codec.CreateErrorResponseWithInfo(id, rpcErr, de.ErrorData())
+	}
+	return codec.CreateErrorResponse(id, rpcErr)
+}
+
 // exec executes the given request and writes the result back using the codec.
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
 	var response interface{}
@@ -332,6 +387,10 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 	} else {
 		response, callback = s.handle(ctx, codec, req)
 	}
+	if s.limits.ResponseSize > 0 {
+		var size int
+		response, _ = s.encodeResponse(codec, &req.id, response, &size)
+	}
 
 	if err := codec.Write(response); err != nil {
 		log.Error(fmt.Sprintf("%v\n", err))
@@ -347,17 +406,28 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 // execBatch executes the given requests and writes the result back using the codec.
 // It will only write the response back when the last request is processed.
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
-	responses := make([]interface{}, len(requests))
-	var callbacks []func()
+	var (
+		responses = make([]interface{}, len(requests))
+		callbacks []func()
+		size      int
+		exceeded  bool
+	)
 	for i, req := range requests {
-		if req.err != nil {
+		switch {
+		case exceeded:
+			// response size limit hit, don't execute the rest of the batch
+			responses[i] = codec.CreateErrorResponse(&req.id, errResponseTooLarge)
+		case req.err != nil:
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
-		} else {
+		default:
 			var callback func()
 			if responses[i], callback = s.handle(ctx, codec, req); callback != nil {
 				callbacks = append(callbacks, callback)
 			}
 		}
+		if s.limits.ResponseSize > 0 && !exceeded {
+			responses[i], exceeded = s.encodeResponse(codec, &req.id, responses[i], &size)
+		}
 	}
 
 	if err := codec.Write(responses); err != nil {
@@ -410,7 +480,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 
 		if r.isPubSub { // irc_subscribe, r.method contains the subscription method name
 			if callb, ok := svc.subscriptions[r.method]; ok {
-				requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
+				requests[i] = &serverRequest{id: r.id, svcname: svc.name, method: svc.name + subscribeMethodSuffix, callb: callb}
 				if r.params != nil && len(callb.argTypes) > 0 {
 					argTypes := []reflect.Type{reflect.TypeOf("")}
 					argTypes = append(argTypes, callb.argTypes...)
@@ -427,7 +497,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 		}
 
 		if callb, ok := svc.callbacks[r.method]; ok { // lookup RPC method
-			requests[i] = &serverRequest{id: r.id, svcname: svc.name, callb: callb}
+			requests[i] = &serverRequest{id: r.id, svcname: svc.name, method: svc.name + serviceMethodSeparator + r.method, callb: callb}
 			if r.params != nil && len(callb.argTypes) > 0 {
 				if args, err := codec.ParseRequestArguments(callb.argTypes, r.params); err == nil {
 					requests[i].args = args
@@ -160,3 +160,38 @@ func TestServerMethodExecution(t *testing.T) {
 func TestServerMethodWithCtx(t *testing.T) {
 	testServerMethodExecution(t, "echoWithCtx")
 }
+
+type testError struct{}
+
+func (testError) Error() string          { return "testError" }
+func (testError) ErrorCode() int         { return 444 }
+func (testError) ErrorData() interface{} { return "testError data" }
+
+type ErrorService struct{}
+
+func (s *ErrorService) ReturnError() error {
+	return testError{}
+}
+
+func TestServerErrorCodeAndData(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(ErrorService)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	client := DialInProc(server)
+	defer client.Close()
+
+	err := client.Call(nil, "test_returnError")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if e, ok := err.(Error); !ok || e.ErrorCode() != 444 {
+		t.Errorf("error code mismatch: have %v", err)
+	}
+	if e, ok := err.(DataError); !ok || e.ErrorData() != "testError data" {
+		t.Errorf("error data mismatch: have %v", err)
+	}
+	if err.Error() != "testError" {
+		t.Errorf("error message mismatch: have %q, want %q", err.Error(), "testError")
+	}
+}
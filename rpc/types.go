@@ -57,6 +57,7 @@ type service struct {
 type serverRequest struct {
 	id            interface{}
 	svcname       string
+	method        string // full method name, for access control
 	callb         *callback
 	args          []reflect.Value
 	isUnsubscribe bool
@@ -70,6 +71,8 @@ type subscriptions map[string]*callback  // collection of subscription callbacks
 // Server represents a RPC server
 type Server struct {
 	services serviceRegistry
+	policy   *accessPolicy // optional method access control and throttling
+	limits   ServerLimits  // resource limits of requests
 
 	run      int32
 	codecsMu sync.Mutex
@@ -92,6 +95,12 @@ type Error interface {
 	ErrorCode() int // returns the code
 }
 
+// A DataError contains some data in addition to the error message.
+type DataError interface {
+	Error() string          // returns the message
+	ErrorData() interface{} // returns the error data
+}
+
 // ServerCodec implements reading, parsing and writing RPC messages for the server side of
 // a RPC session. Implementations must be go-routine safe since the codec can be called in
 // multiple go-routines concurrently.
@@ -68,16 +68,30 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			decoder := func(v interface{}) error {
 				return websocketJSONCodec.Receive(conn, v)
 			}
-			srv.ServeCodec(NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
+			codec := NewCodec(conn, encoder, decoder)
+			defer codec.Close()
+
+			// Carry the client identity and restrictions of the upgrade request over
+			req := conn.Request()
+			ctx := context.WithValue(context.Background(), "remote", req.RemoteAddr)
+			if subject := req.Context().Value(authClientKey{}); subject != nil {
+				ctx = context.WithValue(ctx, authClientKey{}, subject)
+			}
+			if allowed := req.Context().Value(authNamespacesKey{}); allowed != nil {
+				ctx = context.WithValue(ctx, authNamespacesKey{}, allowed)
+			}
+			srv.serveRequest(ctx, codec, false, OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
 
-// NewWSServer creates a new websocket RPC server around an API provider.
+// NewWSServer creates a new websocket RPC server around an API provider. If
+// jwtSecret is set, connections need to be authenticated with an HS256 bearer
+// token signed with it.
 //
 // Deprecated: use Server.WebsocketHandler
-func NewWSServer(allowedOrigins []string, srv *Server) *http.Server {
-	return &http.Server{Handler: srv.WebsocketHandler(allowedOrigins)}
+func NewWSServer(allowedOrigins []string, jwtSecret []byte, srv *Server) *http.Server {
+	return &http.Server{Handler: newJWTHandler(jwtSecret, srv.WebsocketHandler(allowedOrigins))}
 }
 
 // wsHandshakeValidator returns a handler that verifies the origin during the
@@ -124,6 +138,13 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 // The context is used for the initial connection establishment. It does not
 // affect subsequent interactions with the client.
 func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error) {
+	return DialWebsocketWithAuth(ctx, endpoint, origin, nil)
+}
+
+// DialWebsocketWithAuth creates a new RPC client that communicates with a JSON-RPC
+// server that is listening on the given endpoint, authenticating every connection
+// attempt with a bearer token obtained from auth.
+func DialWebsocketWithAuth(ctx context.Context, endpoint, origin string, auth TokenProvider) (*Client, error) {
 	if origin == "" {
 		var err error
 		if origin, err = os.Hostname(); err != nil {
@@ -141,7 +162,17 @@ func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error
 	}
 
 	return newClient(ctx, func(ctx context.Context) (net.Conn, error) {
-		return wsDialContext(ctx, config)
+		if auth == nil {
+			return wsDialContext(ctx, config)
+		}
+		token, err := auth()
+		if err != nil {
+			return nil, err
+		}
+		// Fresh token for every connection, leave the shared config untouched
+		dialConfig := *config
+		dialConfig.Header = http.Header{"Authorization": {"Bearer " + token}}
+		return wsDialContext(ctx, &dialConfig)
 	})
 }
 